@@ -2,28 +2,159 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+	tradesvc "best_trade_logs/internal/service/trade"
+	"best_trade_logs/internal/web"
 )
 
 type config struct {
-	Port            string
-	MongoURI        string
-	MongoDatabase   string
-	MongoCollection string
+	Port             string
+	MongoURI         string
+	MongoDatabase    string
+	MongoCollection  string
+	RunMigrations    bool
+	BreakevenEpsilon float64
+	AdminToken       string
+	SessionSecret    string
+	RecentLimit      int
+	CORSOrigins      string
+	CORSMethods      string
+	CORSHeaders      string
+	QuoteURL         string
+	QuoteInterval    time.Duration
+	SlowRequest      time.Duration
+	FXRates          string
+	KnownSetups      string
+	Mistakes         string
+	ArchiveAfter     time.Duration
+	ArchiveInterval  time.Duration
+	ArchivedInStats  bool
+	AccountSize      float64
+	MaxLeverage      float64
+	APIPrecision     string
+	BaseCurrency     string
+	ExposureLimit    float64
+	CurrencyLimits   string
+	DashboardMetrics string
+	Annualization    string
+	FollowUpTemplate string
+	HomeView         string
+	InstrumentAlias  string
+	ShareTTL         time.Duration
+	MinSamples       int
+	ExitReasons      string
+	RiskFreeRate     float64
+	TagOrder         string
+	StaleAfter       time.Duration
+	AutoCloseStale   bool
+	AutoCloseEvery   time.Duration
+	RPrecision       int
+	MarketHours      string
+	ExportDecimal    string
+	TickSizes        string
+	DefaultTags      string
 }
 
 func loadConfig() (config, error) {
 	cfg := config{
-		Port:            getEnv("PORT", "8080"),
-		MongoURI:        os.Getenv("MONGO_URI"),
-		MongoDatabase:   os.Getenv("MONGO_DB"),
-		MongoCollection: os.Getenv("MONGO_COLLECTION"),
+		Port:             getEnv("PORT", "8080"),
+		MongoURI:         os.Getenv("MONGO_URI"),
+		MongoDatabase:    os.Getenv("MONGO_DB"),
+		MongoCollection:  os.Getenv("MONGO_COLLECTION"),
+		RunMigrations:    getEnvBool("MIGRATE", false),
+		BreakevenEpsilon: getEnvFloat("BREAKEVEN_EPSILON", domain.DefaultBreakevenEpsilon),
+		AdminToken:       os.Getenv("ADMIN_TOKEN"),
+		SessionSecret:    os.Getenv("SESSION_SECRET"),
+		RecentLimit:      getEnvInt("RECENT_TRADES_LIMIT", 5),
+		CORSOrigins:      os.Getenv("CORS_ALLOWED_ORIGINS"),
+		CORSMethods:      os.Getenv("CORS_ALLOWED_METHODS"),
+		CORSHeaders:      os.Getenv("CORS_ALLOWED_HEADERS"),
+		QuoteURL:         os.Getenv("QUOTE_URL"),
+		QuoteInterval:    getEnvDuration("QUOTE_POLL_INTERVAL", 6*time.Hour),
+		SlowRequest:      getEnvDuration("SLOW_REQUEST_THRESHOLD", web.DefaultSlowRequestThreshold),
+		FXRates:          os.Getenv("FX_RATES"),
+		KnownSetups:      os.Getenv("KNOWN_SETUPS"),
+		BaseCurrency:     os.Getenv("BASE_CURRENCY"),
+		ExposureLimit:    getEnvFloat("EXPOSURE_LIMIT", 0),
+		CurrencyLimits:   os.Getenv("CURRENCY_EXPOSURE_LIMITS"),
+		ArchiveAfter:     getEnvDuration("AUTO_ARCHIVE_AFTER", 0),
+		ArchiveInterval:  getEnvDuration("AUTO_ARCHIVE_INTERVAL", 24*time.Hour),
+		ArchivedInStats:  getEnvBool("ARCHIVED_IN_STATS", false),
+		AccountSize:      getEnvFloat("ACCOUNT_SIZE", 0),
+		MaxLeverage:      getEnvFloat("MAX_LEVERAGE", 0),
+		APIPrecision:     os.Getenv("API_PRECISION"),
+		Mistakes:         getEnv("MISTAKES", strings.Join(tradesvc.DefaultMistakeChecklist, ",")),
+		DashboardMetrics: os.Getenv("DASHBOARD_METRICS"),
+		Annualization:    getEnv("ANNUALIZATION", web.AnnualizeSimple),
+		FollowUpTemplate: os.Getenv("FOLLOW_UP_TEMPLATE"),
+		HomeView:         getEnv("HOME_VIEW", web.HomeViewHistory),
+		InstrumentAlias:  os.Getenv("INSTRUMENT_ALIASES"),
+		ShareTTL:         getEnvDuration("SHARE_TTL", tradesvc.DefaultShareTTL),
+		MinSamples:       getEnvInt("MIN_SAMPLES", web.DefaultMinSamples),
+		ExitReasons:      os.Getenv("EXIT_REASONS"),
+		RiskFreeRate:     getEnvFloat("RISK_FREE_RATE", 0),
+		TagOrder:         getEnv("TAG_ORDER", web.TagOrderAlpha),
+		StaleAfter:       getEnvDuration("STALE_AFTER", 0),
+		AutoCloseStale:   getEnvBool("AUTO_CLOSE_STALE", false),
+		AutoCloseEvery:   getEnvDuration("AUTO_CLOSE_INTERVAL", 24*time.Hour),
+		RPrecision:       getEnvInt("R_PRECISION", web.DefaultRPrecision),
+		MarketHours:      os.Getenv("MARKET_HOURS"),
+		ExportDecimal:    getEnv("EXPORT_DECIMAL", web.ExportDecimalPoint),
+		TickSizes:        os.Getenv("TICK_SIZES"),
+		DefaultTags:      os.Getenv("DEFAULT_TAGS"),
 	}
 
 	flag.StringVar(&cfg.Port, "port", cfg.Port, "HTTP port to listen on")
 	flag.StringVar(&cfg.MongoURI, "mongo-uri", cfg.MongoURI, "MongoDB connection URI")
 	flag.StringVar(&cfg.MongoDatabase, "mongo-db", cfg.MongoDatabase, "MongoDB database name")
 	flag.StringVar(&cfg.MongoCollection, "mongo-collection", cfg.MongoCollection, "MongoDB collection name")
+	flag.BoolVar(&cfg.RunMigrations, "migrate", cfg.RunMigrations, "Run data migrations on startup before serving")
+	flag.Float64Var(&cfg.BreakevenEpsilon, "breakeven-epsilon", cfg.BreakevenEpsilon, "Net result tolerance treated as breakeven")
+	flag.StringVar(&cfg.AdminToken, "admin-token", cfg.AdminToken, "Bearer token required by /admin endpoints (disabled when empty)")
+	flag.StringVar(&cfg.SessionSecret, "session-secret", cfg.SessionSecret, "Secret used to sign session cookies (random when empty)")
+	flag.IntVar(&cfg.RecentLimit, "recent-limit", cfg.RecentLimit, "Number of recently viewed trades to remember (0 disables)")
+	flag.StringVar(&cfg.CORSOrigins, "cors-origins", cfg.CORSOrigins, "Comma separated origins allowed to call /api (same-origin only when empty)")
+	flag.StringVar(&cfg.CORSMethods, "cors-methods", cfg.CORSMethods, "Comma separated methods allowed for cross-origin API calls")
+	flag.StringVar(&cfg.CORSHeaders, "cors-headers", cfg.CORSHeaders, "Comma separated request headers allowed for cross-origin API calls")
+	flag.StringVar(&cfg.QuoteURL, "quote-url", cfg.QuoteURL, "Close price endpoint template with {symbol} and {date} placeholders (auto follow-ups disabled when empty)")
+	flag.DurationVar(&cfg.QuoteInterval, "quote-interval", cfg.QuoteInterval, "How often to fetch automatic follow-up prices")
+	flag.DurationVar(&cfg.SlowRequest, "slow-request", cfg.SlowRequest, "Log a warning for requests slower than this (0 disables)")
+	flag.StringVar(&cfg.FXRates, "fx-rates", cfg.FXRates, "Comma separated FROM/TO=rate pairs used to convert fees, e.g. EUR/USD=1.08")
+	flag.StringVar(&cfg.KnownSetups, "setups", cfg.KnownSetups, "Comma separated list of known setups shown as a dropdown (free text when empty)")
+	flag.StringVar(&cfg.ExitReasons, "exit-reasons", cfg.ExitReasons, "Comma separated list of standard exit reasons shown as a dropdown (free text when empty)")
+	flag.StringVar(&cfg.BaseCurrency, "base-currency", cfg.BaseCurrency, "Currency the total open exposure limit is expressed in")
+	flag.Float64Var(&cfg.ExposureLimit, "exposure-limit", cfg.ExposureLimit, "Warn when total open gross exposure in the base currency exceeds this (0 disables)")
+	flag.StringVar(&cfg.CurrencyLimits, "currency-exposure-limits", cfg.CurrencyLimits, "Comma separated CUR=limit open exposure caps per currency, e.g. USD=100000")
+	flag.DurationVar(&cfg.ArchiveAfter, "auto-archive-after", cfg.ArchiveAfter, "Archive closed trades that exited longer ago than this (0 disables)")
+	flag.DurationVar(&cfg.ArchiveInterval, "auto-archive-interval", cfg.ArchiveInterval, "How often to run the auto-archive job")
+	flag.DurationVar(&cfg.StaleAfter, "stale-after", cfg.StaleAfter, "List open trades entered longer ago than this on the dashboard (0 disables)")
+	flag.BoolVar(&cfg.AutoCloseStale, "auto-close-stale", cfg.AutoCloseStale, "Close trades older than --stale-after at their mark price with the exit reason \"auto-closed (stale)\"")
+	flag.DurationVar(&cfg.AutoCloseEvery, "auto-close-interval", cfg.AutoCloseEvery, "How often to run the stale trade auto-close job")
+	flag.BoolVar(&cfg.ArchivedInStats, "archived-in-stats", cfg.ArchivedInStats, "Include archived trades in dashboard statistics")
+	flag.Float64Var(&cfg.RiskFreeRate, "risk-free-rate", cfg.RiskFreeRate, "Annual risk-free rate in percent subtracted from trade returns, pro-rated by hold time, for the Sharpe ratio")
+	flag.Float64Var(&cfg.AccountSize, "account-size", cfg.AccountSize, "Account equity used for effective leverage when a trade records none (0 disables)")
+	flag.Float64Var(&cfg.MaxLeverage, "max-leverage", cfg.MaxLeverage, "Flag trades whose effective leverage exceeds this (0 disables)")
+	flag.StringVar(&cfg.APIPrecision, "api-precision", cfg.APIPrecision, "Comma separated JSON API rounding, off by default, e.g. price=tick,amount=2 (price rounds to the instrument tick; quantity, amount and ratio take decimals)")
+	flag.StringVar(&cfg.Mistakes, "mistakes", cfg.Mistakes, "Comma separated checklist of common mistakes shown on the trade form")
+	flag.StringVar(&cfg.DashboardMetrics, "dashboard-metrics", cfg.DashboardMetrics, "Comma separated dashboard panels to show, in order ("+strings.Join(web.DashboardMetricKeys(), ", ")+"); all when empty")
+	flag.StringVar(&cfg.Annualization, "annualization", cfg.Annualization, "How to annualize trade returns: simple, compounded or both")
+	flag.StringVar(&cfg.FollowUpTemplate, "follow-up-template", cfg.FollowUpTemplate, `Text pre-filled in the follow-up notes field; "\n" starts a new line`)
+	flag.StringVar(&cfg.HomeView, "home-view", cfg.HomeView, "What / shows without filters: history or open")
+	flag.IntVar(&cfg.RPrecision, "r-precision", cfg.RPrecision, "Decimals R multiples are displayed with (0-4)")
+	flag.StringVar(&cfg.TagOrder, "tag-order", cfg.TagOrder, "How the tag filter and tag cloud list tags: alpha or usage (most used first)")
+	flag.DurationVar(&cfg.ShareTTL, "share-ttl", cfg.ShareTTL, "How long read-only share links stay valid")
+	flag.IntVar(&cfg.MinSamples, "min-samples", cfg.MinSamples, "Observations a dashboard statistic or setup ranking needs before it is shown")
+	flag.StringVar(&cfg.InstrumentAlias, "instrument-aliases", cfg.InstrumentAlias, "Comma separated alias=symbol pairs stored under one instrument, e.g. TSM=2330")
+	flag.StringVar(&cfg.MarketHours, "market-hours", cfg.MarketHours, "Comma separated MARKET=HH:MM-HH:MM regular session hours used to classify entries into pre-market, regular and after-hours, e.g. US=09:30-16:00 (\"*\" for all other markets)")
+	flag.StringVar(&cfg.ExportDecimal, "export-decimal", cfg.ExportDecimal, "Default decimal separator of the CSV export: point or comma (semicolon separated fields)")
+	flag.StringVar(&cfg.TickSizes, "tick-sizes", cfg.TickSizes, "Comma separated instrument=tick pairs; prices of these instruments are snapped to the nearest tick on save, e.g. AAPL=0.01,ES=0.25")
+	flag.StringVar(&cfg.DefaultTags, "default-tags", cfg.DefaultTags, "Comma separated tags added to every new trade, e.g. 2024")
 	flag.Parse()
 
 	if cfg.Port == "" {
@@ -32,6 +163,48 @@ func loadConfig() (config, error) {
 	if cfg.MongoCollection == "" {
 		cfg.MongoCollection = "trades"
 	}
+	if cfg.QuoteURL != "" && cfg.QuoteInterval <= 0 {
+		return cfg, fmt.Errorf("quote interval must be positive")
+	}
+	if _, err := domain.ParseFXRates(cfg.FXRates); err != nil {
+		return cfg, err
+	}
+	if _, err := domain.ParseMarketHours(cfg.MarketHours); err != nil {
+		return cfg, err
+	}
+	if _, err := tradesvc.ParseCurrencyLimits(cfg.CurrencyLimits); err != nil {
+		return cfg, err
+	}
+	if _, err := tradesvc.ParseInstrumentAliases(cfg.InstrumentAlias); err != nil {
+		return cfg, err
+	}
+	if _, err := tradesvc.ParseTickSizes(cfg.TickSizes); err != nil {
+		return cfg, err
+	}
+	if cfg.ArchiveAfter < 0 || (cfg.ArchiveAfter > 0 && cfg.ArchiveInterval <= 0) {
+		return cfg, fmt.Errorf("auto archive age and interval must be positive")
+	}
+	if cfg.StaleAfter < 0 || (cfg.AutoCloseStale && (cfg.StaleAfter == 0 || cfg.AutoCloseEvery <= 0)) {
+		return cfg, fmt.Errorf("auto close of stale trades needs a positive stale age and interval")
+	}
+	if _, err := web.ParseAPIPrecision(cfg.APIPrecision); err != nil {
+		return cfg, err
+	}
+	if cfg.AccountSize < 0 || cfg.MaxLeverage < 0 {
+		return cfg, fmt.Errorf("account size and max leverage must not be negative")
+	}
+	if cfg.ExposureLimit < 0 {
+		return cfg, fmt.Errorf("exposure limit must not be negative")
+	}
+	if cfg.MinSamples < 1 {
+		return cfg, fmt.Errorf("min samples must be at least 1")
+	}
+	if cfg.ShareTTL <= 0 {
+		return cfg, fmt.Errorf("share ttl must be positive")
+	}
+	if cfg.BreakevenEpsilon < 0 {
+		return cfg, fmt.Errorf("breakeven epsilon must not be negative")
+	}
 
 	return cfg, nil
 }
@@ -42,3 +215,58 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvBool(key string, fallback bool) bool {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(val)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvInt(key string, fallback int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(val)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+func splitList(val string) []string {
+	if strings.TrimSpace(val) == "" {
+		return nil
+	}
+	return strings.Split(val, ",")
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(val)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
@@ -8,10 +8,21 @@ import (
 	"syscall"
 	"time"
 
+	domain "best_trade_logs/internal/domain/trade"
+	"best_trade_logs/internal/quotes"
 	tradesvc "best_trade_logs/internal/service/trade"
+	"best_trade_logs/internal/storage"
 	"best_trade_logs/internal/web"
 )
 
+// repositories holds the stores the service persists to; setupRepository
+// builds them for the selected backend.
+type repositories struct {
+	trades   storage.TradeRepository
+	views    storage.SavedViewRepository
+	mappings storage.ImportMappingRepository
+}
+
 func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -21,14 +32,85 @@ func main() {
 		log.Fatalf("failed to load configuration: %v", err)
 	}
 
-	repo, cleanup, err := setupRepository(ctx, cfg)
+	repos, cleanup, err := setupRepository(ctx, cfg)
 	if err != nil {
 		log.Fatalf("failed to setup repository: %v", err)
 	}
 	defer cleanup()
 
-	svc := tradesvc.NewService(repo)
-	server, err := web.NewServer(svc)
+	rates, err := domain.ParseFXRates(cfg.FXRates)
+	if err != nil {
+		log.Fatalf("failed to parse fx rates: %v", err)
+	}
+	currencyLimits, err := tradesvc.ParseCurrencyLimits(cfg.CurrencyLimits)
+	if err != nil {
+		log.Fatalf("failed to parse exposure limits: %v", err)
+	}
+	aliases, err := tradesvc.ParseInstrumentAliases(cfg.InstrumentAlias)
+	if err != nil {
+		log.Fatalf("failed to parse instrument aliases: %v", err)
+	}
+	tickSizes, err := tradesvc.ParseTickSizes(cfg.TickSizes)
+	if err != nil {
+		log.Fatalf("failed to parse tick sizes: %v", err)
+	}
+	svc := tradesvc.NewService(repos.trades,
+		tradesvc.WithSavedViews(repos.views),
+		tradesvc.WithImportMappings(repos.mappings),
+		tradesvc.WithFXRates(rates),
+		tradesvc.WithKnownSetups(splitList(cfg.KnownSetups)),
+		tradesvc.WithExitReasons(splitList(cfg.ExitReasons)),
+		tradesvc.WithMistakeChecklist(splitList(cfg.Mistakes)),
+		tradesvc.WithExposureLimits(tradesvc.ExposureLimits{Base: cfg.BaseCurrency, Total: cfg.ExposureLimit, PerCurrency: currencyLimits}),
+		tradesvc.WithInstrumentAliases(aliases),
+		tradesvc.WithTickSizes(tickSizes),
+		tradesvc.WithDefaultTags(splitList(cfg.DefaultTags)),
+	)
+	if cfg.RunMigrations {
+		if _, err := svc.RunMigrations(ctx, tradesvc.Migrations); err != nil {
+			log.Fatalf("failed to run migrations: %v", err)
+		}
+	}
+	if cfg.QuoteURL != "" {
+		go svc.RunAutoFollowUps(ctx, quotes.NewHTTPProvider(cfg.QuoteURL), cfg.QuoteInterval)
+	}
+	if cfg.ArchiveAfter > 0 {
+		go svc.RunAutoArchive(ctx, cfg.ArchiveAfter, cfg.ArchiveInterval)
+	}
+	if cfg.AutoCloseStale {
+		go svc.RunAutoCloseStale(ctx, cfg.StaleAfter, cfg.AutoCloseEvery)
+	}
+
+	precision, err := web.ParseAPIPrecision(cfg.APIPrecision)
+	if err != nil {
+		log.Fatalf("failed to parse api precision: %v", err)
+	}
+	marketHours, err := domain.ParseMarketHours(cfg.MarketHours)
+	if err != nil {
+		log.Fatalf("failed to parse market hours: %v", err)
+	}
+	server, err := web.NewServer(svc,
+		web.WithBreakevenEpsilon(cfg.BreakevenEpsilon),
+		web.WithAdminToken(cfg.AdminToken),
+		web.WithRecentlyViewed(cfg.SessionSecret, cfg.RecentLimit),
+		web.WithCORS(splitList(cfg.CORSOrigins), splitList(cfg.CORSMethods), splitList(cfg.CORSHeaders)),
+		web.WithSlowRequestThreshold(cfg.SlowRequest),
+		web.WithDashboardMetrics(splitList(cfg.DashboardMetrics)),
+		web.WithAnnualization(cfg.Annualization),
+		web.WithArchivedInStats(cfg.ArchivedInStats),
+		web.WithLeverage(cfg.AccountSize, cfg.MaxLeverage),
+		web.WithAPIPrecision(precision),
+		web.WithFollowUpTemplate(cfg.FollowUpTemplate),
+		web.WithHomeView(cfg.HomeView),
+		web.WithShareTTL(cfg.ShareTTL),
+		web.WithMinSamples(cfg.MinSamples),
+		web.WithRiskFreeRate(cfg.RiskFreeRate),
+		web.WithTagOrder(cfg.TagOrder),
+		web.WithStaleAfter(cfg.StaleAfter),
+		web.WithRPrecision(cfg.RPrecision),
+		web.WithMarketHours(marketHours),
+		web.WithExportDecimal(cfg.ExportDecimal),
+	)
 	if err != nil {
 		log.Fatalf("failed to create server: %v", err)
 	}
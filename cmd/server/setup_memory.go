@@ -8,8 +8,12 @@ import (
 	"best_trade_logs/internal/storage"
 )
 
-func setupRepository(_ context.Context, _ config) (storage.TradeRepository, func(), error) {
-	repo := storage.NewInMemoryTradeRepository()
+func setupRepository(_ context.Context, _ config) (repositories, func(), error) {
+	repos := repositories{
+		trades:   storage.NewInMemoryTradeRepository(),
+		views:    storage.NewInMemorySavedViewRepository(),
+		mappings: storage.NewInMemoryImportMappingRepository(),
+	}
 	cleanup := func() {}
-	return repo, cleanup, nil
+	return repos, cleanup, nil
 }
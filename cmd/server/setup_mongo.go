@@ -12,37 +12,54 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func setupRepository(ctx context.Context, cfg config) (storage.TradeRepository, func(), error) {
+// Collections for the saved trade list views and CSV import mappings, next to
+// the trades in the configured database.
+const (
+	savedViewCollection     = "saved_views"
+	importMappingCollection = "import_mappings"
+)
+
+func setupRepository(ctx context.Context, cfg config) (repositories, func(), error) {
 	if cfg.MongoURI == "" {
-		return nil, nil, fmt.Errorf("mongo URI not provided; set MONGO_URI or use --mongo-uri flag")
+		return repositories{}, nil, fmt.Errorf("mongo URI not provided; set MONGO_URI or use --mongo-uri flag")
 	}
 	if cfg.MongoDatabase == "" {
-		return nil, nil, fmt.Errorf("mongo database not provided; set MONGO_DB or use --mongo-db flag")
+		return repositories{}, nil, fmt.Errorf("mongo database not provided; set MONGO_DB or use --mongo-db flag")
 	}
 
 	client, err := mongo.NewClient(options.Client().ApplyURI(cfg.MongoURI))
 	if err != nil {
-		return nil, nil, err
+		return repositories{}, nil, err
 	}
 	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	if err := client.Connect(connectCtx); err != nil {
-		return nil, nil, err
+		return repositories{}, nil, err
 	}
 	if err := client.Ping(connectCtx, nil); err != nil {
 		_ = client.Disconnect(connectCtx)
-		return nil, nil, err
+		return repositories{}, nil, err
 	}
 
 	repo, err := storage.NewMongoTradeRepository(client, cfg.MongoDatabase, cfg.MongoCollection)
 	if err != nil {
 		_ = client.Disconnect(connectCtx)
-		return nil, nil, err
+		return repositories{}, nil, err
+	}
+	views, err := storage.NewMongoSavedViewRepository(client, cfg.MongoDatabase, savedViewCollection)
+	if err != nil {
+		_ = client.Disconnect(connectCtx)
+		return repositories{}, nil, err
+	}
+	mappings, err := storage.NewMongoImportMappingRepository(client, cfg.MongoDatabase, importMappingCollection)
+	if err != nil {
+		_ = client.Disconnect(connectCtx)
+		return repositories{}, nil, err
 	}
 	cleanup := func() {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		_ = client.Disconnect(shutdownCtx)
 	}
-	return repo, cleanup, nil
+	return repositories{trades: repo, views: views, mappings: mappings}, cleanup, nil
 }
@@ -0,0 +1,79 @@
+package trade
+
+import "time"
+
+// Clone returns a deep copy of the trade: slices and pointer fields are copied
+// too, so changing the clone never changes t. New pointer or slice fields must
+// be added here.
+func (t *Trade) Clone() *Trade {
+	if t == nil {
+		return nil
+	}
+	cp := *t
+	cp.FeeFXRate = cloneFloat(t.FeeFXRate)
+	cp.Entry.StopLoss = cloneFloat(t.Entry.StopLoss)
+	cp.Entry.Target = cloneFloat(t.Entry.Target)
+	cp.Entry.RiskPerShare = cloneFloat(t.Entry.RiskPerShare)
+	cp.Entry.PlannedEntryPrice = cloneFloat(t.Entry.PlannedEntryPrice)
+	cp.Entry.Fills = cloneSlice(t.Entry.Fills)
+	cp.Exit = cloneExit(t.Exit)
+	cp.RiskManagement.WinProbability = cloneFloat(t.RiskManagement.WinProbability)
+	cp.FollowUps = cloneSlice(t.FollowUps)
+	cp.Review.Tags = cloneSlice(t.Review.Tags)
+	cp.Review.Mistakes = cloneSlice(t.Review.Mistakes)
+	if t.Review.FollowedPlan != nil {
+		followed := *t.Review.FollowedPlan
+		cp.Review.FollowedPlan = &followed
+	}
+	cp.Events = cloneSlice(t.Events)
+	for i := range cp.Events {
+		cp.Events[i].Exit = cloneExit(t.Events[i].Exit)
+	}
+	cp.DeletedAt = cloneTime(t.DeletedAt)
+	cp.ArchivedAt = cloneTime(t.ArchivedAt)
+	cp.TimeStopDate = cloneTime(t.TimeStopDate)
+	cp.ExecutionScore = cloneFloat(t.ExecutionScore)
+	cp.ConfidenceBefore = cloneFloat(t.ConfidenceBefore)
+	cp.ConfidenceAfter = cloneFloat(t.ConfidenceAfter)
+	cp.AccountSizeAtEntry = cloneFloat(t.AccountSizeAtEntry)
+	cp.MarkPrice = cloneFloat(t.MarkPrice)
+	if t.ExpectedHoldDays != nil {
+		expected := *t.ExpectedHoldDays
+		cp.ExpectedHoldDays = &expected
+	}
+	cp.Shares = cloneSlice(t.Shares)
+	return &cp
+}
+
+func cloneFloat(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	cp := *v
+	return &cp
+}
+
+func cloneTime(v *time.Time) *time.Time {
+	if v == nil {
+		return nil
+	}
+	cp := *v
+	return &cp
+}
+
+func cloneExit(exit *ExitDetail) *ExitDetail {
+	if exit == nil {
+		return nil
+	}
+	cp := *exit
+	cp.Fills = cloneSlice(exit.Fills)
+	return &cp
+}
+
+// cloneSlice copies s, keeping nil and empty slices apart.
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
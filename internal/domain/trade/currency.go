@@ -0,0 +1,70 @@
+package trade
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FXRates maps a "FROM/TO" currency pair to the amount of TO per unit of FROM.
+type FXRates map[string]float64
+
+// Rate returns the conversion rate from one currency to another, falling back to
+// the inverse pair when only that one is configured.
+func (r FXRates) Rate(from, to string) (float64, bool) {
+	from = NormalizeCurrency(from)
+	to = NormalizeCurrency(to)
+	if from == to {
+		return 1, true
+	}
+	if rate, ok := r[from+"/"+to]; ok && rate > 0 {
+		return rate, true
+	}
+	if rate, ok := r[to+"/"+from]; ok && rate > 0 {
+		return 1 / rate, true
+	}
+	return 0, false
+}
+
+// ParseFXRates parses a comma separated list such as "EUR/USD=1.08,USD/TWD=32".
+func ParseFXRates(raw string) (FXRates, error) {
+	rates := FXRates{}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pair, value, ok := strings.Cut(item, "=")
+		from, to, okPair := strings.Cut(pair, "/")
+		if !ok || !okPair {
+			return nil, fmt.Errorf("invalid fx rate %q", item)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid fx rate %q", item)
+		}
+		rates[NormalizeCurrency(from)+"/"+NormalizeCurrency(to)] = rate
+	}
+	return rates, nil
+}
+
+// NormalizeCurrency trims and upper-cases a currency code.
+func NormalizeCurrency(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ChargesFeesInOtherCurrency reports whether fees are charged in a currency that
+// differs from the trade currency.
+func (t Trade) ChargesFeesInOtherCurrency() bool {
+	fee := NormalizeCurrency(t.FeeCurrency)
+	return fee != "" && fee != NormalizeCurrency(t.Currency)
+}
+
+// feeInTradeCurrency converts a fee amount into the trade currency using the
+// stored FeeFXRate. Fees are taken as-is when no conversion applies.
+func (t Trade) feeInTradeCurrency(fee float64) float64 {
+	if !t.ChargesFeesInOtherCurrency() || t.FeeFXRate == nil || *t.FeeFXRate <= 0 {
+		return fee
+	}
+	return fee * *t.FeeFXRate
+}
@@ -0,0 +1,87 @@
+package trade
+
+import (
+	"sort"
+	"time"
+)
+
+// Fill is one execution of a scaled entry or exit.
+type Fill struct {
+	Date     time.Time `bson:"date" json:"date"`
+	Price    float64   `bson:"price" json:"price"`
+	Quantity float64   `bson:"quantity" json:"quantity"`
+}
+
+// vwap returns the volume-weighted average price and total quantity of the
+// fills with a positive quantity; ok is false when there are none.
+func vwap(fills []Fill) (price, quantity float64, ok bool) {
+	var notional float64
+	for _, f := range fills {
+		if f.Quantity <= 0 {
+			continue
+		}
+		notional += f.Price * f.Quantity
+		quantity += f.Quantity
+	}
+	if quantity == 0 {
+		return 0, 0, false
+	}
+	return notional / quantity, quantity, true
+}
+
+// AvgEntryPrice is the volume-weighted average price of the entry fills, or
+// Entry.Price for a single-fill entry. All result metrics are based on it.
+func (t Trade) AvgEntryPrice() float64 {
+	if price, _, ok := vwap(t.Entry.Fills); ok {
+		return price
+	}
+	return t.Entry.Price
+}
+
+// AvgExitPrice is the volume-weighted average price of the exit fills, or
+// Exit.Price for a single-fill exit, and 0 while the trade is open.
+func (t Trade) AvgExitPrice() float64 {
+	if t.Exit == nil {
+		return 0
+	}
+	if price, _, ok := vwap(t.Exit.Fills); ok {
+		return price
+	}
+	return t.Exit.Price
+}
+
+// HasFills reports whether the entry or the exit was scaled over several fills.
+func (t Trade) HasFills() bool {
+	return len(t.Entry.Fills) > 0 || (t.Exit != nil && len(t.Exit.Fills) > 0)
+}
+
+// SyncFills copies the fills into the summary fields: Price becomes the
+// average price, Quantity the filled quantity and Date the first entry fill or
+// last exit fill. Entries and exits without fills are left alone.
+func (t *Trade) SyncFills() {
+	if price, qty, ok := vwap(t.Entry.Fills); ok {
+		sortFills(t.Entry.Fills)
+		t.Entry.Price = price
+		t.Entry.Quantity = qty
+		if first := t.Entry.Fills[0].Date; !first.IsZero() {
+			t.Entry.Date = first
+		}
+	}
+	if t.Exit == nil {
+		return
+	}
+	if price, qty, ok := vwap(t.Exit.Fills); ok {
+		sortFills(t.Exit.Fills)
+		t.Exit.Price = price
+		t.Exit.Quantity = qty
+		if last := t.Exit.Fills[len(t.Exit.Fills)-1].Date; !last.IsZero() {
+			t.Exit.Date = last
+		}
+	}
+}
+
+func sortFills(fills []Fill) {
+	sort.SliceStable(fills, func(i, j int) bool {
+		return fills[i].Date.Before(fills[j].Date)
+	})
+}
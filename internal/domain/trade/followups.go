@@ -0,0 +1,38 @@
+package trade
+
+import "sort"
+
+// SortedFollowUps returns the follow-ups in timeline order: by DaysAfter, with
+// observations orphaned by a reopened exit before current ones and, within
+// those, oldest log first. t.FollowUps itself is left untouched.
+func (t Trade) SortedFollowUps() []FollowUp {
+	sorted := append([]FollowUp(nil), t.FollowUps...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.DaysAfter != b.DaysAfter {
+			return a.DaysAfter < b.DaysAfter
+		}
+		if a.Orphaned != b.Orphaned {
+			return a.Orphaned
+		}
+		return a.LoggedAt.Before(b.LoggedAt)
+	})
+	return sorted
+}
+
+// CompactFollowUps returns the follow-ups in timeline order with at most one
+// observation per day count, keeping the most recently logged one (the later
+// entry on a tie). Orphaned observations are compacted separately from
+// current ones so a reopened trade keeps its history.
+func (t Trade) CompactFollowUps() []FollowUp {
+	sorted := t.SortedFollowUps()
+	compact := sorted[:0]
+	for _, f := range sorted {
+		if n := len(compact); n > 0 && compact[n-1].DaysAfter == f.DaysAfter && compact[n-1].Orphaned == f.Orphaned {
+			compact[n-1] = f
+			continue
+		}
+		compact = append(compact, f)
+	}
+	return compact
+}
@@ -0,0 +1,23 @@
+package trade
+
+import "math"
+
+// MoneyScale is the number of minor units per currency unit in Money. Ten
+// thousandths keep sub-cent fees and forex results intact while still summing
+// exactly.
+const MoneyScale = 10000
+
+// Money is an amount in integer minor units. Trade results are computed in
+// float64; converting each one to Money before adding them up keeps long sums
+// such as totals and equity curves free of accumulated floating-point error.
+type Money int64
+
+// ToMoney rounds an amount to the nearest minor unit.
+func ToMoney(amount float64) Money {
+	return Money(math.Round(amount * MoneyScale))
+}
+
+// Float64 converts the amount back into currency units.
+func (m Money) Float64() float64 {
+	return float64(m) / MoneyScale
+}
@@ -0,0 +1,116 @@
+package trade
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Session is the part of the trading day a trade was entered in.
+type Session string
+
+const (
+	SessionPreMarket  Session = "PRE_MARKET"
+	SessionRegular    Session = "REGULAR"
+	SessionAfterHours Session = "AFTER_HOURS"
+)
+
+// Sessions lists the sessions in the order of the trading day.
+var Sessions = []Session{SessionPreMarket, SessionRegular, SessionAfterHours}
+
+// Valid reports whether s is one of the known sessions.
+func (s Session) Valid() bool {
+	switch s {
+	case SessionPreMarket, SessionRegular, SessionAfterHours:
+		return true
+	}
+	return false
+}
+
+// AnyMarket is the MarketHours key used for markets without hours of their own.
+const AnyMarket = "*"
+
+// SessionHours is a market's regular session as offsets from midnight in the
+// exchange's local time.
+type SessionHours struct {
+	Open  time.Duration
+	Close time.Duration
+}
+
+// MarketHours maps an upper-cased market name to its regular session.
+type MarketHours map[string]SessionHours
+
+// For returns the hours of a market, falling back to the AnyMarket entry.
+func (h MarketHours) For(market string) (SessionHours, bool) {
+	if hours, ok := h[strings.ToUpper(strings.TrimSpace(market))]; ok {
+		return hours, true
+	}
+	hours, ok := h[AnyMarket]
+	return hours, ok
+}
+
+// ParseMarketHours parses a comma separated list such as
+// "US=09:30-16:00,TW=09:00-13:30"; the market "*" applies to all others.
+func ParseMarketHours(raw string) (MarketHours, error) {
+	hours := MarketHours{}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		market, span, ok := strings.Cut(item, "=")
+		open, closing, okSpan := strings.Cut(span, "-")
+		market = strings.ToUpper(strings.TrimSpace(market))
+		if !ok || !okSpan || market == "" {
+			return nil, fmt.Errorf("invalid market hours %q", item)
+		}
+		openAt, errOpen := parseClock(open)
+		closeAt, errClose := parseClock(closing)
+		if errOpen != nil || errClose != nil || closeAt <= openAt {
+			return nil, fmt.Errorf("invalid market hours %q", item)
+		}
+		hours[market] = SessionHours{Open: openAt, Close: closeAt}
+	}
+	return hours, nil
+}
+
+func parseClock(raw string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(raw))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// HasEntryTime reports whether the entry records a time of day. Entries logged
+// with a date only are stored at midnight.
+func (t Trade) HasEntryTime() bool {
+	h, m, s := t.Entry.Date.Clock()
+	return h != 0 || m != 0 || s != 0
+}
+
+// TradingSession returns the session the trade was entered in: the manually
+// selected Session when set, otherwise the one the entry time falls in for the
+// trade's market. Entry times are taken as the exchange's local time. ok is
+// false when neither is known.
+func (t Trade) TradingSession(hours MarketHours) (Session, bool) {
+	if t.Session.Valid() {
+		return t.Session, true
+	}
+	if !t.HasEntryTime() {
+		return "", false
+	}
+	market, ok := hours.For(t.Market)
+	if !ok {
+		return "", false
+	}
+	h, m, s := t.Entry.Date.Clock()
+	at := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+	switch {
+	case at < market.Open:
+		return SessionPreMarket, true
+	case at >= market.Close:
+		return SessionAfterHours, true
+	}
+	return SessionRegular, true
+}
@@ -1,8 +1,12 @@
 package trade
 
 import (
+	"fmt"
 	"math"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // Direction represents the direction of a trade (long or short).
@@ -13,78 +17,232 @@ const (
 	DirectionShort Direction = "SHORT"
 )
 
+// Outcome classifies the realised result of a trade.
+type Outcome string
+
+const (
+	OutcomeOpen      Outcome = "OPEN"
+	OutcomeWin       Outcome = "WIN"
+	OutcomeLoss      Outcome = "LOSS"
+	OutcomeBreakeven Outcome = "BREAKEVEN"
+)
+
+// FeeModel selects how entry and exit fees are determined.
+type FeeModel string
+
+const (
+	// FeeModelFlat uses the fee amounts recorded on the entry and exit as-is.
+	FeeModelFlat FeeModel = ""
+	// FeeModelPercent charges FeeRate times the notional on each side.
+	FeeModelPercent FeeModel = "PERCENT"
+)
+
+// DefaultBreakevenEpsilon is the default tolerance (in currency units) within which
+// a closed trade's net result is treated as breakeven.
+const DefaultBreakevenEpsilon = 0.01
+
 // EntryDetail captures information about entering a trade.
 type EntryDetail struct {
-	Date         time.Time `bson:"date"`
-	Price        float64   `bson:"price"`
-	Quantity     float64   `bson:"quantity"`
-	Fees         float64   `bson:"fees"`
-	StopLoss     *float64  `bson:"stop_loss"`
-	Target       *float64  `bson:"target"`
-	RiskPerShare *float64  `bson:"risk_per_share"`
-	Notes        string    `bson:"notes"`
+	Date         time.Time `bson:"date" json:"date"`
+	Price        float64   `bson:"price" json:"price"`
+	Quantity     float64   `bson:"quantity" json:"quantity"`
+	Fees         float64   `bson:"fees" json:"fees"`
+	StopLoss     *float64  `bson:"stop_loss" json:"stop_loss"`
+	Target       *float64  `bson:"target" json:"target"`
+	RiskPerShare *float64  `bson:"risk_per_share" json:"risk_per_share"`
+	Notes        string    `bson:"notes" json:"notes"`
+	// PlannedEntryPrice is the intended entry (for example the limit price) when
+	// the actual fill differs from it.
+	PlannedEntryPrice *float64 `bson:"planned_entry_price,omitempty" json:"planned_entry_price,omitempty"`
+	// Fills lists the executions of a scaled entry; Price and Quantity then
+	// hold their average price and total (see SyncFills).
+	Fills []Fill `bson:"fills,omitempty" json:"fills,omitempty"`
 }
 
 // ExitDetail captures information when closing a trade.
 type ExitDetail struct {
-	Date     time.Time `bson:"date"`
-	Price    float64   `bson:"price"`
-	Quantity float64   `bson:"quantity"`
-	Fees     float64   `bson:"fees"`
-	Reason   string    `bson:"reason"`
-	Notes    string    `bson:"notes"`
+	Date     time.Time `bson:"date" json:"date"`
+	Price    float64   `bson:"price" json:"price"`
+	Quantity float64   `bson:"quantity" json:"quantity"`
+	Fees     float64   `bson:"fees" json:"fees"`
+	Reason   string    `bson:"reason" json:"reason"`
+	Notes    string    `bson:"notes" json:"notes"`
+	// Fills lists the executions of a scaled exit, like EntryDetail.Fills.
+	Fills []Fill `bson:"fills,omitempty" json:"fills,omitempty"`
 }
 
 // RiskManagement stores the parameters that helped manage the trade.
+// WinProbability is the trader's estimate (0-1) that the target is hit before the stop.
 type RiskManagement struct {
-	Thesis          string  `bson:"thesis"`
-	Plan            string  `bson:"plan"`
-	Checklist       string  `bson:"checklist"`
-	MaxRiskAmount   float64 `bson:"max_risk_amount"`
-	PositionSizing  string  `bson:"position_sizing"`
-	ContingencyPlan string  `bson:"contingency_plan"`
+	Thesis          string   `bson:"thesis" json:"thesis"`
+	Plan            string   `bson:"plan" json:"plan"`
+	Checklist       string   `bson:"checklist" json:"checklist"`
+	MaxRiskAmount   float64  `bson:"max_risk_amount" json:"max_risk_amount"`
+	PositionSizing  string   `bson:"position_sizing" json:"position_sizing"`
+	ContingencyPlan string   `bson:"contingency_plan" json:"contingency_plan"`
+	WinProbability  *float64 `bson:"win_probability" json:"win_probability"`
 }
 
-// FollowUp holds post-trade tracking information.
+// FollowUp holds post-trade tracking information. Auto marks observations
+// fetched from a quote provider rather than logged by hand. Orphaned marks
+// observations measured against an exit that was later removed by a reopen.
 type FollowUp struct {
-	DaysAfter int       `bson:"days_after"`
-	Price     float64   `bson:"price"`
-	Notes     string    `bson:"notes"`
-	LoggedAt  time.Time `bson:"logged_at"`
+	DaysAfter int       `bson:"days_after" json:"days_after"`
+	Price     float64   `bson:"price" json:"price"`
+	Notes     string    `bson:"notes" json:"notes"`
+	LoggedAt  time.Time `bson:"logged_at" json:"logged_at"`
+	Auto      bool      `bson:"auto" json:"auto"`
+	Orphaned  bool      `bson:"orphaned" json:"orphaned"`
+}
+
+// EventKind names an entry in a trade's audit trail.
+type EventKind string
+
+const (
+	EventReopened EventKind = "REOPENED"
+	EventArchived EventKind = "ARCHIVED"
+	EventSplit    EventKind = "SPLIT"
+	// EventAutoClosed marks an open trade closed by the stale-trade job at its
+	// last mark price rather than by the trader.
+	EventAutoClosed EventKind = "AUTO_CLOSED"
+)
+
+// Event records a change to the trade that would otherwise lose information,
+// such as the exit cleared when a closed trade is reopened.
+type Event struct {
+	Kind EventKind   `bson:"kind" json:"kind"`
+	At   time.Time   `bson:"at" json:"at"`
+	Note string      `bson:"note" json:"note"`
+	Exit *ExitDetail `bson:"exit,omitempty" json:"exit,omitempty"`
+}
+
+// ShareLink grants read-only access to a trade until ExpiresAt. ShowAmounts
+// includes quantities, fees and results, which shared views hide by default.
+type ShareLink struct {
+	ID          string    `bson:"id" json:"id"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	ExpiresAt   time.Time `bson:"expires_at" json:"expires_at"`
+	ShowAmounts bool      `bson:"show_amounts" json:"show_amounts"`
+}
+
+// Expired reports whether the link is no longer valid at now.
+func (l ShareLink) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
 }
 
 // TradeReview gathers lessons learnt from the trade.
 type TradeReview struct {
-	OutcomeSummary string   `bson:"outcome_summary"`
-	Psychology     string   `bson:"psychology"`
-	Improvements   string   `bson:"improvements"`
-	Tags           []string `bson:"tags"`
+	OutcomeSummary string   `bson:"outcome_summary" json:"outcome_summary"`
+	Psychology     string   `bson:"psychology" json:"psychology"`
+	Improvements   string   `bson:"improvements" json:"improvements"`
+	Tags           []string `bson:"tags" json:"tags"`
+	// Mistakes lists the checklist items the trader ticked for this trade.
+	Mistakes []string `bson:"mistakes,omitempty" json:"mistakes,omitempty"`
+	// FollowedPlan records whether the trade was executed according to plan; nil
+	// means it was not assessed.
+	FollowedPlan *bool `bson:"followed_plan,omitempty" json:"followed_plan,omitempty"`
+}
+
+// NormalizeTag trims and lower-cases a tag, returning "" for blank or invalid
+// UTF-8 tags so they are dropped.
+func NormalizeTag(tag string) string {
+	trimmed := strings.TrimSpace(strings.ToLower(tag))
+	if trimmed == "" || !utf8.ValidString(trimmed) {
+		return ""
+	}
+	return trimmed
 }
 
 // Trade is the aggregate root representing a single trade.
+// FeeCurrency is only set when fees are charged in a different currency than the
+// instrument; FeeFXRate then holds the trade-currency amount per unit of fee currency.
+// DeletedAt marks a soft-deleted trade kept until it is pruned. ArchivedAt marks
+// an old closed trade moved out of the active list; it is kept indefinitely.
+// With FeeModelPercent, FeeRate is the fraction of notional charged per side
+// (0.001 for 0.1%) and the recorded fee amounts are ignored.
 type Trade struct {
-	ID               string         `bson:"_id,omitempty"`
-	Instrument       string         `bson:"instrument"`
-	Market           string         `bson:"market"`
-	Direction        Direction      `bson:"direction"`
-	Setup            string         `bson:"setup"`
-	Entry            EntryDetail    `bson:"entry"`
-	Exit             *ExitDetail    `bson:"exit"`
-	RiskManagement   RiskManagement `bson:"risk_management"`
-	FollowUps        []FollowUp     `bson:"follow_ups"`
-	Review           TradeReview    `bson:"review"`
-	CreatedAt        time.Time      `bson:"created_at"`
-	UpdatedAt        time.Time      `bson:"updated_at"`
-	AdditionalNotes  string         `bson:"additional_notes"`
-	MarketContext    string         `bson:"market_context"`
-	ExecutionScore   *float64       `bson:"execution_score"`
-	ConfidenceBefore *float64       `bson:"confidence_before"`
-	ConfidenceAfter  *float64       `bson:"confidence_after"`
+	ID               string         `bson:"_id,omitempty" json:"id"`
+	Instrument       string         `bson:"instrument" json:"instrument"`
+	Market           string         `bson:"market" json:"market"`
+	Account          string         `bson:"account" json:"account"`
+	Currency         string         `bson:"currency" json:"currency"`
+	FeeCurrency      string         `bson:"fee_currency" json:"fee_currency"`
+	FeeFXRate        *float64       `bson:"fee_fx_rate" json:"fee_fx_rate"`
+	FeeModel         FeeModel       `bson:"fee_model" json:"fee_model"`
+	FeeRate          float64        `bson:"fee_rate" json:"fee_rate"`
+	Direction        Direction      `bson:"direction" json:"direction"`
+	Setup            string         `bson:"setup" json:"setup"`
+	Entry            EntryDetail    `bson:"entry" json:"entry"`
+	Exit             *ExitDetail    `bson:"exit" json:"exit"`
+	RiskManagement   RiskManagement `bson:"risk_management" json:"risk_management"`
+	FollowUps        []FollowUp     `bson:"follow_ups" json:"follow_ups"`
+	Review           TradeReview    `bson:"review" json:"review"`
+	Events           []Event        `bson:"events" json:"events"`
+	CreatedAt        time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time      `bson:"updated_at" json:"updated_at"`
+	DeletedAt        *time.Time     `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	ArchivedAt       *time.Time     `bson:"archived_at,omitempty" json:"archived_at,omitempty"`
+	AdditionalNotes  string         `bson:"additional_notes" json:"additional_notes"`
+	MarketContext    string         `bson:"market_context" json:"market_context"`
+	ExecutionScore   *float64       `bson:"execution_score" json:"execution_score"`
+	ConfidenceBefore *float64       `bson:"confidence_before" json:"confidence_before"`
+	ConfidenceAfter  *float64       `bson:"confidence_after" json:"confidence_after"`
+
+	// AccountSizeAtEntry is the account equity when the trade was opened; it
+	// overrides the configured account size for leverage calculations.
+	AccountSizeAtEntry *float64 `bson:"account_size_at_entry,omitempty" json:"account_size_at_entry,omitempty"`
+	// FinancingCost is the swap or overnight financing accrued while the position
+	// was held, charged in the fee currency like the fees. Credits are negative.
+	FinancingCost float64 `bson:"financing_cost,omitempty" json:"financing_cost,omitempty"`
+	// InstrumentAlias is the name the instrument was entered under when a
+	// configured alias mapped it onto the canonical Instrument.
+	InstrumentAlias string `bson:"instrument_alias,omitempty" json:"instrument_alias,omitempty"`
+	// Shares lists the read-only share links that have not been revoked.
+	Shares []ShareLink `bson:"shares,omitempty" json:"shares,omitempty"`
+	// IsPaper marks a simulated trade. Paper trades are logged alongside real
+	// ones but left out of the default lists, statistics and exports.
+	IsPaper bool `bson:"is_paper,omitempty" json:"is_paper,omitempty"`
+	// SplitFrom is the ID of the trade this one was split off from.
+	SplitFrom string `bson:"split_from,omitempty" json:"split_from,omitempty"`
+	// HedgeOf is the ID of the trade this one hedges, for example the second
+	// leg of a pairs trade. Linked trades are reported together as one position.
+	HedgeOf string `bson:"hedge_of,omitempty" json:"hedge_of,omitempty"`
+	// MarkPrice is the latest price an open position is valued at for its
+	// unrealized result; it is ignored once the trade has exited.
+	MarkPrice *float64 `bson:"mark_price,omitempty" json:"mark_price,omitempty"`
+	// ExpectedHoldDays is how many calendar days the trade was planned to be
+	// held for, recorded at entry.
+	ExpectedHoldDays *int `bson:"expected_hold_days,omitempty" json:"expected_hold_days,omitempty"`
+	// Session is the manually selected trading session of the entry; when
+	// empty it is derived from the entry time (see TradingSession).
+	Session Session `bson:"session,omitempty" json:"session,omitempty"`
+	// TimeStopDate is the date by which the trade is planned to be exited
+	// regardless of price (a time stop).
+	TimeStopDate *time.Time `bson:"time_stop_date,omitempty" json:"time_stop_date,omitempty"`
+	// ScreenshotURL links to a chart of the setup hosted elsewhere, such as a
+	// TradingView snapshot. Only http and https links are accepted.
+	ScreenshotURL string `bson:"screenshot_url,omitempty" json:"screenshot_url,omitempty"`
+}
+
+// Summary returns a one-line description such as
+// "LONG AAPL 100@180.50 → 190.20, +965.00 (+5.3%, 1.9R)" for logs and notifications.
+// Open trades read "LONG AAPL 100@180.50, open, unrealized N/A". The R multiple is
+// omitted when the trade has no defined risk.
+func (t Trade) Summary() string {
+	head := fmt.Sprintf("%s %s %s@%.2f", t.Direction, t.Instrument, strconv.FormatFloat(t.Entry.Quantity, 'f', -1, 64), t.AvgEntryPrice())
+	if !t.HasExited() {
+		return head + ", open, unrealized N/A"
+	}
+	result := fmt.Sprintf("%+.2f (%+.1f%%", t.NetResult(), t.ResultPercent())
+	if t.TotalRiskAmount() != 0 {
+		result += fmt.Sprintf(", %.1fR", t.RMultiple())
+	}
+	return fmt.Sprintf("%s → %.2f, %s)", head, t.AvgExitPrice(), result)
 }
 
 // GrossExposure calculates the notional size of the trade at entry.
 func (t Trade) GrossExposure() float64 {
-	return math.Abs(t.Entry.Price * t.Entry.Quantity)
+	return math.Abs(t.AvgEntryPrice() * t.Entry.Quantity)
 }
 
 // RiskPerShare calculates the assumed risk per share based on stop loss.
@@ -97,9 +255,36 @@ func (t Trade) RiskPerShare() float64 {
 	}
 	stop := *t.Entry.StopLoss
 	if t.Direction == DirectionLong {
-		return t.Entry.Price - stop
+		return t.AvgEntryPrice() - stop
 	}
-	return stop - t.Entry.Price
+	return stop - t.AvgEntryPrice()
+}
+
+// PriceAtR returns the price at which the trade gains r times its risk per
+// share from entry, above entry for longs and below for shorts. It reports
+// false when the trade has no positive risk per share.
+func (t Trade) PriceAtR(r float64) (float64, bool) {
+	risk := t.RiskPerShare()
+	if risk <= 0 {
+		return 0, false
+	}
+	if t.Direction == DirectionShort {
+		return t.AvgEntryPrice() - r*risk, true
+	}
+	return t.AvgEntryPrice() + r*risk, true
+}
+
+// EffectiveLeverage returns GrossExposure divided by the account size, using
+// AccountSizeAtEntry when recorded and accountSize otherwise. It reports false
+// when no positive account size is available.
+func (t Trade) EffectiveLeverage(accountSize float64) (float64, bool) {
+	if t.AccountSizeAtEntry != nil {
+		accountSize = *t.AccountSizeAtEntry
+	}
+	if accountSize <= 0 {
+		return 0, false
+	}
+	return t.GrossExposure() / accountSize, true
 }
 
 // TotalRiskAmount calculates the nominal risk of the trade.
@@ -107,49 +292,272 @@ func (t Trade) TotalRiskAmount() float64 {
 	return t.RiskPerShare() * t.Entry.Quantity
 }
 
+// HasDefinedRisk reports whether a stop loss or custom risk per share gives the
+// trade a positive risk amount, so its R multiple is meaningful.
+func (t Trade) HasDefinedRisk() bool {
+	return t.TotalRiskAmount() > 0
+}
+
+// IsDeleted reports whether the trade has been soft-deleted.
+func (t Trade) IsDeleted() bool {
+	return t.DeletedAt != nil
+}
+
+// IsArchived reports whether the trade has been moved out of the active list.
+func (t Trade) IsArchived() bool {
+	return t.ArchivedAt != nil
+}
+
 // HasExited indicates whether the trade has been closed.
 func (t Trade) HasExited() bool {
 	return t.Exit != nil
 }
 
-// GrossResult calculates the gross profit or loss (before fees).
+// Outcome classifies a closed trade as a win, loss or breakeven. Net results whose
+// absolute value is within epsilon count as breakeven so floating-point noise does
+// not turn a flat trade into a tiny win or loss.
+func (t Trade) Outcome(epsilon float64) Outcome {
+	if !t.HasExited() {
+		return OutcomeOpen
+	}
+	if epsilon < 0 {
+		epsilon = 0
+	}
+	net := t.NetResult()
+	switch {
+	case math.Abs(net) <= epsilon:
+		return OutcomeBreakeven
+	case net > 0:
+		return OutcomeWin
+	default:
+		return OutcomeLoss
+	}
+}
+
+// DaysSinceExit returns the whole days elapsed between the exit date and now.
+// It reports false for open trades or when the exit date is missing or in the future.
+func (t Trade) DaysSinceExit(now time.Time) (int, bool) {
+	if t.Exit == nil || t.Exit.Date.IsZero() || now.Before(t.Exit.Date) {
+		return 0, false
+	}
+	return int(now.Sub(t.Exit.Date).Hours() / 24), true
+}
+
+// HasReview reports whether any of the review narrative fields were filled in.
+func (t Trade) HasReview() bool {
+	r := t.Review
+	return strings.TrimSpace(r.OutcomeSummary) != "" ||
+		strings.TrimSpace(r.Psychology) != "" ||
+		strings.TrimSpace(r.Improvements) != ""
+}
+
+// GrossResult calculates the gross profit or loss (before fees) on the closed
+// quantity.
 func (t Trade) GrossResult() float64 {
 	if t.Exit == nil {
 		return 0
 	}
-	pnl := (t.Exit.Price - t.Entry.Price) * t.Entry.Quantity
+	qty := t.ClosedQuantity()
+	pnl := (t.AvgExitPrice() - t.AvgEntryPrice()) * qty
 	if t.Direction == DirectionShort {
-		pnl = (t.Entry.Price - t.Exit.Price) * t.Entry.Quantity
+		pnl = (t.AvgEntryPrice() - t.AvgExitPrice()) * qty
 	}
 	return pnl
 }
 
-// NetResult accounts for both entry and exit fees.
+// ClosedQuantity returns the quantity the exit closed, capped at the entry
+// quantity, or 0 for open trades. An exit without a quantity closes the whole
+// position.
+func (t Trade) ClosedQuantity() float64 {
+	if t.Exit == nil {
+		return 0
+	}
+	return math.Min(math.Abs(t.exitQuantity()), math.Abs(t.Entry.Quantity))
+}
+
+// CostBasis is the accounting cost of the position: for a long, the entry
+// notional plus the entry fee; for a short, the notional paid to cover plus the
+// exit fee. Closed trades count the closed quantity, open ones the entry
+// quantity, and an open short has no cost yet. Together with Proceeds it gives
+// NetResult = Proceeds - CostBasis - Financing for closed trades.
+func (t Trade) CostBasis() float64 {
+	qty := t.accountingQuantity()
+	if t.Direction == DirectionShort {
+		if t.Exit == nil {
+			return 0
+		}
+		return math.Abs(t.AvgExitPrice()*qty) + t.ExitFee()
+	}
+	return math.Abs(t.AvgEntryPrice()*qty) + t.EntryFee()
+}
+
+// Proceeds is the accounting sale amount of the position: for a long, the exit
+// notional minus the exit fee; for a short, the entry notional minus the entry
+// fee. An open long has no proceeds yet. See CostBasis.
+func (t Trade) Proceeds() float64 {
+	qty := t.accountingQuantity()
+	if t.Direction == DirectionShort {
+		return math.Abs(t.AvgEntryPrice()*qty) - t.EntryFee()
+	}
+	if t.Exit == nil {
+		return 0
+	}
+	return math.Abs(t.AvgExitPrice()*qty) - t.ExitFee()
+}
+
+func (t Trade) accountingQuantity() float64 {
+	if t.Exit == nil {
+		return math.Abs(t.Entry.Quantity)
+	}
+	return t.ClosedQuantity()
+}
+
+// DeployedCapital is the entry notional of the closed portion of a trade, or
+// the full gross exposure while the trade is open.
+func (t Trade) DeployedCapital() float64 {
+	if t.Exit == nil {
+		return t.GrossExposure()
+	}
+	return math.Abs(t.AvgEntryPrice() * t.ClosedQuantity())
+}
+
+// EntryFee returns the entry fee in the trade currency under the trade's fee model.
+func (t Trade) EntryFee() float64 {
+	if t.FeeModel == FeeModelPercent {
+		return t.FeeRate * math.Abs(t.AvgEntryPrice()*t.Entry.Quantity)
+	}
+	return t.feeInTradeCurrency(t.Entry.Fees)
+}
+
+// ExitFee returns the exit fee in the trade currency under the trade's fee model,
+// or 0 for open trades.
+func (t Trade) ExitFee() float64 {
+	if t.Exit == nil {
+		return 0
+	}
+	if t.FeeModel == FeeModelPercent {
+		return t.FeeRate * math.Abs(t.AvgExitPrice()*t.exitQuantity())
+	}
+	return t.feeInTradeCurrency(t.Exit.Fees)
+}
+
+func (t Trade) exitQuantity() float64 {
+	if t.Exit != nil && t.Exit.Quantity != 0 {
+		return t.Exit.Quantity
+	}
+	return t.Entry.Quantity
+}
+
+// NetResult accounts for both entry and exit fees and any financing cost,
+// converted into the trade currency when they were charged in a different one.
 func (t Trade) NetResult() float64 {
 	if t.Exit == nil {
-		return -t.Entry.Fees
+		return -t.EntryFee() - t.Financing()
 	}
-	return t.GrossResult() - t.Entry.Fees - t.Exit.Fees
+	return t.GrossResult() - t.EntryFee() - t.ExitFee() - t.Financing()
 }
 
-// ResultPercent expresses the net result as a percentage of gross exposure.
+// Financing returns FinancingCost converted into the trade currency.
+func (t Trade) Financing() float64 {
+	return t.feeInTradeCurrency(t.FinancingCost)
+}
+
+// ResultPercent expresses the net result as a percentage of the capital
+// deployed in the closed portion (see DeployedCapital). When the exit closes
+// fewer units than were entered, the result and the denominator both cover only
+// the closed units, so the percentage is not diluted by capital that is still
+// at work. Fees and financing are charged in full against that result.
 func (t Trade) ResultPercent() float64 {
-	exposure := t.GrossExposure()
-	if exposure == 0 {
+	capital := t.DeployedCapital()
+	if capital == 0 {
 		return 0
 	}
-	return (t.NetResult() / exposure) * 100
+	return (t.NetResult() / capital) * 100
 }
 
-// RMultiple calculates the result in terms of risk multiples.
+// RMultiple calculates the result in terms of risk multiples. NetResult of a
+// closed trade covers only the closed quantity, so the risk is scaled to that
+// quantity too.
 func (t Trade) RMultiple() float64 {
 	risk := t.TotalRiskAmount()
+	if t.Exit != nil && t.Entry.Quantity != 0 {
+		risk *= t.ClosedQuantity() / math.Abs(t.Entry.Quantity)
+	}
 	if risk == 0 {
 		return 0
 	}
 	return t.NetResult() / risk
 }
 
+// HoldDeviation compares a closed trade's holding period in calendar days with
+// ExpectedHoldDays. The deviation is signed: positive when the trade was held
+// longer than planned. It reports false for open trades, trades without an
+// expected hold or dates, and exits before entry.
+func (t Trade) HoldDeviation() (actual, deviation float64, ok bool) {
+	if t.ExpectedHoldDays == nil || t.Exit == nil || t.Entry.Date.IsZero() || t.Exit.Date.IsZero() || t.Exit.Date.Before(t.Entry.Date) {
+		return 0, 0, false
+	}
+	actual = t.Exit.Date.Sub(t.Entry.Date).Hours() / 24
+	return actual, actual - float64(*t.ExpectedHoldDays), true
+}
+
+// TimeStopReached reports whether an open trade is still held on or after its
+// TimeStopDate.
+func (t Trade) TimeStopReached(now time.Time) bool {
+	return t.Exit == nil && t.TimeStopDate != nil && !now.Before(*t.TimeStopDate)
+}
+
+// HoldingPeriodReturn returns the net result of a closed trade as a fraction of
+// gross exposure together with the holding period in days. Holds shorter than a
+// day count as one day. It reports false for open trades, trades without dates or
+// with an exit before entry.
+func (t Trade) HoldingPeriodReturn() (ret float64, days float64, ok bool) {
+	if t.Exit == nil || t.Entry.Date.IsZero() || t.Exit.Date.IsZero() || t.Exit.Date.Before(t.Entry.Date) {
+		return 0, 0, false
+	}
+	days = math.Max(t.Exit.Date.Sub(t.Entry.Date).Hours()/24, 1)
+	return t.ResultPercent() / 100, days, true
+}
+
+// AnnualizedReturn scales the holding period return linearly to a year, in percent.
+func (t Trade) AnnualizedReturn() (float64, bool) {
+	r, days, ok := t.HoldingPeriodReturn()
+	if !ok {
+		return 0, false
+	}
+	return r * 365 / days * 100, true
+}
+
+// AnnualizedReturnCompounded compounds the holding period return over a year,
+// (1+r)^(365/days) - 1, in percent. A loss of the whole exposure or more yields
+// -100 instead of NaN, and results too large to represent report false.
+func (t Trade) AnnualizedReturnCompounded() (float64, bool) {
+	r, days, ok := t.HoldingPeriodReturn()
+	if !ok {
+		return 0, false
+	}
+	if 1+r <= 0 {
+		return -100, true
+	}
+	v := (math.Pow(1+r, 365/days) - 1) * 100
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, false
+	}
+	return v, true
+}
+
+// ProfitPerDay divides the net result of a closed trade by its holding period
+// in days, so a quick gain ranks above the same gain held for months. Holds
+// shorter than a day count as one day; it reports false for open trades,
+// trades without dates or with an exit before entry.
+func (t Trade) ProfitPerDay() (float64, bool) {
+	_, days, ok := t.HoldingPeriodReturn()
+	if !ok {
+		return 0, false
+	}
+	return t.NetResult() / days, true
+}
+
 // FollowUpChangePercent returns the percentage change between the exit price
 // and a follow-up observation at the specified number of days.
 func (t Trade) FollowUpChangePercent(daysAfter int) (float64, bool) {
@@ -157,13 +565,13 @@ func (t Trade) FollowUpChangePercent(daysAfter int) (float64, bool) {
 		return 0, false
 	}
 	for _, f := range t.FollowUps {
-		if f.DaysAfter == daysAfter {
-			if t.Exit.Price == 0 {
+		if f.DaysAfter == daysAfter && !f.Orphaned {
+			if t.AvgExitPrice() == 0 {
 				return 0, true
 			}
-			change := ((f.Price - t.Exit.Price) / t.Exit.Price) * 100
+			change := ((f.Price - t.AvgExitPrice()) / t.AvgExitPrice()) * 100
 			if t.Direction == DirectionShort {
-				change = ((t.Exit.Price - f.Price) / t.Exit.Price) * 100
+				change = ((t.AvgExitPrice() - f.Price) / t.AvgExitPrice()) * 100
 			}
 			return change, true
 		}
@@ -171,16 +579,47 @@ func (t Trade) FollowUpChangePercent(daysAfter int) (float64, bool) {
 	return 0, false
 }
 
+// CapturedMovePercent returns the price move realized by the exit as a
+// percentage of the full move from entry to the most favorable price seen,
+// taking the exit and the follow-up observations into account. 100 means the
+// exit caught the best price; 40 means the market went on to run more than
+// twice as far. It reports false for open trades, trades without follow-ups
+// and trades that never moved in their favor.
+func (t Trade) CapturedMovePercent() (float64, bool) {
+	if t.Exit == nil {
+		return 0, false
+	}
+	sign := 1.0
+	if t.Direction == DirectionShort {
+		sign = -1
+	}
+	best := sign * (t.AvgExitPrice() - t.AvgEntryPrice())
+	observed := false
+	for _, f := range t.FollowUps {
+		if f.Orphaned {
+			continue
+		}
+		observed = true
+		if move := sign * (f.Price - t.AvgEntryPrice()); move > best {
+			best = move
+		}
+	}
+	if !observed || best <= 0 {
+		return 0, false
+	}
+	return sign * (t.AvgExitPrice() - t.AvgEntryPrice()) / best * 100, true
+}
+
 // UnrealizedResult calculates P/L using the latest close price provided.
 func (t Trade) UnrealizedResult(closePrice float64) float64 {
 	if t.HasExited() {
 		return t.NetResult()
 	}
-	pnl := (closePrice - t.Entry.Price) * t.Entry.Quantity
+	pnl := (closePrice - t.AvgEntryPrice()) * t.Entry.Quantity
 	if t.Direction == DirectionShort {
-		pnl = (t.Entry.Price - closePrice) * t.Entry.Quantity
+		pnl = (t.AvgEntryPrice() - closePrice) * t.Entry.Quantity
 	}
-	return pnl - t.Entry.Fees
+	return pnl - t.EntryFee() - t.Financing()
 }
 
 // UnrealizedPercent calculates the unrealized return percentage.
@@ -198,9 +637,9 @@ func (t Trade) EffectiveRewardTarget() float64 {
 		return 0
 	}
 	target := *t.Entry.Target
-	pnl := (target - t.Entry.Price) * t.Entry.Quantity
+	pnl := (target - t.AvgEntryPrice()) * t.Entry.Quantity
 	if t.Direction == DirectionShort {
-		pnl = (t.Entry.Price - target) * t.Entry.Quantity
+		pnl = (t.AvgEntryPrice() - target) * t.Entry.Quantity
 	}
 	risk := t.TotalRiskAmount()
 	if risk == 0 {
@@ -208,3 +647,94 @@ func (t Trade) EffectiveRewardTarget() float64 {
 	}
 	return pnl / risk
 }
+
+// ExpectedValue returns the pre-entry expected value in R multiples for a win
+// probability p (0-1): p*targetR - (1-p)*1R. It returns 0 when the trade lacks
+// a target or a defined risk, since the planned R values are unknown.
+func (t Trade) ExpectedValue(p float64) float64 {
+	reward := t.EffectiveRewardTarget()
+	if reward == 0 {
+		return 0
+	}
+	p = math.Max(0, math.Min(1, p))
+	return p*reward - (1 - p)
+}
+
+// StopDistancePercent returns how far the stop sits from the entry price as a
+// percentage of entry, measured in the losing direction. A negative value means the
+// stop is on the wrong side of entry. It reports false when no stop is set.
+func (t Trade) StopDistancePercent() (float64, bool) {
+	if t.Entry.StopLoss == nil || t.AvgEntryPrice() == 0 {
+		return 0, false
+	}
+	dist := t.AvgEntryPrice() - *t.Entry.StopLoss
+	if t.Direction == DirectionShort {
+		dist = -dist
+	}
+	return dist / t.AvgEntryPrice() * 100, true
+}
+
+// TargetDistancePercent returns how far the target sits from the entry price as a
+// percentage of entry, measured in the winning direction. It reports false when
+// no target is set.
+func (t Trade) TargetDistancePercent() (float64, bool) {
+	if t.Entry.Target == nil || t.AvgEntryPrice() == 0 {
+		return 0, false
+	}
+	dist := *t.Entry.Target - t.AvgEntryPrice()
+	if t.Direction == DirectionShort {
+		dist = -dist
+	}
+	return dist / t.AvgEntryPrice() * 100, true
+}
+
+// EntrySlippage returns how much worse the fill was than the planned entry, per
+// unit and direction-aware: positive when a long filled above (or a short below)
+// the planned price. It reports false when no planned entry is recorded.
+func (t Trade) EntrySlippage() (float64, bool) {
+	if t.Entry.PlannedEntryPrice == nil {
+		return 0, false
+	}
+	slip := t.AvgEntryPrice() - *t.Entry.PlannedEntryPrice
+	if t.Direction == DirectionShort {
+		slip = -slip
+	}
+	return slip, true
+}
+
+// EntrySlippageCost returns EntrySlippage scaled by the entry quantity.
+func (t Trade) EntrySlippageCost() (float64, bool) {
+	slip, ok := t.EntrySlippage()
+	return slip * t.Entry.Quantity, ok
+}
+
+// RiskRewardAchieved compares the planned reward:risk implied by the target and
+// stop with the realised R of a closed trade. It reports false unless both stop
+// and target are set, the risk is positive and the trade has exited.
+func (t Trade) RiskRewardAchieved() (planned, achieved float64, ok bool) {
+	if t.Entry.StopLoss == nil || t.Entry.Target == nil || !t.HasExited() || t.TotalRiskAmount() <= 0 {
+		return 0, 0, false
+	}
+	return t.EffectiveRewardTarget(), t.RMultiple(), true
+}
+
+// PartialExitResult returns the direction-aware P/L of closing quantity units at
+// price, net of the proportional share of entry fees and, for closed trades, of
+// the exit fees actually paid. Under the percent fee model both sides are charged
+// at FeeRate on the notional of the quantity being closed.
+func (t Trade) PartialExitResult(price, quantity float64) float64 {
+	pnl := (price - t.AvgEntryPrice()) * quantity
+	if t.Direction == DirectionShort {
+		pnl = (t.AvgEntryPrice() - price) * quantity
+	}
+	if t.FeeModel == FeeModelPercent {
+		return pnl - t.FeeRate*math.Abs(t.AvgEntryPrice()*quantity) - t.FeeRate*math.Abs(price*quantity)
+	}
+	if t.Entry.Quantity != 0 {
+		pnl -= t.EntryFee() * quantity / t.Entry.Quantity
+	}
+	if t.Exit != nil && t.Exit.Quantity != 0 {
+		pnl -= t.ExitFee() * quantity / t.Exit.Quantity
+	}
+	return pnl
+}
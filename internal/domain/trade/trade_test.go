@@ -2,6 +2,7 @@ package trade
 
 import (
 	"math"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -34,6 +35,67 @@ func TestNetResultShort(t *testing.T) {
 	}
 }
 
+func TestResultPercentPartialExit(t *testing.T) {
+	tr := Trade{
+		Direction: DirectionLong,
+		Entry:     EntryDetail{Price: 100, Quantity: 10, Fees: 1},
+		Exit:      &ExitDetail{Price: 110, Quantity: 4, Fees: 1},
+	}
+	if got := tr.ClosedQuantity(); got != 4 {
+		t.Fatalf("expected closed quantity 4, got %v", got)
+	}
+	if got, want := tr.GrossResult(), 40.0; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("unexpected gross result: got %v want %v", got, want)
+	}
+	if got, want := tr.DeployedCapital(), 400.0; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("unexpected deployed capital: got %v want %v", got, want)
+	}
+	if got, want := tr.ResultPercent(), 38.0/400*100; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("unexpected result percent: got %v want %v", got, want)
+	}
+
+	// An exit larger than the position still only closes what was entered.
+	tr.Exit.Quantity = 15
+	if got := tr.ClosedQuantity(); got != 10 {
+		t.Fatalf("expected closed quantity capped at 10, got %v", got)
+	}
+	if got, want := tr.ResultPercent(), 98.0/1000*100; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("unexpected result percent: got %v want %v", got, want)
+	}
+
+	// Without an exit quantity the whole position is closed.
+	tr.Exit.Quantity = 0
+	if got, want := tr.DeployedCapital(), tr.GrossExposure(); got != want {
+		t.Fatalf("expected full exposure %v, got %v", want, got)
+	}
+}
+
+func TestRMultiplePartialExit(t *testing.T) {
+	stop := 95.0
+	tr := Trade{
+		Direction: DirectionLong,
+		Entry:     EntryDetail{Price: 100, Quantity: 10, StopLoss: &stop},
+		Exit:      &ExitDetail{Price: 110, Quantity: 4},
+	}
+	// 4 units closed at +10 against 4 units risking 5 each.
+	if got, want := tr.RMultiple(), 2.0; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("unexpected R on a partial close: got %v want %v", got, want)
+	}
+
+	shortStop := 105.0
+	tr.Direction = DirectionShort
+	tr.Entry.StopLoss = &shortStop
+	tr.Exit.Price = 90
+	if got, want := tr.RMultiple(), 2.0; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("unexpected R on a partial short close: got %v want %v", got, want)
+	}
+
+	tr.Exit.Quantity = 0
+	if got, want := tr.RMultiple(), 2.0; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("unexpected R on a full close: got %v want %v", got, want)
+	}
+}
+
 func TestRMultiple(t *testing.T) {
 	stop := 95.0
 	tr := Trade{
@@ -55,6 +117,68 @@ func TestRMultiple(t *testing.T) {
 	}
 }
 
+func TestNetResultDeductsFinancingCost(t *testing.T) {
+	rate := 0.5
+	tr := Trade{
+		Direction:     DirectionLong,
+		Currency:      "USD",
+		FeeCurrency:   "EUR",
+		FeeFXRate:     &rate,
+		Entry:         EntryDetail{Price: 100, Quantity: 10, Fees: 2},
+		Exit:          &ExitDetail{Price: 110, Quantity: 10, Fees: 2},
+		FinancingCost: 6,
+	}
+	if got := tr.NetResult(); math.Abs(got-(100-1-1-3)) > 1e-9 {
+		t.Fatalf("expected financing converted and deducted, got %v", got)
+	}
+	tr.Exit = nil
+	if got := tr.UnrealizedResult(105); math.Abs(got-(50-1-3)) > 1e-9 {
+		t.Fatalf("expected accrued financing in unrealized result, got %v", got)
+	}
+}
+
+func TestCapturedMovePercent(t *testing.T) {
+	long := Trade{
+		Direction: DirectionLong,
+		Entry:     EntryDetail{Price: 100, Quantity: 1},
+		Exit:      &ExitDetail{Price: 110, Quantity: 1},
+		FollowUps: []FollowUp{{DaysAfter: 7, Price: 125}, {DaysAfter: 30, Price: 105}, {DaysAfter: 1, Price: 200, Orphaned: true}},
+	}
+	if got, ok := long.CapturedMovePercent(); !ok || math.Abs(got-40) > 1e-9 {
+		t.Fatalf("expected 40%% captured, got %v (%v)", got, ok)
+	}
+
+	short := Trade{
+		Direction: DirectionShort,
+		Entry:     EntryDetail{Price: 100, Quantity: 1},
+		Exit:      &ExitDetail{Price: 90, Quantity: 1},
+		FollowUps: []FollowUp{{DaysAfter: 7, Price: 95}},
+	}
+	if got, ok := short.CapturedMovePercent(); !ok || math.Abs(got-100) > 1e-9 {
+		t.Fatalf("expected the exit to be the best price, got %v (%v)", got, ok)
+	}
+
+	short.FollowUps = nil
+	if _, ok := short.CapturedMovePercent(); ok {
+		t.Fatalf("expected no value without follow-ups")
+	}
+}
+
+func TestPriceAtR(t *testing.T) {
+	longStop, shortStop := 95.0, 104.0
+	long := Trade{Direction: DirectionLong, Entry: EntryDetail{Price: 100, StopLoss: &longStop}}
+	short := Trade{Direction: DirectionShort, Entry: EntryDetail{Price: 100, StopLoss: &shortStop}}
+	if got, ok := long.PriceAtR(2); !ok || got != 110 {
+		t.Fatalf("expected long 2R at 110, got %v (%v)", got, ok)
+	}
+	if got, ok := short.PriceAtR(3); !ok || got != 88 {
+		t.Fatalf("expected short 3R at 88, got %v (%v)", got, ok)
+	}
+	if _, ok := (Trade{Entry: EntryDetail{Price: 100}}).PriceAtR(1); ok {
+		t.Fatalf("expected no R levels without a stop")
+	}
+}
+
 func TestFollowUpChangePercent(t *testing.T) {
 	exit := &ExitDetail{Price: 100, Quantity: 10}
 	tr := Trade{
@@ -83,3 +207,595 @@ func TestUnrealizedResultForOpenTrade(t *testing.T) {
 		t.Fatalf("unexpected unrealized result: got %v want %v", got, want)
 	}
 }
+
+func TestOutcomeBreakevenEpsilon(t *testing.T) {
+	build := func(exitPrice float64) Trade {
+		return Trade{
+			Direction: DirectionLong,
+			Entry:     EntryDetail{Price: 100, Quantity: 1},
+			Exit:      &ExitDetail{Price: exitPrice, Quantity: 1},
+		}
+	}
+
+	cases := []struct {
+		name    string
+		exit    float64
+		epsilon float64
+		want    Outcome
+	}{
+		{"exactly flat", 100, DefaultBreakevenEpsilon, OutcomeBreakeven},
+		{"inside epsilon", 100.005, DefaultBreakevenEpsilon, OutcomeBreakeven},
+		{"at epsilon boundary", 100.25, 0.25, OutcomeBreakeven},
+		{"just above epsilon", 100.26, 0.25, OutcomeWin},
+		{"just below negative epsilon", 99.74, 0.25, OutcomeLoss},
+		{"zero epsilon win", 100.001, 0, OutcomeWin},
+	}
+	for _, tc := range cases {
+		if got := build(tc.exit).Outcome(tc.epsilon); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+
+	open := Trade{Entry: EntryDetail{Price: 100, Quantity: 1}}
+	if got := open.Outcome(DefaultBreakevenEpsilon); got != OutcomeOpen {
+		t.Fatalf("expected open outcome, got %s", got)
+	}
+}
+
+func TestDaysSinceExit(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	tr := Trade{Exit: &ExitDetail{Date: time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)}}
+	days, ok := tr.DaysSinceExit(now)
+	if !ok || days != 3 {
+		t.Fatalf("expected 3 days since exit, got %d (ok=%v)", days, ok)
+	}
+
+	if _, ok := (Trade{}).DaysSinceExit(now); ok {
+		t.Fatalf("expected open trade to report no exit")
+	}
+	future := Trade{Exit: &ExitDetail{Date: now.AddDate(0, 0, 1)}}
+	if _, ok := future.DaysSinceExit(now); ok {
+		t.Fatalf("expected future exit to be rejected")
+	}
+}
+
+func TestExpectedValue(t *testing.T) {
+	stop, target := 95.0, 110.0
+	tr := Trade{
+		Direction: DirectionLong,
+		Entry:     EntryDetail{Price: 100, Quantity: 10, StopLoss: &stop, Target: &target},
+	}
+	// Target is 2R away, so at 40% the EV is 0.4*2 - 0.6*1 = 0.2R.
+	if got := tr.ExpectedValue(0.4); math.Abs(got-0.2) > 1e-9 {
+		t.Fatalf("unexpected expected value: %v", got)
+	}
+	if got := tr.ExpectedValue(1.5); math.Abs(got-2) > 1e-9 {
+		t.Fatalf("expected probability to be clamped, got %v", got)
+	}
+
+	tr.Entry.Target = nil
+	if got := tr.ExpectedValue(0.5); got != 0 {
+		t.Fatalf("expected 0 without a target, got %v", got)
+	}
+}
+
+func TestPartialExitResult(t *testing.T) {
+	long := Trade{
+		Direction: DirectionLong,
+		Entry:     EntryDetail{Price: 100, Quantity: 10, Fees: 10},
+	}
+	// Half the position at 120: 20*5 profit minus half of the entry fees.
+	if got := long.PartialExitResult(120, 5); math.Abs(got-95) > 1e-9 {
+		t.Fatalf("unexpected long partial result: %v", got)
+	}
+
+	short := Trade{
+		Direction: DirectionShort,
+		Entry:     EntryDetail{Price: 100, Quantity: 10, Fees: 4},
+		Exit:      &ExitDetail{Price: 90, Quantity: 10, Fees: 6},
+	}
+	want := (100.0-80.0)*2 - 4*0.2 - 6*0.2
+	if got := short.PartialExitResult(80, 2); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("unexpected short partial result: got %v want %v", got, want)
+	}
+
+	if got := short.PartialExitResult(90, 10); math.Abs(got-short.NetResult()) > 1e-9 {
+		t.Fatalf("expected full exit to match NetResult, got %v want %v", got, short.NetResult())
+	}
+}
+
+func TestRiskRewardAchieved(t *testing.T) {
+	stop, target := 98.0, 105.0
+	tr := Trade{
+		Direction: DirectionLong,
+		Entry:     EntryDetail{Price: 100, Quantity: 10, StopLoss: &stop, Target: &target},
+		Exit:      &ExitDetail{Price: 102.2, Quantity: 10},
+	}
+	planned, achieved, ok := tr.RiskRewardAchieved()
+	if !ok {
+		t.Fatalf("expected risk reward to be available")
+	}
+	if math.Abs(planned-2.5) > 1e-9 || math.Abs(achieved-1.1) > 1e-9 {
+		t.Fatalf("unexpected risk reward: planned %v achieved %v", planned, achieved)
+	}
+
+	tr.Entry.Target = nil
+	if _, _, ok := tr.RiskRewardAchieved(); ok {
+		t.Fatalf("expected missing target to report unavailable")
+	}
+	tr.Entry.Target = &target
+	tr.Exit = nil
+	if _, _, ok := tr.RiskRewardAchieved(); ok {
+		t.Fatalf("expected open trade to report unavailable")
+	}
+}
+
+func TestEntrySlippage(t *testing.T) {
+	planned := 100.0
+	tr := Trade{Direction: DirectionLong, Entry: EntryDetail{Price: 100.5, Quantity: 10, PlannedEntryPrice: &planned}}
+	slip, ok := tr.EntrySlippage()
+	if !ok || math.Abs(slip-0.5) > 1e-9 {
+		t.Fatalf("expected long slippage 0.5, got %v (%v)", slip, ok)
+	}
+	if cost, _ := tr.EntrySlippageCost(); math.Abs(cost-5) > 1e-9 {
+		t.Fatalf("expected slippage cost 5, got %v", cost)
+	}
+
+	tr.Direction = DirectionShort
+	if slip, _ := tr.EntrySlippage(); math.Abs(slip+0.5) > 1e-9 {
+		t.Fatalf("expected short fill above plan to be favourable, got %v", slip)
+	}
+
+	tr.Entry.PlannedEntryPrice = nil
+	if _, ok := tr.EntrySlippage(); ok {
+		t.Fatalf("expected no slippage without a planned entry")
+	}
+}
+
+func TestEffectiveLeverage(t *testing.T) {
+	tr := Trade{Entry: EntryDetail{Price: 50, Quantity: 400}}
+	if _, ok := tr.EffectiveLeverage(0); ok {
+		t.Fatalf("expected no leverage without an account size")
+	}
+	if lev, ok := tr.EffectiveLeverage(10000); !ok || math.Abs(lev-2) > 1e-9 {
+		t.Fatalf("expected 2x leverage, got %v", lev)
+	}
+	size := 5000.0
+	tr.AccountSizeAtEntry = &size
+	if lev, _ := tr.EffectiveLeverage(10000); math.Abs(lev-4) > 1e-9 {
+		t.Fatalf("expected per-trade account size to win, got %v", lev)
+	}
+}
+
+func TestNetResultConvertsForeignFees(t *testing.T) {
+	rate := 1.1
+	tr := Trade{
+		Direction:   DirectionLong,
+		Currency:    "USD",
+		FeeCurrency: "EUR",
+		FeeFXRate:   &rate,
+		Entry:       EntryDetail{Price: 100, Quantity: 10, Fees: 10},
+		Exit:        &ExitDetail{Price: 110, Quantity: 10, Fees: 10},
+	}
+	if got := tr.NetResult(); math.Abs(got-78) > 1e-9 {
+		t.Fatalf("expected fees converted at 1.1, got %v", got)
+	}
+
+	tr.FeeCurrency = "usd"
+	if got := tr.NetResult(); math.Abs(got-80) > 1e-9 {
+		t.Fatalf("expected same-currency fees untouched, got %v", got)
+	}
+}
+
+func TestParseFXRates(t *testing.T) {
+	rates, err := ParseFXRates("eur/usd=1.25, USD/TWD=32")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if rate, ok := rates.Rate("EUR", "USD"); !ok || rate != 1.25 {
+		t.Fatalf("unexpected direct rate %v %v", rate, ok)
+	}
+	if rate, ok := rates.Rate("usd", "eur"); !ok || math.Abs(rate-0.8) > 1e-9 {
+		t.Fatalf("unexpected inverse rate %v %v", rate, ok)
+	}
+	if _, ok := rates.Rate("JPY", "USD"); ok {
+		t.Fatalf("expected unknown pair to be missing")
+	}
+	if _, err := ParseFXRates("EURUSD=1.1"); err == nil {
+		t.Fatalf("expected malformed pair to fail")
+	}
+}
+
+func TestStopAndTargetDistancePercent(t *testing.T) {
+	stop, target := 99.6, 112.0
+	long := Trade{Direction: DirectionLong, Entry: EntryDetail{Price: 100, StopLoss: &stop, Target: &target}}
+	if got, ok := long.StopDistancePercent(); !ok || math.Abs(got-0.4) > 1e-9 {
+		t.Fatalf("unexpected long stop distance: %v %v", got, ok)
+	}
+	if got, ok := long.TargetDistancePercent(); !ok || math.Abs(got-12) > 1e-9 {
+		t.Fatalf("unexpected long target distance: %v %v", got, ok)
+	}
+
+	shortStop, shortTarget := 105.0, 90.0
+	short := Trade{Direction: DirectionShort, Entry: EntryDetail{Price: 100, StopLoss: &shortStop, Target: &shortTarget}}
+	if got, ok := short.StopDistancePercent(); !ok || math.Abs(got-5) > 1e-9 {
+		t.Fatalf("unexpected short stop distance: %v %v", got, ok)
+	}
+	if got, ok := short.TargetDistancePercent(); !ok || math.Abs(got-10) > 1e-9 {
+		t.Fatalf("unexpected short target distance: %v %v", got, ok)
+	}
+
+	if _, ok := (Trade{Entry: EntryDetail{Price: 100}}).StopDistancePercent(); ok {
+		t.Fatalf("expected missing stop to report false")
+	}
+	if _, ok := (Trade{Entry: EntryDetail{Price: 100}}).TargetDistancePercent(); ok {
+		t.Fatalf("expected missing target to report false")
+	}
+}
+
+func TestNetResultPercentFeeModel(t *testing.T) {
+	long := Trade{
+		Direction: DirectionLong,
+		FeeModel:  FeeModelPercent,
+		FeeRate:   0.001,
+		Entry:     EntryDetail{Price: 100, Quantity: 10, Fees: 99},
+		Exit:      &ExitDetail{Price: 110, Quantity: 10, Fees: 99},
+	}
+	// Gross 100, fees 0.1% of 1000 entry notional plus 0.1% of 1100 exit notional.
+	if got := long.NetResult(); math.Abs(got-(100-1-1.1)) > 1e-9 {
+		t.Fatalf("unexpected long percent net: %v", got)
+	}
+
+	short := long
+	short.Direction = DirectionShort
+	short.Exit = &ExitDetail{Price: 90, Quantity: 10}
+	if got := short.NetResult(); math.Abs(got-(100-1-0.9)) > 1e-9 {
+		t.Fatalf("unexpected short percent net: %v", got)
+	}
+
+	open := long
+	open.Exit = nil
+	if got := open.NetResult(); math.Abs(got+1) > 1e-9 {
+		t.Fatalf("expected open trade to carry the entry fee only, got %v", got)
+	}
+
+	flat := long
+	flat.FeeModel = FeeModelFlat
+	if got := flat.NetResult(); math.Abs(got-(100-198)) > 1e-9 {
+		t.Fatalf("expected flat fees to be unaffected, got %v", got)
+	}
+}
+
+func TestCostBasisAndProceeds(t *testing.T) {
+	long := Trade{
+		Direction:     DirectionLong,
+		Entry:         EntryDetail{Price: 100, Quantity: 10, Fees: 5},
+		Exit:          &ExitDetail{Price: 110, Quantity: 10, Fees: 4},
+		FinancingCost: 2,
+	}
+	if long.CostBasis() != 1005 || long.Proceeds() != 1096 {
+		t.Fatalf("unexpected long accounting: cost %v proceeds %v", long.CostBasis(), long.Proceeds())
+	}
+	if got := long.Proceeds() - long.CostBasis() - long.Financing(); math.Abs(got-long.NetResult()) > 1e-9 {
+		t.Fatalf("expected proceeds minus cost to match net result, got %v vs %v", got, long.NetResult())
+	}
+
+	short := long
+	short.Direction = DirectionShort
+	short.Exit = &ExitDetail{Price: 90, Quantity: 10, Fees: 4}
+	if short.CostBasis() != 904 || short.Proceeds() != 995 {
+		t.Fatalf("unexpected short accounting: cost %v proceeds %v", short.CostBasis(), short.Proceeds())
+	}
+	if got := short.Proceeds() - short.CostBasis() - short.Financing(); math.Abs(got-short.NetResult()) > 1e-9 {
+		t.Fatalf("expected short proceeds minus cost to match net result, got %v vs %v", got, short.NetResult())
+	}
+
+	openLong := long
+	openLong.Exit = nil
+	if openLong.CostBasis() != 1005 || openLong.Proceeds() != 0 {
+		t.Fatalf("unexpected open long accounting: cost %v proceeds %v", openLong.CostBasis(), openLong.Proceeds())
+	}
+	openShort := short
+	openShort.Exit = nil
+	if openShort.CostBasis() != 0 || openShort.Proceeds() != 995 {
+		t.Fatalf("unexpected open short accounting: cost %v proceeds %v", openShort.CostBasis(), openShort.Proceeds())
+	}
+
+	partial := long
+	partial.Exit = &ExitDetail{Price: 110, Quantity: 4, Fees: 4}
+	if partial.CostBasis() != 405 || partial.Proceeds() != 436 {
+		t.Fatalf("unexpected partial accounting: cost %v proceeds %v", partial.CostBasis(), partial.Proceeds())
+	}
+}
+
+func TestSummary(t *testing.T) {
+	stop := 175.0
+	tr := Trade{
+		Instrument: "AAPL",
+		Direction:  DirectionLong,
+		Entry:      EntryDetail{Price: 180.5, Quantity: 100, Fees: 5, StopLoss: &stop},
+		Exit:       &ExitDetail{Price: 190.2, Quantity: 100, Fees: 0},
+	}
+	if got, want := tr.Summary(), "LONG AAPL 100@180.50 → 190.20, +965.00 (+5.3%, 1.8R)"; got != want {
+		t.Fatalf("unexpected summary:\n got %q\nwant %q", got, want)
+	}
+
+	tr.Exit = nil
+	if got, want := tr.Summary(), "LONG AAPL 100@180.50, open, unrealized N/A"; got != want {
+		t.Fatalf("unexpected open summary: %q", got)
+	}
+
+	short := Trade{Instrument: "EURUSD", Direction: DirectionShort, Entry: EntryDetail{Price: 1.1, Quantity: 1000}, Exit: &ExitDetail{Price: 1.2, Quantity: 1000}}
+	if got, want := short.Summary(), "SHORT EURUSD 1000@1.10 → 1.20, -100.00 (-9.1%)"; got != want {
+		t.Fatalf("unexpected short summary: %q", got)
+	}
+}
+
+func TestAnnualizedReturns(t *testing.T) {
+	entry := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := Trade{
+		Direction: DirectionLong,
+		Entry:     EntryDetail{Date: entry, Price: 100, Quantity: 1},
+		Exit:      &ExitDetail{Date: entry.AddDate(0, 0, 73), Price: 102, Quantity: 1},
+	}
+	simple, ok := tr.AnnualizedReturn()
+	if !ok || math.Abs(simple-10) > 1e-9 {
+		t.Fatalf("unexpected simple annualized return: %v %v", simple, ok)
+	}
+	compounded, ok := tr.AnnualizedReturnCompounded()
+	want := (math.Pow(1.02, 5) - 1) * 100
+	if !ok || math.Abs(compounded-want) > 1e-9 {
+		t.Fatalf("unexpected compounded return: got %v want %v", compounded, want)
+	}
+
+	wipeout := tr
+	wipeout.Exit = &ExitDetail{Date: entry.AddDate(0, 0, 10), Price: 0, Quantity: 1, Fees: 5}
+	if got, ok := wipeout.AnnualizedReturnCompounded(); !ok || got != -100 {
+		t.Fatalf("expected total loss to clamp at -100, got %v %v", got, ok)
+	}
+
+	open := tr
+	open.Exit = nil
+	if _, ok := open.AnnualizedReturn(); ok {
+		t.Fatalf("expected open trade to report false")
+	}
+}
+
+func TestHoldDeviation(t *testing.T) {
+	entry := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expected := 5
+	tr := Trade{
+		Entry:            EntryDetail{Date: entry, Price: 100, Quantity: 1},
+		Exit:             &ExitDetail{Date: entry.AddDate(0, 0, 8), Price: 95, Quantity: 1},
+		ExpectedHoldDays: &expected,
+	}
+	if actual, deviation, ok := tr.HoldDeviation(); !ok || actual != 8 || deviation != 3 {
+		t.Fatalf("unexpected hold deviation: %v %v %v", actual, deviation, ok)
+	}
+
+	early := tr
+	early.Exit = &ExitDetail{Date: entry.Add(36 * time.Hour), Price: 105, Quantity: 1}
+	if _, deviation, ok := early.HoldDeviation(); !ok || deviation != -3.5 {
+		t.Fatalf("expected an early exit to deviate negatively, got %v %v", deviation, ok)
+	}
+
+	unplanned := tr
+	unplanned.ExpectedHoldDays = nil
+	if _, _, ok := unplanned.HoldDeviation(); ok {
+		t.Fatalf("expected a trade without an expected hold to report false")
+	}
+	open := tr
+	open.Exit = nil
+	if _, _, ok := open.HoldDeviation(); ok {
+		t.Fatalf("expected an open trade to report false")
+	}
+}
+
+func TestProfitPerDay(t *testing.T) {
+	entry := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := Trade{
+		Direction: DirectionLong,
+		Entry:     EntryDetail{Date: entry, Price: 100, Quantity: 10},
+		Exit:      &ExitDetail{Date: entry.AddDate(0, 0, 4), Price: 110, Quantity: 10},
+	}
+	if v, ok := tr.ProfitPerDay(); !ok || v != 25 {
+		t.Fatalf("expected 25 per day, got %v %v", v, ok)
+	}
+
+	sameDay := tr
+	sameDay.Exit = &ExitDetail{Date: entry, Price: 110, Quantity: 10}
+	if v, ok := sameDay.ProfitPerDay(); !ok || v != 100 {
+		t.Fatalf("expected a same-day exit to count as one day, got %v %v", v, ok)
+	}
+
+	backwards := tr
+	backwards.Exit = &ExitDetail{Date: entry.AddDate(0, 0, -1), Price: 110, Quantity: 10}
+	if _, ok := backwards.ProfitPerDay(); ok {
+		t.Fatalf("expected an exit before entry to report false")
+	}
+	open := tr
+	open.Exit = nil
+	if _, ok := open.ProfitPerDay(); ok {
+		t.Fatalf("expected an open trade to report false")
+	}
+}
+
+func TestAverageFillPrices(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	single := Trade{
+		Direction: DirectionLong,
+		Entry:     EntryDetail{Price: 100, Quantity: 10},
+		Exit:      &ExitDetail{Price: 110, Quantity: 10},
+	}
+	if single.AvgEntryPrice() != 100 || single.AvgExitPrice() != 110 {
+		t.Fatalf("expected single-fill prices, got %v %v", single.AvgEntryPrice(), single.AvgExitPrice())
+	}
+	open := single
+	open.Exit = nil
+	if open.AvgExitPrice() != 0 {
+		t.Fatalf("expected no exit price for an open trade, got %v", open.AvgExitPrice())
+	}
+
+	scaled := Trade{
+		Direction: DirectionLong,
+		Entry: EntryDetail{Price: 100, Quantity: 10, Fills: []Fill{
+			{Date: day.AddDate(0, 0, 1), Price: 104, Quantity: 10},
+			{Date: day, Price: 100, Quantity: 30},
+		}},
+		Exit: &ExitDetail{Price: 110, Quantity: 10, Fills: []Fill{
+			{Date: day.AddDate(0, 0, 5), Price: 110, Quantity: 20},
+			{Date: day.AddDate(0, 0, 9), Price: 120, Quantity: 20},
+		}},
+	}
+	if got := scaled.AvgEntryPrice(); got != 101 {
+		t.Fatalf("expected a weighted entry of 101, got %v", got)
+	}
+	if got := scaled.AvgExitPrice(); got != 115 {
+		t.Fatalf("expected a weighted exit of 115, got %v", got)
+	}
+
+	scaled.SyncFills()
+	if scaled.Entry.Price != 101 || scaled.Entry.Quantity != 40 || !scaled.Entry.Date.Equal(day) {
+		t.Fatalf("unexpected synced entry: %+v", scaled.Entry)
+	}
+	if scaled.Exit.Price != 115 || scaled.Exit.Quantity != 40 || !scaled.Exit.Date.Equal(day.AddDate(0, 0, 9)) {
+		t.Fatalf("unexpected synced exit: %+v", scaled.Exit)
+	}
+	if got := scaled.NetResult(); got != (115-101)*40 {
+		t.Fatalf("expected the result to use the average prices, got %v", got)
+	}
+}
+
+func TestTradingSession(t *testing.T) {
+	hours, err := ParseMarketHours("us=09:30-16:00, *=09:00-13:30")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	at := func(market string, h, m int) Trade {
+		return Trade{Market: market, Entry: EntryDetail{Date: time.Date(2024, 6, 3, h, m, 0, 0, time.UTC)}}
+	}
+	cases := []struct {
+		trade Trade
+		want  Session
+	}{
+		{at("US", 8, 0), SessionPreMarket},
+		{at("US", 9, 30), SessionRegular},
+		{at("US", 16, 0), SessionAfterHours},
+		{at("TW", 13, 0), SessionRegular},
+		{at("TW", 14, 0), SessionAfterHours},
+	}
+	for _, c := range cases {
+		if got, ok := c.trade.TradingSession(hours); !ok || got != c.want {
+			t.Fatalf("%s %s: expected %s, got %s %v", c.trade.Market, c.trade.Entry.Date.Format("15:04"), c.want, got, ok)
+		}
+	}
+
+	dateOnly := at("US", 0, 0)
+	if _, ok := dateOnly.TradingSession(hours); ok {
+		t.Fatalf("expected an entry without a time to have no session")
+	}
+	dateOnly.Session = SessionAfterHours
+	if got, ok := dateOnly.TradingSession(hours); !ok || got != SessionAfterHours {
+		t.Fatalf("expected the selected session to be used, got %s %v", got, ok)
+	}
+	if _, ok := at("US", 10, 0).TradingSession(nil); ok {
+		t.Fatalf("expected no session without market hours")
+	}
+
+	for _, raw := range []string{"US", "US=16:00-09:30", "US=9h-16h"} {
+		if _, err := ParseMarketHours(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestTimeStopReached(t *testing.T) {
+	stop := time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC)
+	tr := Trade{Entry: EntryDetail{Date: stop.AddDate(0, 0, -20)}, TimeStopDate: &stop}
+	if tr.TimeStopReached(stop.Add(-time.Hour)) {
+		t.Fatalf("expected the time stop not to be reached before its date")
+	}
+	if !tr.TimeStopReached(stop) || !tr.TimeStopReached(stop.AddDate(0, 0, 3)) {
+		t.Fatalf("expected the time stop to be reached on and after its date")
+	}
+	closed := tr
+	closed.Exit = &ExitDetail{Date: stop.AddDate(0, 0, 1)}
+	if closed.TimeStopReached(stop.AddDate(0, 0, 3)) {
+		t.Fatalf("expected a closed trade never to be flagged")
+	}
+	unplanned := tr
+	unplanned.TimeStopDate = nil
+	if unplanned.TimeStopReached(stop) {
+		t.Fatalf("expected a trade without a time stop never to be flagged")
+	}
+}
+
+func TestMoneyRoundTrip(t *testing.T) {
+	if got := ToMoney(0.1) + ToMoney(0.2); got.Float64() != 0.3 {
+		t.Fatalf("expected 0.1 + 0.2 to be exactly 0.3, got %v", got.Float64())
+	}
+	if got := ToMoney(-1.23456); got != -12346 {
+		t.Fatalf("expected rounding to the nearest minor unit, got %d", got)
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	yes := true
+	archived := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	build := func() *Trade {
+		return &Trade{
+			ID:        "t1",
+			FeeFXRate: f(1.1),
+			Entry:     EntryDetail{Price: 100, Quantity: 10, StopLoss: f(95), Target: f(110), RiskPerShare: f(5), PlannedEntryPrice: f(99), Fills: []Fill{{Price: 100, Quantity: 10}}},
+			Exit:      &ExitDetail{Price: 105, Quantity: 10, Fills: []Fill{{Price: 105, Quantity: 10}}},
+			RiskManagement: RiskManagement{
+				WinProbability: f(0.5),
+			},
+			FollowUps:          []FollowUp{{DaysAfter: 7, Price: 108}},
+			Review:             TradeReview{Tags: []string{"breakout"}, Mistakes: []string{"追價進場"}, FollowedPlan: &yes},
+			Events:             []Event{{Kind: EventReopened, Exit: &ExitDetail{Price: 101}}},
+			ArchivedAt:         &archived,
+			TimeStopDate:       &archived,
+			ExecutionScore:     f(7),
+			ConfidenceBefore:   f(6),
+			ConfidenceAfter:    f(5),
+			AccountSizeAtEntry: f(10000),
+			Shares:             []ShareLink{{ID: "s1"}},
+		}
+	}
+	orig := build()
+	clone := orig.Clone()
+	if !reflect.DeepEqual(orig, clone) {
+		t.Fatalf("expected an equal clone")
+	}
+
+	*clone.FeeFXRate = 2
+	*clone.Entry.StopLoss = 1
+	*clone.Entry.Target = 1
+	*clone.Entry.RiskPerShare = 1
+	*clone.Entry.PlannedEntryPrice = 1
+	clone.Exit.Price = 1
+	clone.Entry.Fills[0].Price = 1
+	clone.Exit.Fills[0].Price = 1
+	*clone.RiskManagement.WinProbability = 1
+	clone.FollowUps[0].Price = 1
+	clone.Review.Tags[0] = "changed"
+	clone.Review.Mistakes[0] = "changed"
+	*clone.Review.FollowedPlan = false
+	clone.Events[0].Exit.Price = 1
+	*clone.ArchivedAt = time.Time{}
+	*clone.TimeStopDate = time.Time{}
+	*clone.ExecutionScore = 1
+	*clone.ConfidenceBefore = 1
+	*clone.ConfidenceAfter = 1
+	*clone.AccountSizeAtEntry = 1
+	clone.Shares[0].ID = "changed"
+
+	if !reflect.DeepEqual(orig, build()) {
+		t.Fatalf("changing the clone changed the original: %+v", orig)
+	}
+	if (*Trade)(nil).Clone() != nil {
+		t.Fatalf("expected a nil trade to clone to nil")
+	}
+}
@@ -0,0 +1,78 @@
+package quotes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNoQuote is returned when the provider has no close for the requested date yet.
+var ErrNoQuote = errors.New("no quote available")
+
+// Provider supplies daily closing prices.
+type Provider interface {
+	// CloseOnOrAfter returns the first available close at or after date along
+	// with the session date it belongs to.
+	CloseOnOrAfter(ctx context.Context, instrument string, date time.Time) (float64, time.Time, error)
+}
+
+// HTTPProvider fetches closes from a JSON endpoint. The URL template may contain
+// {symbol} and {date} (YYYY-MM-DD) placeholders and must respond with
+// {"price": 123.45, "date": "2024-01-02"}; a 404 means no quote is available yet.
+type HTTPProvider struct {
+	URLTemplate string
+	Client      *http.Client
+}
+
+// NewHTTPProvider constructs an HTTPProvider with a bounded request timeout.
+func NewHTTPProvider(urlTemplate string) *HTTPProvider {
+	return &HTTPProvider{
+		URLTemplate: urlTemplate,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// CloseOnOrAfter implements Provider.
+func (p *HTTPProvider) CloseOnOrAfter(ctx context.Context, instrument string, date time.Time) (float64, time.Time, error) {
+	target := strings.NewReplacer(
+		"{symbol}", url.QueryEscape(instrument),
+		"{date}", date.Format("2006-01-02"),
+	).Replace(p.URLTemplate)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, time.Time{}, ErrNoQuote
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, time.Time{}, fmt.Errorf("quote provider returned %s", resp.Status)
+	}
+
+	var payload struct {
+		Price float64 `json:"price"`
+		Date  string  `json:"date"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return 0, time.Time{}, fmt.Errorf("decode quote: %w", err)
+	}
+	at := date
+	if payload.Date != "" {
+		if parsed, err := time.Parse("2006-01-02", payload.Date); err == nil {
+			at = parsed
+		}
+	}
+	return payload.Price, at, nil
+}
@@ -0,0 +1,37 @@
+package quotes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPProviderCloseOnOrAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("symbol") == "MISSING" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("date") != "2024-03-09" {
+			t.Errorf("unexpected date %q", r.URL.Query().Get("date"))
+		}
+		w.Write([]byte(`{"price": 187.5, "date": "2024-03-11"}`))
+	}))
+	defer srv.Close()
+
+	provider := NewHTTPProvider(srv.URL + "?symbol={symbol}&date={date}")
+	price, at, err := provider.CloseOnOrAfter(context.Background(), "AAPL", time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if price != 187.5 || at.Format("2006-01-02") != "2024-03-11" {
+		t.Fatalf("unexpected quote %v at %v", price, at)
+	}
+
+	if _, _, err := provider.CloseOnOrAfter(context.Background(), "MISSING", time.Now()); !errors.Is(err, ErrNoQuote) {
+		t.Fatalf("expected ErrNoQuote, got %v", err)
+	}
+}
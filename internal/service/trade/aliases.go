@@ -0,0 +1,79 @@
+package trade
+
+import (
+	"fmt"
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// ParseInstrumentAliases parses a comma separated list of alias=symbol pairs
+// such as "台積電=2330,TSM=2330".
+func ParseInstrumentAliases(raw string) (map[string]string, error) {
+	aliases := map[string]string{}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		alias, symbol, ok := strings.Cut(item, "=")
+		alias, symbol = strings.TrimSpace(alias), strings.TrimSpace(symbol)
+		if !ok || alias == "" || symbol == "" {
+			return nil, fmt.Errorf("invalid instrument alias %q", item)
+		}
+		aliases[alias] = symbol
+	}
+	return aliases, nil
+}
+
+// WithInstrumentAliases maps alternative names of an instrument onto its
+// canonical symbol. Aliases match case-insensitively; trades saved under an
+// alias are stored with the symbol so filters and per-instrument statistics
+// see one instrument. An empty map leaves instruments untouched.
+func WithInstrumentAliases(aliases map[string]string) Option {
+	return func(s *Service) {
+		s.instrumentAliases = make(map[string]string, len(aliases))
+		for alias, symbol := range aliases {
+			s.instrumentAliases[strings.ToUpper(strings.TrimSpace(alias))] = strings.TrimSpace(symbol)
+		}
+	}
+}
+
+// InstrumentAliases returns a copy of the configured aliases, keyed by the
+// upper-cased alias, or nil when unconfigured.
+func (s *Service) InstrumentAliases() map[string]string {
+	if len(s.instrumentAliases) == 0 {
+		return nil
+	}
+	aliases := make(map[string]string, len(s.instrumentAliases))
+	for alias, symbol := range s.instrumentAliases {
+		aliases[alias] = symbol
+	}
+	return aliases
+}
+
+// CanonicalInstrument returns the symbol configured for an alias, or the
+// instrument unchanged when it is not an alias.
+func (s *Service) CanonicalInstrument(instrument string) string {
+	if symbol, ok := s.instrumentAliases[strings.ToUpper(strings.TrimSpace(instrument))]; ok {
+		return symbol
+	}
+	return instrument
+}
+
+// applyInstrumentAlias replaces an aliased instrument with its symbol and keeps
+// what was typed in InstrumentAlias. An alias recorded earlier survives edits
+// as long as it still resolves to the trade's instrument.
+func (s *Service) applyInstrumentAlias(tr *domain.Trade) {
+	if len(s.instrumentAliases) == 0 {
+		return
+	}
+	if symbol := s.CanonicalInstrument(tr.Instrument); symbol != tr.Instrument {
+		tr.InstrumentAlias = strings.TrimSpace(tr.Instrument)
+		tr.Instrument = symbol
+		return
+	}
+	if tr.InstrumentAlias != "" && s.CanonicalInstrument(tr.InstrumentAlias) != tr.Instrument {
+		tr.InstrumentAlias = ""
+	}
+}
@@ -0,0 +1,67 @@
+package trade
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+	"best_trade_logs/internal/storage"
+)
+
+// AutoArchive archives closed trades that exited more than olderThan ago.
+// Archived trades leave the active list but stay viewable and are never pruned.
+func (s *Service) AutoArchive(ctx context.Context, olderThan time.Duration) (int, error) {
+	now := s.Now()
+	cutoff := now.Add(-olderThan)
+	archived := 0
+	err := s.repo.Tx(ctx, func(repo storage.TradeRepository) error {
+		archived = 0
+		trades, err := repo.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, tr := range trades {
+			if tr.IsDeleted() || tr.IsArchived() {
+				continue
+			}
+			if !tr.HasExited() || tr.Exit.Date.IsZero() || !tr.Exit.Date.Before(cutoff) {
+				continue
+			}
+			at := now
+			tr.ArchivedAt = &at
+			note := fmt.Sprintf("出場已超過 %d 天，自動封存", int(olderThan.Hours()/24))
+			tr.Events = append(tr.Events, domain.Event{Kind: domain.EventArchived, At: now, Note: note})
+			tr.UpdatedAt = now
+			if err := repo.Update(ctx, tr); err != nil {
+				return err
+			}
+			archived++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return archived, nil
+}
+
+// RunAutoArchive calls AutoArchive immediately and then on every tick until ctx is cancelled.
+func (s *Service) RunAutoArchive(ctx context.Context, olderThan, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		archived, err := s.AutoArchive(ctx, olderThan)
+		if err != nil {
+			log.Printf("auto archive failed: %v", err)
+		} else if archived > 0 {
+			log.Printf("auto archive: archived %d trades", archived)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
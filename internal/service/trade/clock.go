@@ -0,0 +1,32 @@
+package trade
+
+import "time"
+
+// Clock supplies the current time. Tests override it to make time-based
+// features deterministic.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface.
+type ClockFunc func() time.Time
+
+// Now calls f.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock reads the wall clock.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// WithClock replaces the clock used for timestamps and time-based defaults.
+func WithClock(clock Clock) Option {
+	return func(s *Service) {
+		s.clock = clock
+	}
+}
+
+// Now returns the service clock's current time in UTC.
+func (s *Service) Now() time.Time {
+	return s.clock.Now().UTC()
+}
@@ -0,0 +1,50 @@
+package trade
+
+import (
+	"context"
+	"errors"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+var (
+	// ErrTradeClosed is returned when closing a trade that already has an exit.
+	ErrTradeClosed = errors.New("trade is already closed")
+	// ErrInvalidExit is returned when the exit details fail validation.
+	ErrInvalidExit = errors.New("invalid exit")
+)
+
+// CloseTrade records the exit of an open trade. Closing an already closed trade
+// fails with ErrTradeClosed unless override is set, in which case the previous
+// exit is replaced. The exit must not precede the entry and needs a positive
+// price; a missing quantity defaults to the entry quantity.
+func (s *Service) CloseTrade(ctx context.Context, id string, exit domain.ExitDetail, override bool) (*domain.Trade, error) {
+	tr, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tr.HasExited() && !override {
+		return nil, ErrTradeClosed
+	}
+	if exit.Price <= 0 {
+		return nil, errors.Join(ErrInvalidExit, errors.New("exit price must be positive"))
+	}
+	if exit.Quantity < 0 || exit.Fees < 0 {
+		return nil, errors.Join(ErrInvalidExit, errors.New("exit quantity and fees must not be negative"))
+	}
+	if exit.Date.IsZero() {
+		exit.Date = s.Now()
+	}
+	if !tr.Entry.Date.IsZero() && exit.Date.Before(tr.Entry.Date) {
+		return nil, errors.Join(ErrInvalidExit, errors.New("exit date is before entry date"))
+	}
+	if exit.Quantity == 0 {
+		exit.Quantity = tr.Entry.Quantity
+	}
+
+	tr.Exit = &exit
+	if err := s.Update(ctx, tr); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
@@ -0,0 +1,49 @@
+package trade
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// DuplicatePriceTolerance is the relative entry price difference under which two
+// trades on the same instrument and entry date are treated as possible duplicates.
+const DuplicatePriceTolerance = 0.001
+
+// FindPossibleDuplicates returns stored trades that look like the same fill as
+// candidate: same instrument, same entry date and an entry price within
+// DuplicatePriceTolerance. The candidate itself is skipped when it has an ID.
+func (s *Service) FindPossibleDuplicates(ctx context.Context, candidate *domain.Trade) ([]*domain.Trade, error) {
+	trades, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*domain.Trade
+	for _, tr := range trades {
+		if candidate.ID != "" && tr.ID == candidate.ID {
+			continue
+		}
+		if isPossibleDuplicate(candidate, tr) {
+			matches = append(matches, tr)
+		}
+	}
+	return matches, nil
+}
+
+func isPossibleDuplicate(a, b *domain.Trade) bool {
+	if !strings.EqualFold(strings.TrimSpace(a.Instrument), strings.TrimSpace(b.Instrument)) {
+		return false
+	}
+	ay, am, ad := a.Entry.Date.Date()
+	by, bm, bd := b.Entry.Date.Date()
+	if ay != by || am != bm || ad != bd {
+		return false
+	}
+	ref := math.Max(math.Abs(a.Entry.Price), math.Abs(b.Entry.Price))
+	if ref == 0 {
+		return true
+	}
+	return math.Abs(a.Entry.Price-b.Entry.Price)/ref <= DuplicatePriceTolerance
+}
@@ -0,0 +1,59 @@
+package trade
+
+import (
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// WithExitReasons configures the standard exit reasons offered on the trade
+// form. Reasons matching an entry case-insensitively are stored with its
+// spelling; anything else is kept as free text. An empty list leaves exit
+// reasons free-text.
+func WithExitReasons(reasons []string) Option {
+	return func(s *Service) {
+		s.exitReasons = nil
+		seen := make(map[string]struct{})
+		for _, reason := range reasons {
+			reason = NormalizeExitReason(reason)
+			key := strings.ToLower(reason)
+			if reason == "" {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			s.exitReasons = append(s.exitReasons, reason)
+		}
+	}
+}
+
+// ExitReasons returns the configured exit reasons, or nil when unconfigured.
+func (s *Service) ExitReasons() []string {
+	return s.exitReasons
+}
+
+// NormalizeExitReason trims a reason and collapses its inner whitespace, so
+// reasons that only differ in spacing or line breaks are grouped together.
+func NormalizeExitReason(reason string) string {
+	return strings.Join(strings.Fields(reason), " ")
+}
+
+// canonicalExitReason returns the configured spelling of reason when it is a
+// standard reason. Free-text reasons are returned unchanged.
+func (s *Service) canonicalExitReason(reason string) string {
+	normalized := NormalizeExitReason(reason)
+	for _, known := range s.exitReasons {
+		if strings.EqualFold(known, normalized) {
+			return known
+		}
+	}
+	return reason
+}
+
+func (s *Service) applyExitReason(tr *domain.Trade) {
+	if tr.Exit != nil {
+		tr.Exit.Reason = s.canonicalExitReason(tr.Exit.Reason)
+	}
+}
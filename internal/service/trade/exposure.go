@@ -0,0 +1,107 @@
+package trade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// ExposureLimits caps the gross exposure of open trades. Trades without a
+// currency count as Base. A zero limit disables that check.
+type ExposureLimits struct {
+	Base        string
+	Total       float64
+	PerCurrency map[string]float64
+}
+
+// ParseCurrencyLimits parses a comma separated list such as "USD=100000,EUR=50000".
+func ParseCurrencyLimits(raw string) (map[string]float64, error) {
+	limits := map[string]float64{}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		code, value, ok := strings.Cut(item, "=")
+		limit, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || domain.NormalizeCurrency(code) == "" || err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid exposure limit %q", item)
+		}
+		limits[domain.NormalizeCurrency(code)] = limit
+	}
+	return limits, nil
+}
+
+// WithExposureLimits enables open-exposure warnings on new trades.
+func WithExposureLimits(limits ExposureLimits) Option {
+	return func(s *Service) {
+		limits.Base = domain.NormalizeCurrency(limits.Base)
+		s.exposureLimits = limits
+	}
+}
+
+// ExposureWarnings reports the configured limits that adding tr would push open
+// gross exposure over. Closed and paper trades never trigger a warning, and
+// paper trades do not count towards the real-money exposure. Exposure in a
+// currency without a configured rate to the base currency is reported instead
+// of being silently left out of the total.
+func (s *Service) ExposureWarnings(ctx context.Context, tr *domain.Trade) ([]string, error) {
+	limits := s.exposureLimits
+	if tr.HasExited() || tr.IsPaper || (limits.Total <= 0 && len(limits.PerCurrency) == 0) {
+		return nil, nil
+	}
+	trades, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byCurrency := make(map[string]float64)
+	for _, open := range trades {
+		if !open.HasExited() && !open.IsPaper {
+			byCurrency[s.exposureCurrency(open)] += open.GrossExposure()
+		}
+	}
+	byCurrency[s.exposureCurrency(tr)] += tr.GrossExposure()
+
+	var warnings []string
+	newCurrency := s.exposureCurrency(tr)
+	if limit, ok := limits.PerCurrency[newCurrency]; ok && byCurrency[newCurrency] > limit {
+		warnings = append(warnings, fmt.Sprintf("%s 未平倉曝險 %.2f 超過上限 %.2f", newCurrency, byCurrency[newCurrency], limit))
+	}
+	if limits.Total > 0 {
+		total, missing := s.totalExposure(byCurrency)
+		if len(missing) > 0 {
+			warnings = append(warnings, fmt.Sprintf("缺少 %s 兌 %s 的匯率，無法計算總曝險", strings.Join(missing, "、"), limits.Base))
+		} else if total > limits.Total {
+			warnings = append(warnings, fmt.Sprintf("總未平倉曝險 %.2f %s 超過上限 %.2f", total, limits.Base, limits.Total))
+		}
+	}
+	return warnings, nil
+}
+
+func (s *Service) exposureCurrency(tr *domain.Trade) string {
+	if code := domain.NormalizeCurrency(tr.Currency); code != "" {
+		return code
+	}
+	return s.exposureLimits.Base
+}
+
+// totalExposure converts per-currency exposure into the base currency and
+// returns the currencies that could not be converted, sorted.
+func (s *Service) totalExposure(byCurrency map[string]float64) (float64, []string) {
+	var total float64
+	var missing []string
+	for code, amount := range byCurrency {
+		rate, ok := s.fxRates.Rate(code, s.exposureLimits.Base)
+		if !ok {
+			missing = append(missing, code)
+			continue
+		}
+		total += amount * rate
+	}
+	sort.Strings(missing)
+	return total, missing
+}
@@ -0,0 +1,121 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+	"best_trade_logs/internal/quotes"
+)
+
+// AutoFollowUpDays are the follow-up checkpoints filled from the quote provider.
+var AutoFollowUpDays = []int{7, 30}
+
+// AutoFollowUps fills missing follow-up observations for closed trades whose
+// checkpoint dates have passed. Existing follow-ups for a checkpoint, manual or
+// automatic, are never overwritten. It returns the number of follow-ups added.
+func (s *Service) AutoFollowUps(ctx context.Context, provider quotes.Provider, now time.Time) (int, error) {
+	trades, err := s.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, tr := range trades {
+		if tr.Exit == nil || tr.Exit.Date.IsZero() {
+			continue
+		}
+		changed := false
+		for _, days := range AutoFollowUpDays {
+			target := tr.Exit.Date.AddDate(0, 0, days)
+			if target.After(now) || hasFollowUp(tr.FollowUps, days) {
+				continue
+			}
+			price, at, err := provider.CloseOnOrAfter(ctx, tr.Instrument, target)
+			if err != nil {
+				if !errors.Is(err, quotes.ErrNoQuote) {
+					log.Printf("auto follow-up %s (%s, +%d): %v", tr.ID, tr.Instrument, days, err)
+				}
+				continue
+			}
+			tr.FollowUps = append(tr.FollowUps, domain.FollowUp{
+				DaysAfter: days,
+				Price:     price,
+				Notes:     fmt.Sprintf("自動取得 %s 收盤價", at.Format("2006-01-02")),
+				LoggedAt:  now.UTC(),
+				Auto:      true,
+			})
+			changed = true
+			added++
+		}
+		if !changed {
+			continue
+		}
+		tr.UpdatedAt = now.UTC()
+		if err := s.repo.Update(ctx, tr); err != nil {
+			return added, err
+		}
+	}
+	return added, nil
+}
+
+// RunAutoFollowUps calls AutoFollowUps immediately and then on every tick until ctx is cancelled.
+func (s *Service) RunAutoFollowUps(ctx context.Context, provider quotes.Provider, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		added, err := s.AutoFollowUps(ctx, provider, s.Now())
+		if err != nil {
+			log.Printf("auto follow-ups failed: %v", err)
+		} else if added > 0 {
+			log.Printf("auto follow-ups: added %d observations", added)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func hasFollowUp(followUps []domain.FollowUp, days int) bool {
+	for _, f := range followUps {
+		if f.DaysAfter == days && !f.Orphaned {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutAutoFollowUp drops automatic observations for a checkpoint so a manual
+// follow-up replaces them.
+func withoutAutoFollowUp(followUps []domain.FollowUp, days int) []domain.FollowUp {
+	kept := followUps[:0:0]
+	for _, f := range followUps {
+		if f.Auto && f.DaysAfter == days {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// SortFollowUps stores a trade's follow-ups in timeline order and drops
+// duplicate observations for the same day count, keeping the latest. It
+// returns how many observations were removed.
+func (s *Service) SortFollowUps(ctx context.Context, tradeID string) (int, error) {
+	tr, err := s.Get(ctx, tradeID)
+	if err != nil {
+		return 0, err
+	}
+	compact := tr.CompactFollowUps()
+	removed := len(tr.FollowUps) - len(compact)
+	if len(compact) == 0 {
+		compact = nil
+	}
+	tr.FollowUps = compact
+	tr.UpdatedAt = s.Now()
+	return removed, s.repo.Update(ctx, tr)
+}
@@ -0,0 +1,83 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	domain "best_trade_logs/internal/domain/trade"
+	"best_trade_logs/internal/storage"
+)
+
+// ErrInvalidHedge is returned when a trade is linked as a hedge of itself, of
+// a trade that does not exist, or in a way that would chain hedges together.
+var ErrInvalidHedge = errors.New("invalid hedge")
+
+// checkHedge validates tr.HedgeOf. A hedge always points at the primary trade
+// of its group, so the primary may not itself be a hedge and a trade that
+// other trades hedge may not become a hedge.
+func (s *Service) checkHedge(ctx context.Context, tr *domain.Trade) error {
+	if tr.HedgeOf == "" {
+		return nil
+	}
+	if tr.HedgeOf == tr.ID {
+		return fmt.Errorf("%w: a trade cannot hedge itself", ErrInvalidHedge)
+	}
+	primary, err := s.Get(ctx, tr.HedgeOf)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%w: trade %s not found", ErrInvalidHedge, tr.HedgeOf)
+	}
+	if err != nil {
+		return err
+	}
+	if primary.HedgeOf != "" {
+		return fmt.Errorf("%w: trade %s is itself a hedge", ErrInvalidHedge, tr.HedgeOf)
+	}
+	if tr.ID == "" {
+		return nil
+	}
+	legs, err := s.hedgeLegs(ctx, tr.ID)
+	if err != nil {
+		return err
+	}
+	if len(legs) > 0 {
+		return fmt.Errorf("%w: trade %s is hedged by other trades", ErrInvalidHedge, tr.ID)
+	}
+	return nil
+}
+
+func (s *Service) hedgeLegs(ctx context.Context, primaryID string) ([]*domain.Trade, error) {
+	trades, err := s.ListWithArchived(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var legs []*domain.Trade
+	for _, tr := range trades {
+		if tr.HedgeOf == primaryID {
+			legs = append(legs, tr)
+		}
+	}
+	return legs, nil
+}
+
+// HedgeGroup returns the trades hedged together with tr, the primary trade
+// first and then its hedges in list order. It returns nil when tr neither
+// hedges nor is hedged by another trade.
+func (s *Service) HedgeGroup(ctx context.Context, tr *domain.Trade) ([]*domain.Trade, error) {
+	primary := tr
+	if tr.HedgeOf != "" {
+		p, err := s.Get(ctx, tr.HedgeOf)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		primary = p
+	}
+	legs, err := s.hedgeLegs(ctx, primary.ID)
+	if err != nil || len(legs) == 0 {
+		return nil, err
+	}
+	return append([]*domain.Trade{primary}, legs...), nil
+}
@@ -0,0 +1,74 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"best_trade_logs/internal/storage"
+)
+
+// ErrInvalidMapping is returned when an import mapping cannot be saved.
+var ErrInvalidMapping = errors.New("invalid import mapping")
+
+// WithImportMappings sets where CSV import mappings are saved. Without it they
+// are kept in memory and lost when the process exits.
+func WithImportMappings(repo storage.ImportMappingRepository) Option {
+	return func(s *Service) {
+		if repo != nil {
+			s.importMappings = repo
+		}
+	}
+}
+
+// SaveImportMapping stores a column mapping under its name so later imports of
+// the same layout can reuse it.
+func (s *Service) SaveImportMapping(ctx context.Context, m storage.ImportMapping) error {
+	m.Name = strings.TrimSpace(m.Name)
+	if m.Name == "" {
+		return errors.Join(ErrInvalidMapping, errors.New("name is required"))
+	}
+	m.UpdatedAt = s.Now()
+	return s.importMappings.SaveMapping(ctx, m)
+}
+
+// ImportMapping returns the mapping saved under name.
+func (s *Service) ImportMapping(ctx context.Context, name string) (storage.ImportMapping, error) {
+	return s.importMappings.GetMapping(ctx, strings.TrimSpace(name))
+}
+
+// ImportMappings lists the saved mappings ordered by name.
+func (s *Service) ImportMappings(ctx context.Context) ([]storage.ImportMapping, error) {
+	return s.importMappings.ListMappings(ctx)
+}
+
+// MatchImportMapping returns the most recently saved mapping whose headers
+// equal headers, ignoring case and surrounding spaces.
+func (s *Service) MatchImportMapping(ctx context.Context, headers []string) (storage.ImportMapping, bool, error) {
+	mappings, err := s.importMappings.ListMappings(ctx)
+	if err != nil {
+		return storage.ImportMapping{}, false, err
+	}
+	var (
+		best  storage.ImportMapping
+		found bool
+	)
+	for _, m := range mappings {
+		if sameHeaders(m.Headers, headers) && (!found || m.UpdatedAt.After(best.UpdatedAt)) {
+			best, found = m, true
+		}
+	}
+	return best, found, nil
+}
+
+func sameHeaders(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(strings.TrimSpace(a[i]), strings.TrimSpace(b[i])) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package trade
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	domain "best_trade_logs/internal/domain/trade"
+	"best_trade_logs/internal/storage"
+)
+
+// Migration describes an idempotent backfill applied to every stored trade.
+// Apply must report whether it changed the trade so untouched records are not rewritten.
+type Migration struct {
+	Name  string
+	Apply func(tr *domain.Trade) bool
+}
+
+// MigrationReport summarises the outcome of a single migration run.
+type MigrationReport struct {
+	Name    string
+	Scanned int
+	Updated int
+}
+
+// Migrations lists the registered backfills in the order they should run.
+var Migrations = []Migration{
+	{Name: "backfill-exit-quantity", Apply: backfillExitQuantity},
+}
+
+// RunMigrations iterates all stored trades and applies each migration in turn.
+// Running it repeatedly is safe: trades that are already up to date are skipped.
+func (s *Service) RunMigrations(ctx context.Context, migrations []Migration) ([]MigrationReport, error) {
+	reports := make([]MigrationReport, 0, len(migrations))
+	for _, m := range migrations {
+		var report MigrationReport
+		err := s.repo.Tx(ctx, func(repo storage.TradeRepository) error {
+			report = MigrationReport{Name: m.Name}
+			trades, err := repo.List(ctx)
+			if err != nil {
+				return fmt.Errorf("migration %s: list trades: %w", m.Name, err)
+			}
+			for _, tr := range trades {
+				report.Scanned++
+				if !m.Apply(tr) {
+					continue
+				}
+				tr.UpdatedAt = s.Now()
+				if err := repo.Update(ctx, tr); err != nil {
+					return fmt.Errorf("migration %s: update trade %s: %w", m.Name, tr.ID, err)
+				}
+				report.Updated++
+			}
+			return nil
+		})
+		if err != nil {
+			return reports, err
+		}
+		log.Printf("migration %s: scanned %d trades, updated %d", report.Name, report.Scanned, report.Updated)
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// backfillExitQuantity fills the exit quantity for closed trades recorded before
+// the form defaulted it to the entry quantity.
+func backfillExitQuantity(tr *domain.Trade) bool {
+	if tr.Exit == nil || tr.Exit.Quantity != 0 {
+		return false
+	}
+	tr.Exit.Quantity = tr.Entry.Quantity
+	return true
+}
@@ -0,0 +1,71 @@
+package trade
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultMistakeChecklist is the checklist offered when none is configured.
+var DefaultMistakeChecklist = []string{"追價進場", "移動停損", "沒有計畫", "部位過大", "提早出場"}
+
+// WithMistakeChecklist replaces the checklist of common mistakes shown on the
+// trade form. Blank and duplicate (case-insensitive) entries are dropped.
+func WithMistakeChecklist(items []string) Option {
+	return func(s *Service) {
+		s.mistakes = nil
+		seen := make(map[string]struct{})
+		for _, item := range items {
+			item = strings.TrimSpace(item)
+			key := strings.ToLower(item)
+			if item == "" {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			s.mistakes = append(s.mistakes, item)
+		}
+	}
+}
+
+// MistakeChecklist returns the configured checklist of common mistakes.
+func (s *Service) MistakeChecklist() []string {
+	return s.mistakes
+}
+
+// normalizeMistakes trims and de-duplicates the selected mistakes, maps
+// checklist items to their canonical spelling and orders them as in the
+// checklist. Items outside the checklist are kept after the known ones.
+func (s *Service) normalizeMistakes(selected []string) []string {
+	rank := make(map[string]int, len(s.mistakes))
+	for i, item := range s.mistakes {
+		rank[strings.ToLower(item)] = i
+	}
+	seen := make(map[string]struct{})
+	var out []string
+	for _, item := range selected {
+		item = strings.TrimSpace(item)
+		key := strings.ToLower(item)
+		if item == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if i, ok := rank[key]; ok {
+			item = s.mistakes[i]
+		}
+		out = append(out, item)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ri, iok := rank[strings.ToLower(out[i])]
+		rj, jok := rank[strings.ToLower(out[j])]
+		if iok != jok {
+			return iok
+		}
+		return iok && ri < rj
+	})
+	return out
+}
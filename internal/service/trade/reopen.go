@@ -0,0 +1,51 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// ErrTradeOpen is returned when an operation requires a closed trade.
+var ErrTradeOpen = errors.New("trade is not closed")
+
+// Reopen clears the exit of a closed trade, keeping the removed exit in a
+// REOPENED audit event. Follow-ups measured against that exit are flagged as
+// orphaned, or dropped when removeFollowUps is set.
+func (s *Service) Reopen(ctx context.Context, id string, removeFollowUps bool) (*domain.Trade, error) {
+	tr, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !tr.HasExited() {
+		return nil, ErrTradeOpen
+	}
+
+	now := s.Now()
+	note := "重新開啟部位，保留既有後續追蹤並標記為失效"
+	if removeFollowUps {
+		note = "重新開啟部位，並刪除既有後續追蹤"
+	}
+	// Summarize before the exit is cleared so the log shows what was reopened.
+	closed := tr.Summary()
+	tr.Events = append(tr.Events, domain.Event{Kind: domain.EventReopened, At: now, Note: note, Exit: tr.Exit})
+	tr.Exit = nil
+	tr.ArchivedAt = nil
+
+	if removeFollowUps {
+		tr.FollowUps = nil
+	} else {
+		for i := range tr.FollowUps {
+			tr.FollowUps[i].Orphaned = true
+		}
+	}
+	tr.UpdatedAt = now
+	normalize(tr)
+	if err := s.repo.Update(ctx, tr); err != nil {
+		return nil, err
+	}
+	log.Printf("reopened trade %s: %s", tr.ID, closed)
+	return tr, nil
+}
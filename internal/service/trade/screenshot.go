@@ -0,0 +1,35 @@
+package trade
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidScreenshotURL is returned when a trade's screenshot link is not an
+// absolute http or https URL.
+var ErrInvalidScreenshotURL = errors.New("invalid screenshot URL")
+
+// ValidateScreenshotURL accepts an empty link or an absolute http(s) URL with
+// a host. Other schemes, javascript: included, are rejected so the link is
+// safe to render.
+func ValidateScreenshotURL(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidScreenshotURL, err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	default:
+		return fmt.Errorf("%w: scheme must be http or https", ErrInvalidScreenshotURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%w: missing host", ErrInvalidScreenshotURL)
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package trade
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"reflect"
 	"sort"
 	"strings"
 	"time"
@@ -12,65 +15,319 @@ import (
 
 // Service coordinates higher-level trade workflows.
 type Service struct {
-	repo storage.TradeRepository
+	repo        storage.TradeRepository
+	fxRates     domain.FXRates
+	knownSetups []string
+	mistakes    []string
+	clock       Clock
+	// exposureLimits caps open gross exposure; see ExposureWarnings.
+	exposureLimits ExposureLimits
+	// instrumentAliases maps upper-cased aliases to canonical symbols.
+	instrumentAliases map[string]string
+	exitReasons       []string
+	importMappings    storage.ImportMappingRepository
+	savedViews        storage.SavedViewRepository
+	// tickSizes maps upper-cased symbols to their tick; see WithTickSizes.
+	tickSizes map[string]float64
+	// defaultTags are added to every new trade; see WithDefaultTags.
+	defaultTags []string
+}
+
+// Option customises a Service.
+type Option func(*Service)
+
+// WithFXRates sets the rate table used to convert fees charged in a currency
+// other than the trade currency.
+func WithFXRates(rates domain.FXRates) Option {
+	return func(s *Service) {
+		s.fxRates = rates
+	}
 }
 
 // NewService creates a trade service with the provided repository.
-func NewService(repo storage.TradeRepository) *Service {
-	return &Service{repo: repo}
+func NewService(repo storage.TradeRepository, opts ...Option) *Service {
+	s := &Service{repo: repo, mistakes: DefaultMistakeChecklist, clock: SystemClock, importMappings: storage.NewInMemoryImportMappingRepository(), savedViews: storage.NewInMemorySavedViewRepository()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Create persists a new trade.
 func (s *Service) Create(ctx context.Context, tr *domain.Trade) error {
-	tr.CreatedAt = time.Now().UTC()
+	return s.createIn(ctx, s.repo, tr)
+}
+
+// createIn checks, prepares and stores a new trade through repo, which is the
+// service's repository or that of the caller's transaction.
+func (s *Service) createIn(ctx context.Context, repo storage.TradeRepository, tr *domain.Trade) error {
+	if err := s.withRepo(repo).checkTrade(ctx, tr); err != nil {
+		return err
+	}
+	s.prepareNew(tr)
+	return repo.Create(ctx, tr)
+}
+
+// checkTrade enforces the rules every created or edited trade must pass.
+func (s *Service) checkTrade(ctx context.Context, tr *domain.Trade) error {
+	if err := s.checkHedge(ctx, tr); err != nil {
+		return err
+	}
+	return ValidateScreenshotURL(tr.ScreenshotURL)
+}
+
+// withRepo returns a copy of the service that reads and writes through repo,
+// so checks run inside a transaction see its writes and do not wait on it.
+func (s *Service) withRepo(repo storage.TradeRepository) *Service {
+	cp := *s
+	cp.repo = repo
+	return &cp
+}
+
+// prepareNew stamps and normalizes a trade entered for the first time,
+// including the configured default tags.
+func (s *Service) prepareNew(tr *domain.Trade) {
+	tr.CreatedAt = s.Now()
 	tr.UpdatedAt = tr.CreatedAt
+	s.applyDefaultTags(tr)
+	s.prepare(tr)
+}
+
+// prepare applies the normalization every saved trade goes through.
+func (s *Service) prepare(tr *domain.Trade) {
 	normalize(tr)
-	return s.repo.Create(ctx, tr)
+	s.applyInstrumentAlias(tr)
+	s.applyTickSize(tr)
+	s.applyFeeRate(tr)
+	tr.Setup, _ = s.canonicalSetup(tr.Setup)
+	s.applyExitReason(tr)
+	tr.Review.Mistakes = s.normalizeMistakes(tr.Review.Mistakes)
+}
+
+// PrepareAll applies the normalization CreateAll would to the trades without
+// storing them, so an import can be previewed as it would be saved.
+func (s *Service) PrepareAll(trades []*domain.Trade) {
+	for _, tr := range trades {
+		s.prepareNew(tr)
+	}
+}
+
+// CreateAll persists several new trades in one transaction, so either all of
+// them are stored or, if any fails Create's checks or its write, none are.
+func (s *Service) CreateAll(ctx context.Context, trades []*domain.Trade) error {
+	return s.repo.Tx(ctx, func(repo storage.TradeRepository) error {
+		for _, tr := range trades {
+			if err := s.createIn(ctx, repo, tr); err != nil {
+				return fmt.Errorf("trade %s: %w", tr.Instrument, err)
+			}
+		}
+		return nil
+	})
 }
 
 // Update modifies an existing trade.
 func (s *Service) Update(ctx context.Context, tr *domain.Trade) error {
-	tr.UpdatedAt = time.Now().UTC()
-	normalize(tr)
+	if err := s.checkTrade(ctx, tr); err != nil {
+		return err
+	}
+	prev, err := s.repo.GetByID(ctx, tr.ID)
+	if err != nil {
+		return err
+	}
+	dropStaleFeeRate(prev, tr)
+	tr.UpdatedAt = s.Now()
+	s.prepare(tr)
 	return s.repo.Update(ctx, tr)
 }
 
-// Delete removes a trade by ID.
+// applyFeeRate records the fee conversion rate from the configured table the
+// first time a trade is saved with a foreign fee currency, so later rate table
+// changes do not rewrite historical results.
+func (s *Service) applyFeeRate(tr *domain.Trade) {
+	if !tr.ChargesFeesInOtherCurrency() {
+		tr.FeeFXRate = nil
+		return
+	}
+	if tr.FeeFXRate != nil {
+		return
+	}
+	if rate, ok := s.fxRates.Rate(tr.FeeCurrency, tr.Currency); ok {
+		tr.FeeFXRate = &rate
+	}
+}
+
+// dropStaleFeeRate clears the fee rate carried over from prev when the fee or
+// trade currency changed but the rate did not, so applyFeeRate looks up the
+// rate for the new pair instead of converting with the old one.
+func dropStaleFeeRate(prev, tr *domain.Trade) {
+	if prev.FeeFXRate == nil || tr.FeeFXRate == nil || *prev.FeeFXRate != *tr.FeeFXRate {
+		return
+	}
+	if domain.NormalizeCurrency(prev.FeeCurrency) != domain.NormalizeCurrency(tr.FeeCurrency) ||
+		domain.NormalizeCurrency(prev.Currency) != domain.NormalizeCurrency(tr.Currency) {
+		tr.FeeFXRate = nil
+	}
+}
+
+// Delete soft-deletes a trade by ID. Deleted trades are hidden from Get and List
+// and removed for good by Prune once their grace period has passed.
 func (s *Service) Delete(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+	tr, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := s.Now()
+	tr.DeletedAt = &now
+	tr.UpdatedAt = now
+	if err := s.repo.Update(ctx, tr); err != nil {
+		return err
+	}
+	log.Printf("deleted trade %s: %s", tr.ID, tr.Summary())
+	return nil
 }
 
-// Get fetches a trade by ID.
+// Prune permanently removes trades deleted more than olderThan ago.
+func (s *Service) Prune(ctx context.Context, olderThan time.Duration) (int, error) {
+	return s.repo.PruneDeleted(ctx, s.Now().Add(-olderThan))
+}
+
+// Get fetches a trade by ID. Deleted trades are reported as not found.
 func (s *Service) Get(ctx context.Context, id string) (*domain.Trade, error) {
-	return s.repo.GetByID(ctx, id)
+	tr, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tr.IsDeleted() {
+		return nil, storage.ErrNotFound
+	}
+	return tr, nil
 }
 
-// List retrieves all trades sorted by creation date desc.
+// GetMany fetches several trades in one repository call. Trades come back in
+// the order of ids with duplicates collapsed; unknown and deleted IDs are
+// returned in missing instead.
+func (s *Service) GetMany(ctx context.Context, ids []string) (trades []*domain.Trade, missing []string, err error) {
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	found, err := s.repo.GetMany(ctx, unique)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[string]*domain.Trade, len(found))
+	for _, tr := range found {
+		if !tr.IsDeleted() {
+			byID[tr.ID] = tr
+		}
+	}
+	trades = make([]*domain.Trade, 0, len(unique))
+	missing = []string{}
+	for _, id := range unique {
+		if tr, ok := byID[id]; ok {
+			trades = append(trades, tr)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return trades, missing, nil
+}
+
+// List retrieves all active (neither deleted nor archived) trades sorted by
+// creation date desc.
 func (s *Service) List(ctx context.Context) ([]*domain.Trade, error) {
-	trades, err := s.repo.List(ctx)
+	return s.list(ctx, false)
+}
+
+// ListWithArchived is List including archived trades.
+func (s *Service) ListWithArchived(ctx context.Context) ([]*domain.Trade, error) {
+	return s.list(ctx, true)
+}
+
+func (s *Service) list(ctx context.Context, withArchived bool) ([]*domain.Trade, error) {
+	all, err := s.repo.List(ctx)
 	if err != nil {
 		return nil, err
 	}
+	trades := all[:0]
+	for _, tr := range all {
+		if !tr.IsDeleted() && (withArchived || !tr.IsArchived()) {
+			trades = append(trades, tr)
+		}
+	}
 	sort.SliceStable(trades, func(i, j int) bool {
 		return trades[i].CreatedAt.After(trades[j].CreatedAt)
 	})
 	return trades, nil
 }
 
-// AddFollowUp records a follow-up observation for the trade.
+// ListUpdatedSince returns the trades changed after since, oldest change
+// first, for clients that sync incrementally. Deleted and archived trades are
+// included so clients can drop or move them; DeletedAt tells deletions apart.
+func (s *Service) ListUpdatedSince(ctx context.Context, since time.Time) ([]*domain.Trade, error) {
+	return s.repo.ListUpdatedSince(ctx, since)
+}
+
+// AddFollowUp records a follow-up observation for the trade. A provided
+// LoggedAt is kept so historical observations can be backfilled; it defaults to now.
 func (s *Service) AddFollowUp(ctx context.Context, tradeID string, followUp domain.FollowUp) error {
-	tr, err := s.repo.GetByID(ctx, tradeID)
+	tr, err := s.Get(ctx, tradeID)
 	if err != nil {
 		return err
 	}
-	followUp.LoggedAt = time.Now().UTC()
+	now := s.Now()
+	if followUp.LoggedAt.IsZero() {
+		followUp.LoggedAt = now
+	}
+	if !followUp.Auto {
+		tr.FollowUps = withoutAutoFollowUp(tr.FollowUps, followUp.DaysAfter)
+	}
 	tr.FollowUps = append(tr.FollowUps, followUp)
-	tr.UpdatedAt = followUp.LoggedAt
+	tr.UpdatedAt = now
 	normalize(tr)
 	return s.repo.Update(ctx, tr)
 }
 
+// NormalizeAll re-applies the normalisation rules to every stored trade and
+// persists the ones that changed. It returns how many trades were updated.
+func (s *Service) NormalizeAll(ctx context.Context) (int, error) {
+	updated := 0
+	err := s.repo.Tx(ctx, func(repo storage.TradeRepository) error {
+		updated = 0
+		trades, err := repo.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, tr := range trades {
+			before := tr.Clone()
+			normalize(tr)
+			if reflect.DeepEqual(before, tr) {
+				continue
+			}
+			tr.UpdatedAt = s.Now()
+			if err := repo.Update(ctx, tr); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
 func normalize(tr *domain.Trade) {
+	tr.Currency = domain.NormalizeCurrency(tr.Currency)
+	tr.FeeCurrency = domain.NormalizeCurrency(tr.FeeCurrency)
+	tr.SyncFills()
+	tr.Session = domain.Session(strings.ToUpper(strings.TrimSpace(string(tr.Session))))
+	tr.ScreenshotURL = strings.TrimSpace(tr.ScreenshotURL)
 	if tr.Review.Tags != nil {
 		cleaned := make([]string, 0, len(tr.Review.Tags))
 		for _, tag := range tr.Review.Tags {
@@ -2,10 +2,15 @@ package trade
 
 import (
 	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	domain "best_trade_logs/internal/domain/trade"
+	"best_trade_logs/internal/quotes"
 	"best_trade_logs/internal/storage"
 )
 
@@ -95,3 +100,1056 @@ func TestUpdateKeepsCreatedAt(t *testing.T) {
 		t.Fatalf("updatedAt should be later than createdAt")
 	}
 }
+
+func TestRunMigrationsIsIdempotent(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := NewService(repo)
+
+	tr := &domain.Trade{
+		Instrument: "NVDA",
+		Entry:      domain.EntryDetail{Price: 400, Quantity: 3},
+		Exit:       &domain.ExitDetail{Price: 420},
+	}
+	if err := svc.Create(context.Background(), tr); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	reports, err := svc.RunMigrations(context.Background(), Migrations)
+	if err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if len(reports) != len(Migrations) || reports[0].Updated != 1 {
+		t.Fatalf("expected first run to update 1 trade, got %+v", reports)
+	}
+	stored, err := svc.Get(context.Background(), tr.ID)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if stored.Exit.Quantity != 3 {
+		t.Fatalf("expected exit quantity to be backfilled, got %v", stored.Exit.Quantity)
+	}
+
+	reports, err = svc.RunMigrations(context.Background(), Migrations)
+	if err != nil {
+		t.Fatalf("second migrate failed: %v", err)
+	}
+	if reports[0].Updated != 0 {
+		t.Fatalf("expected second run to be a no-op, got %+v", reports)
+	}
+}
+
+func TestNormalizeAllUpdatesOnlyChangedTrades(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	clean := &domain.Trade{Instrument: "AAPL", Review: domain.TradeReview{Tags: []string{"breakout"}}}
+	if err := svc.Create(ctx, clean); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	// Stored directly so it bypasses the service normalisation.
+	stale := &domain.Trade{Instrument: "MSFT", Review: domain.TradeReview{Tags: []string{" Momentum "}}}
+	if err := repo.Create(ctx, stale); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	updated, err := svc.NormalizeAll(ctx)
+	if err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+	if updated != 1 {
+		t.Fatalf("expected 1 updated trade, got %d", updated)
+	}
+	stored, err := svc.Get(ctx, stale.ID)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if len(stored.Review.Tags) != 1 || stored.Review.Tags[0] != "momentum" {
+		t.Fatalf("expected stale tags to be normalized, got %#v", stored.Review.Tags)
+	}
+}
+
+type stubQuotes map[string]float64
+
+func (q stubQuotes) CloseOnOrAfter(_ context.Context, instrument string, date time.Time) (float64, time.Time, error) {
+	price, ok := q[instrument]
+	if !ok {
+		return 0, time.Time{}, quotes.ErrNoQuote
+	}
+	return price, date, nil
+}
+
+func TestAutoFollowUpsRespectsManualEntries(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tr := &domain.Trade{
+		Instrument: "AAPL",
+		Entry:      domain.EntryDetail{Price: 100, Quantity: 1},
+		Exit:       &domain.ExitDetail{Date: now.AddDate(0, 0, -10), Price: 110, Quantity: 1},
+		FollowUps:  []domain.FollowUp{{DaysAfter: 7, Price: 115, Notes: "manual"}},
+	}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	added, err := svc.AutoFollowUps(ctx, stubQuotes{"AAPL": 120}, now)
+	if err != nil {
+		t.Fatalf("auto follow-ups failed: %v", err)
+	}
+	// +7 already logged manually and +30 is still in the future.
+	if added != 0 {
+		t.Fatalf("expected no follow-ups to be added, got %d", added)
+	}
+
+	added, err = svc.AutoFollowUps(ctx, stubQuotes{"AAPL": 120}, now.AddDate(0, 0, 30))
+	if err != nil {
+		t.Fatalf("auto follow-ups failed: %v", err)
+	}
+	if added != 1 {
+		t.Fatalf("expected the +30 follow-up to be added, got %d", added)
+	}
+
+	if err := svc.AddFollowUp(ctx, tr.ID, domain.FollowUp{DaysAfter: 30, Price: 125}); err != nil {
+		t.Fatalf("add follow up failed: %v", err)
+	}
+	stored, err := svc.Get(ctx, tr.ID)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if len(stored.FollowUps) != 2 {
+		t.Fatalf("expected manual +30 to replace the automatic one, got %+v", stored.FollowUps)
+	}
+	for _, f := range stored.FollowUps {
+		if f.Auto {
+			t.Fatalf("expected no automatic follow-ups to remain, got %+v", f)
+		}
+	}
+}
+
+func TestFindPossibleDuplicatesMatchesSameFill(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+	day := time.Date(2024, 3, 4, 9, 30, 0, 0, time.UTC)
+
+	existing := &domain.Trade{Instrument: "AAPL", Entry: domain.EntryDetail{Date: day, Price: 100}}
+	other := &domain.Trade{Instrument: "AAPL", Entry: domain.EntryDetail{Date: day.AddDate(0, 0, 1), Price: 100}}
+	for _, tr := range []*domain.Trade{existing, other} {
+		if err := svc.Create(ctx, tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	candidate := &domain.Trade{Instrument: "aapl", Entry: domain.EntryDetail{Date: day.Add(2 * time.Hour), Price: 100.05}}
+	matches, err := svc.FindPossibleDuplicates(ctx, candidate)
+	if err != nil {
+		t.Fatalf("find duplicates: %v", err)
+	}
+	if len(matches) != 1 || matches[0].ID != existing.ID {
+		t.Fatalf("expected only the same-day fill to match, got %+v", matches)
+	}
+
+	candidate.Entry.Price = 101
+	if matches, _ := svc.FindPossibleDuplicates(ctx, candidate); len(matches) != 0 {
+		t.Fatalf("expected price outside tolerance not to match, got %d", len(matches))
+	}
+}
+
+func TestCreateRecordsFeeRateFromTable(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := NewService(repo, WithFXRates(domain.FXRates{"EUR/USD": 1.2}))
+	ctx := context.Background()
+
+	tr := &domain.Trade{Currency: "usd", FeeCurrency: "eur", Entry: domain.EntryDetail{Price: 10, Quantity: 1, Fees: 5}}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	stored, err := svc.Get(ctx, tr.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if stored.FeeFXRate == nil || *stored.FeeFXRate != 1.2 {
+		t.Fatalf("expected fee rate to be recorded, got %v", stored.FeeFXRate)
+	}
+	if stored.NetResult() != -6 {
+		t.Fatalf("expected converted entry fee, got %v", stored.NetResult())
+	}
+
+	plain := &domain.Trade{Currency: "USD", Entry: domain.EntryDetail{Price: 10, Quantity: 1, Fees: 5}}
+	if err := svc.Create(ctx, plain); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if plain.FeeFXRate != nil || plain.NetResult() != -5 {
+		t.Fatalf("expected trades without fee currency to be unaffected")
+	}
+}
+
+func TestUpdateRecomputesFeeRateWhenCurrencyChanges(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := NewService(repo, WithFXRates(domain.FXRates{"EUR/USD": 1.2, "GBP/USD": 1.3}))
+	ctx := context.Background()
+
+	tr := &domain.Trade{Currency: "USD", FeeCurrency: "EUR", Entry: domain.EntryDetail{Price: 10, Quantity: 1, Fees: 5}}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	tr.FeeCurrency = "GBP"
+	if err := svc.Update(ctx, tr); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if tr.FeeFXRate == nil || *tr.FeeFXRate != 1.3 {
+		t.Fatalf("expected the GBP rate after changing the fee currency, got %v", tr.FeeFXRate)
+	}
+
+	tr.FeeCurrency = "JPY"
+	if err := svc.Update(ctx, tr); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if tr.FeeFXRate != nil {
+		t.Fatalf("expected no rate for a fee currency missing from the table, got %v", *tr.FeeFXRate)
+	}
+
+	manual := 150.0
+	tr.FeeCurrency = "EUR"
+	tr.FeeFXRate = &manual
+	if err := svc.Update(ctx, tr); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if tr.FeeFXRate == nil || *tr.FeeFXRate != manual {
+		t.Fatalf("expected a rate entered with the new currency to be kept, got %v", tr.FeeFXRate)
+	}
+
+	tr.FeeCurrency = "USD"
+	if err := svc.Update(ctx, tr); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if tr.FeeFXRate != nil || tr.NetResult() != -5 {
+		t.Fatalf("expected the rate to be cleared once fees are in the trade currency")
+	}
+}
+
+func TestDeleteArchivesTrade(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tr := &domain.Trade{Instrument: "AAPL"}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := svc.Delete(ctx, tr.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := svc.Get(ctx, tr.ID); err != storage.ErrNotFound {
+		t.Fatalf("expected archived trade to be hidden, got %v", err)
+	}
+	if trades, _ := svc.List(ctx); len(trades) != 0 {
+		t.Fatalf("expected archived trade to be excluded from list, got %d", len(trades))
+	}
+	stored, err := repo.GetByID(ctx, tr.ID)
+	if err != nil || stored.DeletedAt == nil {
+		t.Fatalf("expected trade to be kept with DeletedAt, got %v %v", stored, err)
+	}
+
+	if n, _ := svc.Prune(ctx, time.Hour); n != 0 {
+		t.Fatalf("expected grace period to keep the trade, pruned %d", n)
+	}
+	if n, _ := svc.Prune(ctx, 0); n != 1 {
+		t.Fatalf("expected trade to be pruned, pruned %d", n)
+	}
+}
+
+func TestReopenMovesExitToEvent(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tr := &domain.Trade{
+		Instrument: "AAPL",
+		Entry:      domain.EntryDetail{Price: 100, Quantity: 1},
+		Exit:       &domain.ExitDetail{Price: 110, Quantity: 1},
+		FollowUps:  []domain.FollowUp{{DaysAfter: 7, Price: 115}},
+	}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	reopened, err := svc.Reopen(ctx, tr.ID, false)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if reopened.Exit != nil {
+		t.Fatalf("expected exit to be cleared")
+	}
+	if len(reopened.Events) != 1 || reopened.Events[0].Kind != domain.EventReopened || reopened.Events[0].Exit == nil || reopened.Events[0].Exit.Price != 110 {
+		t.Fatalf("expected removed exit in audit event, got %+v", reopened.Events)
+	}
+	if len(reopened.FollowUps) != 1 || !reopened.FollowUps[0].Orphaned {
+		t.Fatalf("expected follow-up to be kept and flagged, got %+v", reopened.FollowUps)
+	}
+
+	if _, err := svc.Reopen(ctx, tr.ID, false); err != ErrTradeOpen {
+		t.Fatalf("expected ErrTradeOpen for open trade, got %v", err)
+	}
+
+	stored, _ := svc.Get(ctx, tr.ID)
+	stored.Exit = &domain.ExitDetail{Price: 105, Quantity: 1}
+	if err := svc.Update(ctx, stored); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	reopened, err = svc.Reopen(ctx, tr.ID, true)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if len(reopened.FollowUps) != 0 || len(reopened.Events) != 2 {
+		t.Fatalf("expected follow-ups removed and a second event, got %d follow-ups, %d events", len(reopened.FollowUps), len(reopened.Events))
+	}
+}
+
+func TestKnownSetupsCanonicaliseAndWarn(t *testing.T) {
+	svc := NewService(storage.NewInMemoryTradeRepository(), WithKnownSetups([]string{" Breakout ", "Pullback", "breakout"}))
+	ctx := context.Background()
+
+	if got := svc.KnownSetups(); len(got) != 2 || got[0] != "Breakout" {
+		t.Fatalf("unexpected known setups: %v", got)
+	}
+
+	known := &domain.Trade{Setup: "breakout"}
+	if err := svc.Create(ctx, known); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if known.Setup != "Breakout" || svc.SetupWarning(known) != "" {
+		t.Fatalf("expected canonical setup without warning, got %q", known.Setup)
+	}
+
+	unknown := &domain.Trade{Setup: "Gap fill"}
+	if err := svc.Create(ctx, unknown); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if unknown.Setup != "Gap fill" || svc.SetupWarning(unknown) == "" {
+		t.Fatalf("expected unknown setup to be kept with a warning")
+	}
+
+	if NewService(storage.NewInMemoryTradeRepository()).SetupWarning(unknown) != "" {
+		t.Fatalf("expected no warning without a configured taxonomy")
+	}
+}
+
+func TestExitReasonsCanonicaliseKnownReasons(t *testing.T) {
+	svc := NewService(storage.NewInMemoryTradeRepository(), WithExitReasons([]string{"達標出場", " Time  stop ", "time stop"}))
+	ctx := context.Background()
+
+	if got := svc.ExitReasons(); len(got) != 2 || got[1] != "Time stop" {
+		t.Fatalf("unexpected exit reasons: %v", got)
+	}
+
+	known := &domain.Trade{Exit: &domain.ExitDetail{Price: 1, Quantity: 1, Reason: "TIME STOP"}}
+	if err := svc.Create(ctx, known); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if known.Exit.Reason != "Time stop" {
+		t.Fatalf("expected canonical exit reason, got %q", known.Exit.Reason)
+	}
+
+	free := &domain.Trade{Exit: &domain.ExitDetail{Price: 1, Quantity: 1, Reason: "量縮\n跌破均線"}}
+	if err := svc.Create(ctx, free); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if free.Exit.Reason != "量縮\n跌破均線" {
+		t.Fatalf("expected free-text reason to be kept, got %q", free.Exit.Reason)
+	}
+	if got := NormalizeExitReason("  量縮\n 跌破均線 "); got != "量縮 跌破均線" {
+		t.Fatalf("unexpected normalized reason %q", got)
+	}
+}
+
+func TestInstrumentAliasesResolveToSymbol(t *testing.T) {
+	aliases, err := ParseInstrumentAliases("台積電=2330, tsm = 2330")
+	if err != nil {
+		t.Fatalf("parse aliases: %v", err)
+	}
+	if _, err := ParseInstrumentAliases("TSM"); err == nil {
+		t.Fatalf("expected an error for an alias without a symbol")
+	}
+	svc := NewService(storage.NewInMemoryTradeRepository(), WithInstrumentAliases(aliases))
+	ctx := context.Background()
+
+	tr := &domain.Trade{Instrument: "TSM"}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if tr.Instrument != "2330" || tr.InstrumentAlias != "TSM" {
+		t.Fatalf("expected TSM stored as 2330, got %q (alias %q)", tr.Instrument, tr.InstrumentAlias)
+	}
+
+	// Editing the trade under its symbol keeps the name it was entered as.
+	if err := svc.Update(ctx, tr); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if tr.Instrument != "2330" || tr.InstrumentAlias != "TSM" {
+		t.Fatalf("expected alias to survive an update, got %q (alias %q)", tr.Instrument, tr.InstrumentAlias)
+	}
+	tr.Instrument = "AAPL"
+	if err := svc.Update(ctx, tr); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if tr.InstrumentAlias != "" {
+		t.Fatalf("expected stale alias to be cleared, got %q", tr.InstrumentAlias)
+	}
+
+	plain := NewService(storage.NewInMemoryTradeRepository())
+	untouched := &domain.Trade{Instrument: "TSM"}
+	if err := plain.Create(ctx, untouched); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if untouched.Instrument != "TSM" || untouched.InstrumentAlias != "" {
+		t.Fatalf("expected instrument untouched without aliases, got %q", untouched.Instrument)
+	}
+}
+
+func TestMistakesNormalisedAgainstChecklist(t *testing.T) {
+	svc := NewService(storage.NewInMemoryTradeRepository(), WithMistakeChecklist([]string{"Chased entry", "Moved stop", " "}))
+	if got := svc.MistakeChecklist(); len(got) != 2 {
+		t.Fatalf("unexpected checklist: %v", got)
+	}
+
+	tr := &domain.Trade{Review: domain.TradeReview{Mistakes: []string{" oversized ", "moved stop", "", "chased entry", "Moved Stop"}}}
+	if err := svc.Create(context.Background(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	want := []string{"Chased entry", "Moved stop", "oversized"}
+	if !reflect.DeepEqual(tr.Review.Mistakes, want) {
+		t.Fatalf("expected %v, got %v", want, tr.Review.Mistakes)
+	}
+
+	if got := NewService(storage.NewInMemoryTradeRepository()).MistakeChecklist(); !reflect.DeepEqual(got, DefaultMistakeChecklist) {
+		t.Fatalf("expected default checklist, got %v", got)
+	}
+}
+
+func TestServiceUsesInjectedClock(t *testing.T) {
+	fixed := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	svc := NewService(storage.NewInMemoryTradeRepository(), WithClock(ClockFunc(func() time.Time { return fixed })))
+	ctx := context.Background()
+
+	tr := &domain.Trade{Instrument: "AAPL", Entry: domain.EntryDetail{Date: fixed.AddDate(0, 0, -3), Price: 100, Quantity: 1}}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !tr.CreatedAt.Equal(fixed) {
+		t.Fatalf("expected created at %v, got %v", fixed, tr.CreatedAt)
+	}
+	closed, err := svc.CloseTrade(ctx, tr.ID, domain.ExitDetail{Price: 110}, false)
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if !closed.Exit.Date.Equal(fixed) {
+		t.Fatalf("expected exit date to default to the clock, got %v", closed.Exit.Date)
+	}
+}
+
+func TestExposureWarningsIgnorePaperTrades(t *testing.T) {
+	svc := NewService(storage.NewInMemoryTradeRepository(),
+		WithExposureLimits(ExposureLimits{Base: "USD", Total: 10000}),
+	)
+	ctx := context.Background()
+	if err := svc.Create(ctx, &domain.Trade{Instrument: "SIM", IsPaper: true, Entry: domain.EntryDetail{Price: 100, Quantity: 90}}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	real := &domain.Trade{Instrument: "AAPL", Entry: domain.EntryDetail{Price: 100, Quantity: 50}}
+	if warnings, err := svc.ExposureWarnings(ctx, real); err != nil || len(warnings) != 0 {
+		t.Fatalf("expected the paper position left out of real exposure, got %v (%v)", warnings, err)
+	}
+	paper := &domain.Trade{Instrument: "SIM", IsPaper: true, Entry: domain.EntryDetail{Price: 100, Quantity: 200}}
+	if warnings, err := svc.ExposureWarnings(ctx, paper); err != nil || len(warnings) != 0 {
+		t.Fatalf("expected no warning for a paper trade, got %v (%v)", warnings, err)
+	}
+}
+
+func TestExposureWarnings(t *testing.T) {
+	rates, _ := domain.ParseFXRates("EUR/USD=1.1")
+	svc := NewService(storage.NewInMemoryTradeRepository(),
+		WithFXRates(rates),
+		WithExposureLimits(ExposureLimits{Base: "usd", Total: 20000, PerCurrency: map[string]float64{"EUR": 10000}}),
+	)
+	ctx := context.Background()
+	if err := svc.Create(ctx, &domain.Trade{Instrument: "SAP", Currency: "EUR", Entry: domain.EntryDetail{Price: 100, Quantity: 80}}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := svc.Create(ctx, &domain.Trade{Instrument: "AAPL", Entry: domain.EntryDetail{Price: 100, Quantity: 50}, Exit: &domain.ExitDetail{Price: 110, Quantity: 50}}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	small := &domain.Trade{Instrument: "MSFT", Entry: domain.EntryDetail{Price: 100, Quantity: 50}}
+	if warnings, err := svc.ExposureWarnings(ctx, small); err != nil || len(warnings) != 0 {
+		t.Fatalf("expected no warnings, got %v (%v)", warnings, err)
+	}
+
+	large := &domain.Trade{Instrument: "SIE", Currency: "EUR", Entry: domain.EntryDetail{Price: 100, Quantity: 50}}
+	warnings, err := svc.ExposureWarnings(ctx, large)
+	if err != nil {
+		t.Fatalf("warnings: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "EUR") {
+		t.Fatalf("expected only the EUR limit warning, got %v", warnings)
+	}
+
+	large.Entry.Quantity = 120
+	if warnings, _ := svc.ExposureWarnings(ctx, large); len(warnings) != 2 {
+		t.Fatalf("expected currency and total warnings, got %v", warnings)
+	}
+
+	if _, err := ParseCurrencyLimits("USD=abc"); err == nil {
+		t.Fatalf("expected invalid limit to be rejected")
+	}
+}
+
+func TestAutoArchiveOldClosedTrades(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	svc := NewService(storage.NewInMemoryTradeRepository(), WithClock(ClockFunc(func() time.Time { return now })))
+	ctx := context.Background()
+	old := &domain.Trade{Instrument: "OLD", Exit: &domain.ExitDetail{Date: now.AddDate(-1, 0, -1), Price: 1}}
+	recent := &domain.Trade{Instrument: "NEW", Exit: &domain.ExitDetail{Date: now.AddDate(0, -1, 0), Price: 1}}
+	open := &domain.Trade{Instrument: "OPEN"}
+	for _, tr := range []*domain.Trade{old, recent, open} {
+		if err := svc.Create(ctx, tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	archived, err := svc.AutoArchive(ctx, 365*24*time.Hour)
+	if err != nil || archived != 1 {
+		t.Fatalf("expected one archived trade, got %d (%v)", archived, err)
+	}
+	if active, _ := svc.List(ctx); len(active) != 2 {
+		t.Fatalf("expected archived trade to leave the active list, got %d", len(active))
+	}
+	if all, _ := svc.ListWithArchived(ctx); len(all) != 3 {
+		t.Fatalf("expected archived trade to stay listable, got %d", len(all))
+	}
+	got, err := svc.Get(ctx, old.ID)
+	if err != nil || !got.IsArchived() || got.Events[len(got.Events)-1].Kind != domain.EventArchived {
+		t.Fatalf("expected archived trade to remain viewable with an event, got %+v (%v)", got, err)
+	}
+	if pruned, _ := svc.Prune(ctx, 0); pruned != 0 {
+		t.Fatalf("expected archived trades never to be pruned, got %d", pruned)
+	}
+}
+
+func TestAutoCloseStaleUsesMarkPrice(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	svc := NewService(storage.NewInMemoryTradeRepository(), WithClock(ClockFunc(func() time.Time { return now })))
+	ctx := context.Background()
+	mark := 42.5
+	marked := &domain.Trade{Instrument: "MARKED", Entry: domain.EntryDetail{Date: now.AddDate(0, -4, 0), Price: 40, Quantity: 10}, MarkPrice: &mark}
+	unmarked := &domain.Trade{Instrument: "UNMARKED", Entry: domain.EntryDetail{Date: now.AddDate(0, -6, 0), Price: 40, Quantity: 10}}
+	fresh := &domain.Trade{Instrument: "FRESH", Entry: domain.EntryDetail{Date: now.AddDate(0, 0, -5), Price: 40, Quantity: 10}, MarkPrice: &mark}
+	for _, tr := range []*domain.Trade{marked, unmarked, fresh} {
+		if err := svc.Create(ctx, tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	olderThan := 90 * 24 * time.Hour
+
+	stale, err := svc.StaleTrades(ctx, olderThan)
+	if err != nil || len(stale) != 2 || stale[0].ID != unmarked.ID || stale[1].ID != marked.ID {
+		t.Fatalf("expected the two old open trades oldest first, got %+v (%v)", stale, err)
+	}
+
+	closed, err := svc.AutoCloseStale(ctx, olderThan)
+	if err != nil || len(closed) != 1 || closed[0].ID != marked.ID {
+		t.Fatalf("expected only the marked stale trade to be closed, got %+v (%v)", closed, err)
+	}
+	got, err := svc.Get(ctx, marked.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Exit == nil || got.Exit.Price != mark || got.Exit.Quantity != 10 || !got.Exit.Date.Equal(now) || got.Exit.Reason != StaleExitReason {
+		t.Fatalf("unexpected exit: %+v", got.Exit)
+	}
+	if len(got.Events) != 1 || got.Events[0].Kind != domain.EventAutoClosed {
+		t.Fatalf("expected an auto-close audit event, got %+v", got.Events)
+	}
+	if still, _ := svc.Get(ctx, unmarked.ID); still.HasExited() {
+		t.Fatalf("expected a trade without a mark price to stay open")
+	}
+}
+
+func TestAddFollowUpKeepsProvidedLoggedAt(t *testing.T) {
+	svc := NewService(storage.NewInMemoryTradeRepository())
+	ctx := context.Background()
+	tr := &domain.Trade{Instrument: "AAPL", Exit: &domain.ExitDetail{Price: 100, Quantity: 1}}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	past := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	if err := svc.AddFollowUp(ctx, tr.ID, domain.FollowUp{DaysAfter: 7, Price: 101, LoggedAt: past}); err != nil {
+		t.Fatalf("add follow-up: %v", err)
+	}
+	if err := svc.AddFollowUp(ctx, tr.ID, domain.FollowUp{DaysAfter: 30, Price: 102}); err != nil {
+		t.Fatalf("add follow-up: %v", err)
+	}
+	stored, _ := svc.Get(ctx, tr.ID)
+	if !stored.FollowUps[0].LoggedAt.Equal(past) {
+		t.Fatalf("expected provided LoggedAt to be kept, got %v", stored.FollowUps[0].LoggedAt)
+	}
+	if time.Since(stored.FollowUps[1].LoggedAt) > time.Minute {
+		t.Fatalf("expected missing LoggedAt to default to now, got %v", stored.FollowUps[1].LoggedAt)
+	}
+}
+
+func TestSortFollowUpsOrdersAndDeduplicates(t *testing.T) {
+	svc := NewService(storage.NewInMemoryTradeRepository())
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	tr := &domain.Trade{
+		Instrument: "AAPL",
+		Exit:       &domain.ExitDetail{Price: 100, Quantity: 1},
+		FollowUps: []domain.FollowUp{
+			{DaysAfter: 30, Price: 110, LoggedAt: day(30)},
+			{DaysAfter: 7, Price: 104, LoggedAt: day(9)},
+			{DaysAfter: 7, Price: 103, LoggedAt: day(8)},
+			{DaysAfter: 7, Price: 90, LoggedAt: day(1), Orphaned: true},
+		},
+	}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	removed, err := svc.SortFollowUps(ctx, tr.ID)
+	if err != nil {
+		t.Fatalf("sort follow-ups: %v", err)
+	}
+	if removed != 1 {
+		t.Fatalf("expected one duplicate removed, got %d", removed)
+	}
+	stored, _ := svc.Get(ctx, tr.ID)
+	var prices []float64
+	for _, f := range stored.FollowUps {
+		prices = append(prices, f.Price)
+	}
+	if len(prices) != 3 || prices[0] != 90 || prices[1] != 104 || prices[2] != 110 {
+		t.Fatalf("expected orphaned, latest +7 and +30 in order, got %v", prices)
+	}
+}
+
+func TestCloseTrade(t *testing.T) {
+	svc := NewService(storage.NewInMemoryTradeRepository())
+	ctx := context.Background()
+	entry := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	tr := &domain.Trade{Instrument: "AAPL", Entry: domain.EntryDetail{Date: entry, Price: 100, Quantity: 5}}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if _, err := svc.CloseTrade(ctx, tr.ID, domain.ExitDetail{Date: entry.AddDate(0, 0, -1), Price: 110}, false); !errors.Is(err, ErrInvalidExit) {
+		t.Fatalf("expected exit before entry to be rejected, got %v", err)
+	}
+	closed, err := svc.CloseTrade(ctx, tr.ID, domain.ExitDetail{Date: entry.AddDate(0, 0, 3), Price: 110}, false)
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if closed.Exit == nil || closed.Exit.Quantity != 5 {
+		t.Fatalf("expected exit with entry quantity, got %+v", closed.Exit)
+	}
+	if _, err := svc.CloseTrade(ctx, tr.ID, domain.ExitDetail{Price: 120}, false); !errors.Is(err, ErrTradeClosed) {
+		t.Fatalf("expected ErrTradeClosed, got %v", err)
+	}
+	replaced, err := svc.CloseTrade(ctx, tr.ID, domain.ExitDetail{Date: entry.AddDate(0, 0, 4), Price: 120}, true)
+	if err != nil || replaced.Exit.Price != 120 {
+		t.Fatalf("expected override to replace exit, got %v %+v", err, replaced)
+	}
+}
+
+func TestCreateAllNormalisesEveryTrade(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := NewService(repo)
+	trades := []*domain.Trade{
+		{Instrument: "AAPL", Currency: "usd", Entry: domain.EntryDetail{Price: 10, Quantity: 1}},
+		{Instrument: "MSFT", Review: domain.TradeReview{Tags: []string{" swing ", ""}}, Entry: domain.EntryDetail{Price: 20, Quantity: 1}},
+	}
+	if err := svc.CreateAll(context.Background(), trades); err != nil {
+		t.Fatalf("create all failed: %v", err)
+	}
+	stored, _ := svc.List(context.Background())
+	if len(stored) != 2 {
+		t.Fatalf("expected 2 trades, got %d", len(stored))
+	}
+	if trades[0].Currency != "USD" || !reflect.DeepEqual(trades[1].Review.Tags, []string{"swing"}) {
+		t.Fatalf("expected normalised trades, got %q and %q", trades[0].Currency, trades[1].Review.Tags)
+	}
+}
+
+func TestShareLinksExpireAndRevoke(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	svc := NewService(storage.NewInMemoryTradeRepository(), WithClock(ClockFunc(func() time.Time { return now })))
+	ctx := context.Background()
+	tr := &domain.Trade{Instrument: "AAPL"}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	short, err := svc.Share(ctx, tr.ID, time.Hour, false)
+	if err != nil {
+		t.Fatalf("share: %v", err)
+	}
+	long, err := svc.Share(ctx, tr.ID, 48*time.Hour, true)
+	if err != nil {
+		t.Fatalf("share: %v", err)
+	}
+	if short.ID == "" || short.ID == long.ID || !short.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected links: %+v %+v", short, long)
+	}
+	if _, link, err := svc.SharedTrade(ctx, tr.ID, short.ID); err != nil || link.ShowAmounts {
+		t.Fatalf("expected a valid link without amounts, got %+v, %v", link, err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, _, err := svc.SharedTrade(ctx, tr.ID, short.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected expired link to be not found, got %v", err)
+	}
+	if _, err := svc.Share(ctx, tr.ID, time.Hour, false); err != nil {
+		t.Fatalf("share: %v", err)
+	}
+	if stored, _ := svc.Get(ctx, tr.ID); len(stored.Shares) != 2 {
+		t.Fatalf("expected the expired link to be dropped, got %d links", len(stored.Shares))
+	}
+
+	if err := svc.RevokeShare(ctx, tr.ID, long.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, _, err := svc.SharedTrade(ctx, tr.ID, long.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected revoked link to be not found, got %v", err)
+	}
+	if err := svc.RevokeShare(ctx, tr.ID, long.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected revoking twice to report not found, got %v", err)
+	}
+}
+
+func TestSplitDividesQuantityAndFees(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	tr := &domain.Trade{
+		Instrument:     "AAPL",
+		Direction:      domain.DirectionLong,
+		Entry:          domain.EntryDetail{Price: 100, Quantity: 3, Fees: 10},
+		RiskManagement: domain.RiskManagement{MaxRiskAmount: 100},
+		Review:         domain.TradeReview{Tags: []string{"core"}},
+	}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	original, split, err := svc.Split(ctx, tr.ID, 1)
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+	if original.Entry.Quantity != 2 || split.Entry.Quantity != 1 {
+		t.Fatalf("unexpected quantities: %v + %v", original.Entry.Quantity, split.Entry.Quantity)
+	}
+	if split.Entry.Fees != 3.3333 || domain.ToMoney(original.Entry.Fees)+domain.ToMoney(split.Entry.Fees) != domain.ToMoney(10) {
+		t.Fatalf("expected fees to add up to 10, got %v + %v", original.Entry.Fees, split.Entry.Fees)
+	}
+	if domain.ToMoney(original.RiskManagement.MaxRiskAmount)+domain.ToMoney(split.RiskManagement.MaxRiskAmount) != domain.ToMoney(100) {
+		t.Fatalf("expected max risk to add up to 100")
+	}
+	if split.SplitFrom != original.ID || split.ID == original.ID || split.Instrument != "AAPL" {
+		t.Fatalf("expected a linked copy, got %+v", split)
+	}
+	split.Review.Tags[0] = "swing"
+	stored, err := svc.Get(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if stored.Entry.Quantity != 2 || stored.Review.Tags[0] != "core" {
+		t.Fatalf("expected the stored original to be reduced and independent, got %+v", stored)
+	}
+	if len(stored.Events) != 1 || stored.Events[0].Kind != domain.EventSplit {
+		t.Fatalf("expected a split event on the original, got %+v", stored.Events)
+	}
+
+	for _, qty := range []float64{0, 2, 5} {
+		if _, _, err := svc.Split(ctx, tr.ID, qty); !errors.Is(err, ErrInvalidSplit) {
+			t.Fatalf("quantity %v: expected ErrInvalidSplit, got %v", qty, err)
+		}
+	}
+	if _, err := svc.CloseTrade(ctx, tr.ID, domain.ExitDetail{Price: 110}, false); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if _, _, err := svc.Split(ctx, tr.ID, 1); !errors.Is(err, ErrTradeClosed) {
+		t.Fatalf("expected ErrTradeClosed, got %v", err)
+	}
+}
+
+func TestHedgeLinksTradesIntoOneGroup(t *testing.T) {
+	svc := NewService(storage.NewInMemoryTradeRepository())
+	ctx := context.Background()
+
+	primary := &domain.Trade{Instrument: "KO", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 60, Quantity: 10}}
+	if err := svc.Create(ctx, primary); err != nil {
+		t.Fatalf("create primary: %v", err)
+	}
+	hedge := &domain.Trade{Instrument: "PEP", Direction: domain.DirectionShort, HedgeOf: primary.ID, Entry: domain.EntryDetail{Price: 170, Quantity: 4}}
+	if err := svc.Create(ctx, hedge); err != nil {
+		t.Fatalf("create hedge: %v", err)
+	}
+
+	for _, tr := range []*domain.Trade{primary, hedge} {
+		group, err := svc.HedgeGroup(ctx, tr)
+		if err != nil {
+			t.Fatalf("hedge group: %v", err)
+		}
+		if len(group) != 2 || group[0].ID != primary.ID || group[1].ID != hedge.ID {
+			t.Fatalf("expected primary then hedge, got %+v", group)
+		}
+	}
+
+	for name, tr := range map[string]*domain.Trade{
+		"missing":  {HedgeOf: "nope"},
+		"chained":  {HedgeOf: hedge.ID},
+		"self":     {ID: primary.ID, HedgeOf: primary.ID},
+		"reversed": {ID: primary.ID, HedgeOf: hedge.ID},
+	} {
+		var err error
+		if tr.ID == "" {
+			err = svc.Create(ctx, tr)
+		} else {
+			err = svc.Update(ctx, tr)
+		}
+		if !errors.Is(err, ErrInvalidHedge) {
+			t.Fatalf("%s: expected ErrInvalidHedge, got %v", name, err)
+		}
+	}
+
+	lone := &domain.Trade{Instrument: "AAPL"}
+	if err := svc.Create(ctx, lone); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if group, err := svc.HedgeGroup(ctx, lone); err != nil || group != nil {
+		t.Fatalf("expected no group for an unlinked trade, got %v, %v", group, err)
+	}
+}
+
+func TestTickSizesSnapPricesOnSave(t *testing.T) {
+	sizes, err := ParseTickSizes("aapl=0.01, ES=0.25")
+	if err != nil {
+		t.Fatalf("parse tick sizes: %v", err)
+	}
+	if _, err := ParseTickSizes("AAPL=0"); err == nil {
+		t.Fatalf("expected an error for a zero tick")
+	}
+	svc := NewService(storage.NewInMemoryTradeRepository(), WithTickSizes(sizes))
+	ctx := context.Background()
+
+	stop := 177.004
+	tr := &domain.Trade{
+		Instrument: "AAPL",
+		Direction:  domain.DirectionLong,
+		Entry:      domain.EntryDetail{Price: 180.503, Quantity: 10, StopLoss: &stop},
+		Exit:       &domain.ExitDetail{Price: 185.5, Quantity: 10},
+	}
+	warnings := svc.TickWarnings(tr)
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "180.503") || !strings.Contains(warnings[0], "180.5") {
+		t.Fatalf("expected warnings for the entry and stop only, got %v", warnings)
+	}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if tr.Entry.Price != 180.5 || *tr.Entry.StopLoss != 177 || tr.Exit.Price != 185.5 {
+		t.Fatalf("expected prices snapped to 0.01, got %v %v %v", tr.Entry.Price, *tr.Entry.StopLoss, tr.Exit.Price)
+	}
+
+	future := &domain.Trade{
+		Instrument: "ES",
+		Direction:  domain.DirectionLong,
+		Entry: domain.EntryDetail{Fills: []domain.Fill{
+			{Price: 5000.1, Quantity: 1},
+			{Price: 5000.4, Quantity: 1},
+		}},
+	}
+	if err := svc.Create(ctx, future); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if future.Entry.Fills[0].Price != 5000 || future.Entry.Fills[1].Price != 5000.5 || future.Entry.Price != 5000.25 {
+		t.Fatalf("expected fills snapped to 0.25 and averaged, got %+v", future.Entry)
+	}
+
+	unknown := &domain.Trade{Instrument: "MSFT", Entry: domain.EntryDetail{Price: 410.123, Quantity: 1}}
+	if warnings := svc.TickWarnings(unknown); warnings != nil {
+		t.Fatalf("expected no warnings for an instrument without a tick size, got %v", warnings)
+	}
+	if err := svc.Create(ctx, unknown); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if unknown.Entry.Price != 410.123 {
+		t.Fatalf("expected an unknown instrument to keep its price, got %v", unknown.Entry.Price)
+	}
+}
+
+func TestScreenshotURLValidatedOnSave(t *testing.T) {
+	for _, raw := range []string{"", "https://www.tradingview.com/x/AbCd1234/", " http://example.com/chart.png "} {
+		if err := ValidateScreenshotURL(raw); err != nil {
+			t.Fatalf("expected %q to be accepted, got %v", raw, err)
+		}
+	}
+	for _, raw := range []string{"javascript:alert(1)", "JavaScript://example.com/%0Aalert(1)", "ftp://example.com/chart.png", "/charts/1.png", "https://"} {
+		if err := ValidateScreenshotURL(raw); !errors.Is(err, ErrInvalidScreenshotURL) {
+			t.Fatalf("expected %q to be rejected, got %v", raw, err)
+		}
+	}
+
+	svc := NewService(storage.NewInMemoryTradeRepository())
+	ctx := context.Background()
+	if err := svc.Create(ctx, &domain.Trade{Instrument: "AAPL", ScreenshotURL: "javascript:alert(1)"}); !errors.Is(err, ErrInvalidScreenshotURL) {
+		t.Fatalf("expected create to reject a javascript: link, got %v", err)
+	}
+	tr := &domain.Trade{Instrument: "AAPL", ScreenshotURL: " https://example.com/chart.png "}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if tr.ScreenshotURL != "https://example.com/chart.png" {
+		t.Fatalf("expected the link to be trimmed, got %q", tr.ScreenshotURL)
+	}
+}
+
+func TestDefaultTagsMergedOnCreate(t *testing.T) {
+	svc := NewService(storage.NewInMemoryTradeRepository(), WithDefaultTags([]string{" 2024 ", "", "Cohort-A"}))
+	ctx := context.Background()
+
+	tr := &domain.Trade{Instrument: "AAPL", Review: domain.TradeReview{Tags: []string{"Breakout", "cohort-a"}}}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if got := strings.Join(tr.Review.Tags, ","); got != "breakout,cohort-a,2024" {
+		t.Fatalf("expected merged, de-duplicated tags, got %q", got)
+	}
+
+	imported := []*domain.Trade{{Instrument: "MSFT"}}
+	if err := svc.CreateAll(ctx, imported); err != nil {
+		t.Fatalf("create all: %v", err)
+	}
+	if got := strings.Join(imported[0].Review.Tags, ","); got != "2024,cohort-a" {
+		t.Fatalf("expected default tags on imported trades, got %q", got)
+	}
+
+	tr.Review.Tags = []string{"reviewed"}
+	if err := svc.Update(ctx, tr); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got := strings.Join(tr.Review.Tags, ","); got != "reviewed" {
+		t.Fatalf("expected edits to leave tags alone, got %q", got)
+	}
+
+	plain := &domain.Trade{Instrument: "TSLA"}
+	if err := NewService(storage.NewInMemoryTradeRepository()).Create(ctx, plain); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if len(plain.Review.Tags) != 0 {
+		t.Fatalf("expected no tags without configuration, got %v", plain.Review.Tags)
+	}
+}
+
+func TestSplitDividesEntryFills(t *testing.T) {
+	svc := NewService(storage.NewInMemoryTradeRepository())
+	ctx := context.Background()
+	tr := &domain.Trade{
+		Instrument: "AAPL",
+		Direction:  domain.DirectionLong,
+		Entry: domain.EntryDetail{Fills: []domain.Fill{
+			{Price: 10, Quantity: 60},
+			{Price: 11, Quantity: 40},
+		}},
+	}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	original, split, err := svc.Split(ctx, tr.ID, 30)
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+	if split.Entry.Quantity != 30 || original.Entry.Quantity != 70 {
+		t.Fatalf("expected 30 + 70 after the split, got %v + %v", split.Entry.Quantity, original.Entry.Quantity)
+	}
+	if math.Abs(split.AvgEntryPrice()-10.4) > 1e-9 || math.Abs(original.AvgEntryPrice()-10.4) > 1e-9 {
+		t.Fatalf("expected both portions to keep the 10.4 average, got %v and %v", split.AvgEntryPrice(), original.AvgEntryPrice())
+	}
+
+	// Saving the original again re-syncs its quantity from its own fills.
+	if err := svc.Update(ctx, original); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	stored, err := svc.Get(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if stored.Entry.Quantity != 70 || len(stored.Entry.Fills) != 2 {
+		t.Fatalf("expected the original to stay at 70 after an update, got %v", stored.Entry.Quantity)
+	}
+}
+
+func TestSplitSkipsDefaultTagsAndKeepsCreatedAt(t *testing.T) {
+	created := time.Date(2023, 12, 30, 10, 0, 0, 0, time.UTC)
+	now := created
+	clock := ClockFunc(func() time.Time { return now })
+	svc := NewService(storage.NewInMemoryTradeRepository(), WithClock(clock))
+	ctx := context.Background()
+	tr := &domain.Trade{Instrument: "AAPL", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 10}, Review: domain.TradeReview{Tags: []string{"2023"}}}
+	if err := svc.Create(ctx, tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// A new year's default tag must not reach the split-off half of the position.
+	WithDefaultTags([]string{"2024"})(svc)
+	now = time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	_, split, err := svc.Split(ctx, tr.ID, 4)
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+	if got := strings.Join(split.Review.Tags, ","); got != "2023" {
+		t.Fatalf("expected the split to keep only the original tags, got %q", got)
+	}
+	if !split.CreatedAt.Equal(created) || !split.UpdatedAt.Equal(now) {
+		t.Fatalf("expected the split to keep the original creation time, got %v / %v", split.CreatedAt, split.UpdatedAt)
+	}
+}
+
+func TestCreateAllEnforcesCreateChecks(t *testing.T) {
+	svc := NewService(storage.NewInMemoryTradeRepository())
+	ctx := context.Background()
+	valid := func() *domain.Trade {
+		return &domain.Trade{Instrument: "AAPL", Entry: domain.EntryDetail{Price: 100, Quantity: 1}}
+	}
+
+	orphanHedge := valid()
+	orphanHedge.HedgeOf = "missing"
+	if err := svc.CreateAll(ctx, []*domain.Trade{valid(), orphanHedge}); !errors.Is(err, ErrInvalidHedge) {
+		t.Fatalf("expected an invalid hedge to fail the import, got %v", err)
+	}
+	badLink := valid()
+	badLink.ScreenshotURL = "javascript:alert(1)"
+	if err := svc.CreateAll(ctx, []*domain.Trade{valid(), badLink}); !errors.Is(err, ErrInvalidScreenshotURL) {
+		t.Fatalf("expected an invalid screenshot URL to fail the import, got %v", err)
+	}
+	if trades, _ := svc.List(ctx); len(trades) != 0 {
+		t.Fatalf("expected a rejected import to store nothing, got %d trades", len(trades))
+	}
+
+	primary := valid()
+	if err := svc.Create(ctx, primary); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	hedge := valid()
+	hedge.HedgeOf = primary.ID
+	if err := svc.CreateAll(ctx, []*domain.Trade{hedge}); err != nil {
+		t.Fatalf("expected a hedge of an existing trade to import, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package trade
+
+import (
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// WithKnownSetups configures the setup taxonomy. Setups matching an entry
+// case-insensitively are stored with its canonical spelling; others are kept
+// but reported by SetupWarning. An empty list leaves setups free-text.
+func WithKnownSetups(setups []string) Option {
+	return func(s *Service) {
+		s.knownSetups = nil
+		seen := make(map[string]struct{})
+		for _, setup := range setups {
+			setup = strings.TrimSpace(setup)
+			key := strings.ToLower(setup)
+			if setup == "" {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			s.knownSetups = append(s.knownSetups, setup)
+		}
+	}
+}
+
+// KnownSetups returns the configured setup taxonomy, or nil when unconfigured.
+func (s *Service) KnownSetups() []string {
+	return s.knownSetups
+}
+
+// canonicalSetup returns the configured spelling of setup, if it is known.
+func (s *Service) canonicalSetup(setup string) (string, bool) {
+	for _, known := range s.knownSetups {
+		if strings.EqualFold(known, strings.TrimSpace(setup)) {
+			return known, true
+		}
+	}
+	return setup, false
+}
+
+// SetupWarning returns a non-blocking warning when a taxonomy is configured and
+// the trade's setup is not part of it. It returns "" otherwise.
+func (s *Service) SetupWarning(tr *domain.Trade) string {
+	if len(s.knownSetups) == 0 || strings.TrimSpace(tr.Setup) == "" {
+		return ""
+	}
+	if _, ok := s.canonicalSetup(tr.Setup); ok {
+		return ""
+	}
+	return "策略「" + tr.Setup + "」不在已知策略清單中"
+}
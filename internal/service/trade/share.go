@@ -0,0 +1,87 @@
+package trade
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+	"best_trade_logs/internal/storage"
+)
+
+// DefaultShareTTL is how long a share link stays valid unless configured.
+const DefaultShareTTL = 7 * 24 * time.Hour
+
+// Share creates a read-only share link for a trade that expires after ttl.
+// Expired links on the trade are dropped at the same time.
+func (s *Service) Share(ctx context.Context, id string, ttl time.Duration, showAmounts bool) (domain.ShareLink, error) {
+	tr, err := s.Get(ctx, id)
+	if err != nil {
+		return domain.ShareLink{}, err
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return domain.ShareLink{}, err
+	}
+	now := s.Now()
+	link := domain.ShareLink{
+		ID:          hex.EncodeToString(token),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+		ShowAmounts: showAmounts,
+	}
+	tr.Shares = append(activeShares(tr.Shares, now), link)
+	tr.UpdatedAt = now
+	if err := s.repo.Update(ctx, tr); err != nil {
+		return domain.ShareLink{}, err
+	}
+	return link, nil
+}
+
+// RevokeShare removes a share link so it stops working immediately. Unknown
+// links are reported as storage.ErrNotFound.
+func (s *Service) RevokeShare(ctx context.Context, id, shareID string) error {
+	tr, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	kept := make([]domain.ShareLink, 0, len(tr.Shares))
+	for _, link := range tr.Shares {
+		if link.ID != shareID {
+			kept = append(kept, link)
+		}
+	}
+	if len(kept) == len(tr.Shares) {
+		return storage.ErrNotFound
+	}
+	tr.Shares = kept
+	tr.UpdatedAt = s.Now()
+	return s.repo.Update(ctx, tr)
+}
+
+// SharedTrade returns the trade and link for a share that is still valid.
+// Revoked and expired links, and links to deleted trades, are reported as
+// storage.ErrNotFound.
+func (s *Service) SharedTrade(ctx context.Context, id, shareID string) (*domain.Trade, domain.ShareLink, error) {
+	tr, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, domain.ShareLink{}, err
+	}
+	for _, link := range tr.Shares {
+		if link.ID == shareID && !link.Expired(s.Now()) {
+			return tr, link, nil
+		}
+	}
+	return nil, domain.ShareLink{}, storage.ErrNotFound
+}
+
+func activeShares(links []domain.ShareLink, now time.Time) []domain.ShareLink {
+	active := make([]domain.ShareLink, 0, len(links))
+	for _, link := range links {
+		if !link.Expired(now) {
+			active = append(active, link)
+		}
+	}
+	return active
+}
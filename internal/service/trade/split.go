@@ -0,0 +1,102 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	domain "best_trade_logs/internal/domain/trade"
+	"best_trade_logs/internal/storage"
+)
+
+// ErrInvalidSplit is returned when the split quantity does not leave both
+// portions with a position.
+var ErrInvalidSplit = errors.New("invalid split")
+
+// Split moves quantity of an open trade's entry into a new trade linked back
+// through SplitFrom, so the two portions can be managed with separate plans.
+// Entry fees and the planned maximum risk are divided in proportion to the
+// quantity, rounded to domain.Money so the portions add up to the original
+// exactly. A scaled entry has each of its fills divided the same way, so both
+// portions keep the average price. Both trades get a SPLIT event. Closed
+// trades cannot be split.
+func (s *Service) Split(ctx context.Context, id string, quantity float64) (original, split *domain.Trade, err error) {
+	original, err = s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if original.HasExited() {
+		return nil, nil, ErrTradeClosed
+	}
+	total := original.Entry.Quantity
+	if quantity <= 0 || quantity >= total {
+		return nil, nil, errors.Join(ErrInvalidSplit, fmt.Errorf("quantity must be between 0 and %g", total))
+	}
+
+	split = original.Clone()
+	split.ID = ""
+	split.SplitFrom = original.ID
+	split.Exit = nil
+	split.FollowUps = nil
+	split.Events = nil
+	split.Shares = nil
+
+	share := quantity / total
+	split.Entry.Quantity = quantity
+	original.Entry.Quantity = total - quantity
+	split.Entry.Fills, original.Entry.Fills = divideFills(original.Entry.Fills, quantity, total)
+	split.Entry.Fees, original.Entry.Fees = divideMoney(original.Entry.Fees, share)
+	split.RiskManagement.MaxRiskAmount, original.RiskManagement.MaxRiskAmount = divideMoney(original.RiskManagement.MaxRiskAmount, share)
+
+	now := s.Now()
+	split.Events = []domain.Event{{Kind: domain.EventSplit, At: now, Note: fmt.Sprintf("自交易 %s 拆分數量 %g", original.ID, quantity)}}
+	// The split keeps the original's creation time and tags: it is part of an
+	// existing position, not a new trade.
+	split.CreatedAt = original.CreatedAt
+	split.UpdatedAt = now
+	err = s.repo.Tx(ctx, func(repo storage.TradeRepository) error {
+		s.prepare(split)
+		if err := repo.Create(ctx, split); err != nil {
+			return err
+		}
+		original.Events = append(original.Events, domain.Event{Kind: domain.EventSplit, At: now, Note: fmt.Sprintf("拆分數量 %g 至交易 %s", quantity, split.ID)})
+		original.UpdatedAt = now
+		return repo.Update(ctx, original)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Printf("split trade %s into %s: %s", original.ID, split.ID, split.Summary())
+	return original, split, nil
+}
+
+// divideFills splits each fill's quantity in the proportion quantity/total.
+// The last fill of the split portion takes the remainder, so its fills add up
+// to quantity and the rest add up to what the original keeps.
+func divideFills(fills []domain.Fill, quantity, total float64) (part, rest []domain.Fill) {
+	if len(fills) == 0 {
+		return nil, nil
+	}
+	part = make([]domain.Fill, len(fills))
+	rest = make([]domain.Fill, len(fills))
+	var assigned float64
+	for i, f := range fills {
+		part[i], rest[i] = f, f
+		part[i].Quantity = f.Quantity * quantity / total
+		if i == len(fills)-1 {
+			part[i].Quantity = quantity - assigned
+		}
+		assigned += part[i].Quantity
+		rest[i].Quantity = f.Quantity - part[i].Quantity
+	}
+	return part, rest
+}
+
+// divideMoney splits amount into the given share and the remainder, both
+// rounded to whole domain.Money units so they sum back to the rounded amount.
+func divideMoney(amount, share float64) (part, rest float64) {
+	whole := domain.ToMoney(amount)
+	portion := domain.ToMoney(amount * share)
+	return portion.Float64(), (whole - portion).Float64()
+}
@@ -0,0 +1,103 @@
+package trade
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+	"best_trade_logs/internal/storage"
+)
+
+// StaleExitReason is the exit reason recorded on trades closed by AutoCloseStale.
+const StaleExitReason = "auto-closed (stale)"
+
+// isStale reports whether tr is an active open trade entered before cutoff.
+func isStale(tr *domain.Trade, cutoff time.Time) bool {
+	if tr.IsDeleted() || tr.IsArchived() || tr.HasExited() {
+		return false
+	}
+	return !tr.Entry.Date.IsZero() && tr.Entry.Date.Before(cutoff)
+}
+
+// StaleTrades lists the open trades entered more than olderThan ago, oldest
+// first, so forgotten positions can be reviewed before they are closed.
+func (s *Service) StaleTrades(ctx context.Context, olderThan time.Duration) ([]*domain.Trade, error) {
+	trades, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := s.Now().Add(-olderThan)
+	var stale []*domain.Trade
+	for _, tr := range trades {
+		if isStale(tr, cutoff) {
+			stale = append(stale, tr)
+		}
+	}
+	sort.SliceStable(stale, func(i, j int) bool {
+		return stale[i].Entry.Date.Before(stale[j].Entry.Date)
+	})
+	return stale, nil
+}
+
+// AutoCloseStale closes the open trades entered more than olderThan ago at
+// their MarkPrice, with StaleExitReason as the exit reason. Trades without a
+// mark price are left open. Every closed trade gets an EventAutoClosed entry
+// and a log line, and the closed trades are returned.
+func (s *Service) AutoCloseStale(ctx context.Context, olderThan time.Duration) ([]*domain.Trade, error) {
+	now := s.Now()
+	cutoff := now.Add(-olderThan)
+	var closed []*domain.Trade
+	err := s.repo.Tx(ctx, func(repo storage.TradeRepository) error {
+		closed = nil
+		trades, err := repo.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, tr := range trades {
+			if !isStale(tr, cutoff) || tr.MarkPrice == nil || *tr.MarkPrice <= 0 {
+				continue
+			}
+			tr.Exit = &domain.ExitDetail{
+				Date:     now,
+				Price:    *tr.MarkPrice,
+				Quantity: tr.Entry.Quantity,
+				Reason:   StaleExitReason,
+			}
+			note := fmt.Sprintf("未平倉超過 %d 天，以最新參考價 %s 自動平倉", int(olderThan.Hours()/24), strconv.FormatFloat(*tr.MarkPrice, 'f', -1, 64))
+			tr.Events = append(tr.Events, domain.Event{Kind: domain.EventAutoClosed, At: now, Note: note})
+			tr.UpdatedAt = now
+			if err := repo.Update(ctx, tr); err != nil {
+				return err
+			}
+			closed = append(closed, tr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, tr := range closed {
+		log.Printf("auto close: closed stale trade %s (%s) at mark price %v", tr.ID, tr.Instrument, tr.Exit.Price)
+	}
+	return closed, nil
+}
+
+// RunAutoCloseStale calls AutoCloseStale immediately and then on every tick until ctx is cancelled.
+func (s *Service) RunAutoCloseStale(ctx context.Context, olderThan, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.AutoCloseStale(ctx, olderThan); err != nil {
+			log.Printf("auto close failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package trade
+
+import domain "best_trade_logs/internal/domain/trade"
+
+// WithDefaultTags adds tags, for example a cohort tag such as "2024", to every
+// new trade. They are merged with the tags the trade already has. An empty list
+// adds nothing.
+func WithDefaultTags(tags []string) Option {
+	return func(s *Service) {
+		s.defaultTags = nil
+		for _, tag := range tags {
+			if tag = domain.NormalizeTag(tag); tag != "" {
+				s.defaultTags = append(s.defaultTags, tag)
+			}
+		}
+	}
+}
+
+// applyDefaultTags appends the configured default tags to a new trade's tags
+// and drops duplicates, keeping the first occurrence of each tag.
+func (s *Service) applyDefaultTags(tr *domain.Trade) {
+	if len(s.defaultTags) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(tr.Review.Tags)+len(s.defaultTags))
+	merged := make([]string, 0, len(tr.Review.Tags)+len(s.defaultTags))
+	for _, tag := range append(append([]string{}, tr.Review.Tags...), s.defaultTags...) {
+		tag = domain.NormalizeTag(tag)
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		merged = append(merged, tag)
+	}
+	tr.Review.Tags = merged
+}
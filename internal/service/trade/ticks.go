@@ -0,0 +1,136 @@
+package trade
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// ParseTickSizes reads a comma separated list of instrument=tick pairs, such as
+// "AAPL=0.01,ES=0.25".
+func ParseTickSizes(raw string) (map[string]float64, error) {
+	sizes := map[string]float64{}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		instrument, value, ok := strings.Cut(item, "=")
+		instrument = strings.TrimSpace(instrument)
+		tick, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || instrument == "" || err != nil || tick <= 0 {
+			return nil, fmt.Errorf("invalid tick size %q", item)
+		}
+		sizes[instrument] = tick
+	}
+	return sizes, nil
+}
+
+// WithTickSizes snaps the prices of the listed instruments to their tick size
+// when a trade is saved; see TickWarnings. Instruments match
+// case-insensitively against the canonical symbol, and instruments without a
+// tick size are saved as entered. An empty map disables snapping.
+func WithTickSizes(sizes map[string]float64) Option {
+	return func(s *Service) {
+		s.tickSizes = make(map[string]float64, len(sizes))
+		for instrument, tick := range sizes {
+			s.tickSizes[strings.ToUpper(strings.TrimSpace(instrument))] = tick
+		}
+	}
+}
+
+// tickPrice is a price field subject to tick rounding.
+type tickPrice struct {
+	label string
+	price *float64
+}
+
+// tickPrices lists the trade's prices that must sit on a tick. Averages of
+// scaled entries and exits are left alone, their fills are snapped instead.
+func tickPrices(tr *domain.Trade) []tickPrice {
+	var prices []tickPrice
+	if len(tr.Entry.Fills) == 0 {
+		prices = append(prices, tickPrice{"進場價", &tr.Entry.Price})
+	}
+	for i := range tr.Entry.Fills {
+		prices = append(prices, tickPrice{fmt.Sprintf("第 %d 筆進場成交價", i+1), &tr.Entry.Fills[i].Price})
+	}
+	prices = append(prices,
+		tickPrice{"停損價", tr.Entry.StopLoss},
+		tickPrice{"目標價", tr.Entry.Target},
+		tickPrice{"計畫進場價", tr.Entry.PlannedEntryPrice},
+	)
+	if tr.Exit != nil {
+		if len(tr.Exit.Fills) == 0 {
+			prices = append(prices, tickPrice{"出場價", &tr.Exit.Price})
+		}
+		for i := range tr.Exit.Fills {
+			prices = append(prices, tickPrice{fmt.Sprintf("第 %d 筆出場成交價", i+1), &tr.Exit.Fills[i].Price})
+		}
+	}
+	return prices
+}
+
+// TickSize returns the tick configured for the trade's instrument, resolving
+// aliases first.
+func (s *Service) TickSize(tr *domain.Trade) (float64, bool) {
+	if len(s.tickSizes) == 0 {
+		return 0, false
+	}
+	tick, ok := s.tickSizes[strings.ToUpper(strings.TrimSpace(s.CanonicalInstrument(tr.Instrument)))]
+	return tick, ok
+}
+
+// SnapToTick rounds price to the nearest multiple of tick, trimmed to the
+// tick's decimals so the result does not carry floating-point noise.
+func SnapToTick(price, tick float64) float64 {
+	snapped := math.Round(price/tick) * tick
+	decimals := 0
+	if _, frac, ok := strings.Cut(strconv.FormatFloat(tick, 'f', -1, 64), "."); ok {
+		decimals = len(frac)
+	}
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(snapped, 'f', decimals, 64), 64)
+	if err != nil {
+		return snapped
+	}
+	return rounded
+}
+
+// TickWarnings describes the prices saving tr would move onto its
+// instrument's tick. Call it before the trade is saved; afterwards the prices
+// are already snapped.
+func (s *Service) TickWarnings(tr *domain.Trade) []string {
+	tick, ok := s.TickSize(tr)
+	if !ok {
+		return nil
+	}
+	var warnings []string
+	for _, p := range tickPrices(tr) {
+		if p.price == nil {
+			continue
+		}
+		if snapped := SnapToTick(*p.price, tick); snapped != *p.price {
+			warnings = append(warnings, fmt.Sprintf("%s %s 不符合最小跳動單位 %s，已調整為 %s", p.label,
+				strconv.FormatFloat(*p.price, 'f', -1, 64), strconv.FormatFloat(tick, 'f', -1, 64), strconv.FormatFloat(snapped, 'f', -1, 64)))
+		}
+	}
+	return warnings
+}
+
+// applyTickSize snaps the trade's prices to its instrument's tick and
+// recomputes fill averages from the snapped fills.
+func (s *Service) applyTickSize(tr *domain.Trade) {
+	tick, ok := s.TickSize(tr)
+	if !ok {
+		return
+	}
+	for _, p := range tickPrices(tr) {
+		if p.price != nil {
+			*p.price = SnapToTick(*p.price, tick)
+		}
+	}
+	tr.SyncFills()
+}
@@ -0,0 +1,43 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"best_trade_logs/internal/storage"
+)
+
+// ErrInvalidView is returned when a saved view cannot be saved.
+var ErrInvalidView = errors.New("invalid saved view")
+
+// WithSavedViews sets where saved trade list views are kept. Without it they
+// are kept in memory and lost when the process exits.
+func WithSavedViews(repo storage.SavedViewRepository) Option {
+	return func(s *Service) {
+		if repo != nil {
+			s.savedViews = repo
+		}
+	}
+}
+
+// SaveView stores a named set of trade list filters, replacing any view with
+// the same name.
+func (s *Service) SaveView(ctx context.Context, v storage.SavedView) error {
+	v.Name = strings.TrimSpace(v.Name)
+	if v.Name == "" {
+		return errors.Join(ErrInvalidView, errors.New("name is required"))
+	}
+	v.UpdatedAt = s.Now()
+	return s.savedViews.SaveView(ctx, v)
+}
+
+// DeleteView removes the view saved under name.
+func (s *Service) DeleteView(ctx context.Context, name string) error {
+	return s.savedViews.DeleteView(ctx, strings.TrimSpace(name))
+}
+
+// SavedViews lists the saved views ordered by name.
+func (s *Service) SavedViews(ctx context.Context) ([]storage.SavedView, error) {
+	return s.savedViews.ListViews(ctx)
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrMappingNotFound is returned when no import mapping has the requested name.
+var ErrMappingNotFound = errors.New("import mapping not found")
+
+// ImportMapping is a saved column mapping for the generic CSV import. Columns
+// maps a CSV header onto the import field it fills; Headers is the header row
+// the mapping was saved for, so it can be offered again for files with the
+// same layout.
+type ImportMapping struct {
+	Name      string            `bson:"_id" json:"name"`
+	Headers   []string          `bson:"headers" json:"headers"`
+	Columns   map[string]string `bson:"columns" json:"columns"`
+	UpdatedAt time.Time         `bson:"updated_at" json:"updated_at"`
+}
+
+// ImportMappingRepository stores CSV import mappings by name.
+type ImportMappingRepository interface {
+	// SaveMapping stores m, replacing any mapping with the same name.
+	SaveMapping(ctx context.Context, m ImportMapping) error
+	GetMapping(ctx context.Context, name string) (ImportMapping, error)
+	// ListMappings returns every mapping ordered by name.
+	ListMappings(ctx context.Context) ([]ImportMapping, error)
+}
+
+// InMemoryImportMappingRepository keeps import mappings for the lifetime of the
+// process.
+type InMemoryImportMappingRepository struct {
+	mu       sync.RWMutex
+	mappings map[string]ImportMapping
+}
+
+// NewInMemoryImportMappingRepository constructs an empty mapping repository.
+func NewInMemoryImportMappingRepository() *InMemoryImportMappingRepository {
+	return &InMemoryImportMappingRepository{mappings: make(map[string]ImportMapping)}
+}
+
+// SaveMapping stores a copy of m under its name.
+func (r *InMemoryImportMappingRepository) SaveMapping(_ context.Context, m ImportMapping) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mappings[m.Name] = cloneMapping(m)
+	return nil
+}
+
+// GetMapping returns the mapping saved under name.
+func (r *InMemoryImportMappingRepository) GetMapping(_ context.Context, name string) (ImportMapping, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.mappings[name]
+	if !ok {
+		return ImportMapping{}, ErrMappingNotFound
+	}
+	return cloneMapping(m), nil
+}
+
+// ListMappings returns every saved mapping ordered by name.
+func (r *InMemoryImportMappingRepository) ListMappings(context.Context) ([]ImportMapping, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	mappings := make([]ImportMapping, 0, len(r.mappings))
+	for _, m := range r.mappings {
+		mappings = append(mappings, cloneMapping(m))
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].Name < mappings[j].Name })
+	return mappings, nil
+}
+
+func cloneMapping(m ImportMapping) ImportMapping {
+	m.Headers = append([]string(nil), m.Headers...)
+	columns := make(map[string]string, len(m.Columns))
+	for header, field := range m.Columns {
+		columns[header] = field
+	}
+	m.Columns = columns
+	return m
+}
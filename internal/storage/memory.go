@@ -28,7 +28,10 @@ func NewInMemoryTradeRepository() *InMemoryTradeRepository {
 func (r *InMemoryTradeRepository) Create(_ context.Context, tr *trade.Trade) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	return r.create(tr)
+}
 
+func (r *InMemoryTradeRepository) create(tr *trade.Trade) error {
 	if tr.ID == "" {
 		tr.ID = generateID()
 	}
@@ -36,10 +39,11 @@ func (r *InMemoryTradeRepository) Create(_ context.Context, tr *trade.Trade) err
 	if tr.CreatedAt.IsZero() {
 		tr.CreatedAt = now
 	}
-	tr.UpdatedAt = now
+	if tr.UpdatedAt.IsZero() {
+		tr.UpdatedAt = tr.CreatedAt
+	}
 
-	cp := *tr
-	r.trades[tr.ID] = &cp
+	r.trades[tr.ID] = tr.Clone()
 	return nil
 }
 
@@ -47,16 +51,21 @@ func (r *InMemoryTradeRepository) Create(_ context.Context, tr *trade.Trade) err
 func (r *InMemoryTradeRepository) Update(_ context.Context, tr *trade.Trade) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	return r.update(tr)
+}
 
+func (r *InMemoryTradeRepository) update(tr *trade.Trade) error {
 	if tr.ID == "" {
 		return ErrNotFound
 	}
 	if _, ok := r.trades[tr.ID]; !ok {
 		return ErrNotFound
 	}
-	cp := *tr
-	cp.UpdatedAt = time.Now().UTC()
-	r.trades[tr.ID] = &cp
+	cp := tr.Clone()
+	if cp.UpdatedAt.IsZero() {
+		cp.UpdatedAt = time.Now().UTC()
+	}
+	r.trades[tr.ID] = cp
 	return nil
 }
 
@@ -64,7 +73,10 @@ func (r *InMemoryTradeRepository) Update(_ context.Context, tr *trade.Trade) err
 func (r *InMemoryTradeRepository) Delete(_ context.Context, id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	return r.delete(id)
+}
 
+func (r *InMemoryTradeRepository) delete(id string) error {
 	if _, ok := r.trades[id]; !ok {
 		return ErrNotFound
 	}
@@ -72,35 +84,145 @@ func (r *InMemoryTradeRepository) Delete(_ context.Context, id string) error {
 	return nil
 }
 
+// PruneDeleted removes soft-deleted trades whose DeletedAt is before the cutoff.
+func (r *InMemoryTradeRepository) PruneDeleted(_ context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pruneDeleted(before), nil
+}
+
+func (r *InMemoryTradeRepository) pruneDeleted(before time.Time) int {
+	pruned := 0
+	for id, tr := range r.trades {
+		if tr.DeletedAt != nil && tr.DeletedAt.Before(before) {
+			delete(r.trades, id)
+			pruned++
+		}
+	}
+	return pruned
+}
+
 // GetByID retrieves a trade by its identifier.
 func (r *InMemoryTradeRepository) GetByID(_ context.Context, id string) (*trade.Trade, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	return r.getByID(id)
+}
 
+func (r *InMemoryTradeRepository) getByID(id string) (*trade.Trade, error) {
 	tr, ok := r.trades[id]
 	if !ok {
 		return nil, ErrNotFound
 	}
-	cp := *tr
-	return &cp, nil
+	return tr.Clone(), nil
+}
+
+// GetMany retrieves the trades with the given identifiers, skipping unknown ones.
+func (r *InMemoryTradeRepository) GetMany(_ context.Context, ids []string) ([]*trade.Trade, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.getMany(ids), nil
+}
+
+func (r *InMemoryTradeRepository) getMany(ids []string) []*trade.Trade {
+	results := make([]*trade.Trade, 0, len(ids))
+	for _, id := range ids {
+		if tr, err := r.getByID(id); err == nil {
+			results = append(results, tr)
+		}
+	}
+	return results
 }
 
 // List returns the trades sorted by creation date descending.
 func (r *InMemoryTradeRepository) List(_ context.Context) ([]*trade.Trade, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	return r.list(), nil
+}
 
+func (r *InMemoryTradeRepository) list() []*trade.Trade {
 	results := make([]*trade.Trade, 0, len(r.trades))
 	for _, tr := range r.trades {
-		cp := *tr
-		results = append(results, &cp)
+		results = append(results, tr.Clone())
 	}
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].CreatedAt.After(results[j].CreatedAt)
 	})
-	return results, nil
+	return results
+}
+
+// ListUpdatedSince returns the trades updated after since, oldest update first.
+func (r *InMemoryTradeRepository) ListUpdatedSince(_ context.Context, since time.Time) ([]*trade.Trade, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.listUpdatedSince(since), nil
+}
+
+func (r *InMemoryTradeRepository) listUpdatedSince(since time.Time) []*trade.Trade {
+	var results []*trade.Trade
+	for _, tr := range r.trades {
+		if tr.UpdatedAt.After(since) {
+			results = append(results, tr.Clone())
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].UpdatedAt.Before(results[j].UpdatedAt)
+	})
+	return results
+}
+
+// Tx runs fn while holding the write lock. Stored trades are never modified in
+// place, so a shallow copy of the map is enough to restore the previous state
+// when fn fails.
+func (r *InMemoryTradeRepository) Tx(_ context.Context, fn func(TradeRepository) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := make(map[string]*trade.Trade, len(r.trades))
+	for id, tr := range r.trades {
+		snapshot[id] = tr
+	}
+	if err := fn(memoryTx{r}); err != nil {
+		r.trades = snapshot
+		return err
+	}
+	return nil
+}
+
+// memoryTx exposes the repository to a transaction body. The caller already
+// holds the lock, so its methods use the unlocked helpers.
+type memoryTx struct {
+	r *InMemoryTradeRepository
 }
 
+func (t memoryTx) Create(_ context.Context, tr *trade.Trade) error { return t.r.create(tr) }
+
+func (t memoryTx) Update(_ context.Context, tr *trade.Trade) error { return t.r.update(tr) }
+
+func (t memoryTx) Delete(_ context.Context, id string) error { return t.r.delete(id) }
+
+func (t memoryTx) GetByID(_ context.Context, id string) (*trade.Trade, error) {
+	return t.r.getByID(id)
+}
+
+func (t memoryTx) GetMany(_ context.Context, ids []string) ([]*trade.Trade, error) {
+	return t.r.getMany(ids), nil
+}
+
+func (t memoryTx) List(context.Context) ([]*trade.Trade, error) { return t.r.list(), nil }
+
+func (t memoryTx) ListUpdatedSince(_ context.Context, since time.Time) ([]*trade.Trade, error) {
+	return t.r.listUpdatedSince(since), nil
+}
+
+func (t memoryTx) PruneDeleted(_ context.Context, before time.Time) (int, error) {
+	return t.r.pruneDeleted(before), nil
+}
+
+// Tx on an open transaction joins it rather than nesting.
+func (t memoryTx) Tx(_ context.Context, fn func(TradeRepository) error) error { return fn(t) }
+
 func generateID() string {
 	return time.Now().UTC().Format("20060102T150405.000000000")
 }
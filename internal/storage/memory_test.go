@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -53,3 +54,127 @@ func TestInMemoryRepositoryCRUD(t *testing.T) {
 		t.Fatalf("expected ErrNotFound after delete, got %v", err)
 	}
 }
+
+func TestInMemoryRepositoryPruneDeleted(t *testing.T) {
+	repo := NewInMemoryTradeRepository()
+	ctx := context.Background()
+	cutoff := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	before, after := cutoff.Add(-time.Hour), cutoff.Add(time.Hour)
+
+	for _, tr := range []*trade.Trade{{DeletedAt: &before}, {DeletedAt: &after}, {}} {
+		if err := repo.Create(ctx, tr); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+	pruned, err := repo.PruneDeleted(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if pruned != 1 {
+		t.Fatalf("expected 1 pruned trade, got %d", pruned)
+	}
+	if list, _ := repo.List(ctx); len(list) != 2 {
+		t.Fatalf("expected 2 remaining trades, got %d", len(list))
+	}
+}
+
+func TestInMemoryRepositoryTxRollsBack(t *testing.T) {
+	repo := NewInMemoryTradeRepository()
+	ctx := context.Background()
+	kept := &trade.Trade{Instrument: "AAPL"}
+	if err := repo.Create(ctx, kept); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	errBoom := errors.New("boom")
+	err := repo.Tx(ctx, func(tx TradeRepository) error {
+		changed := *kept
+		changed.Instrument = "MSFT"
+		if err := tx.Update(ctx, &changed); err != nil {
+			return err
+		}
+		if err := tx.Create(ctx, &trade.Trade{ID: "new", Instrument: "NVDA"}); err != nil {
+			return err
+		}
+		if err := tx.Tx(ctx, func(inner TradeRepository) error { return inner.Delete(ctx, kept.ID) }); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected the body's error, got %v", err)
+	}
+	list, _ := repo.List(ctx)
+	if len(list) != 1 || list[0].Instrument != "AAPL" {
+		t.Fatalf("expected the original trade only, got %+v", list)
+	}
+
+	if err := repo.Tx(ctx, func(tx TradeRepository) error {
+		return tx.Create(ctx, &trade.Trade{Instrument: "NVDA"})
+	}); err != nil {
+		t.Fatalf("tx failed: %v", err)
+	}
+	if list, _ := repo.List(ctx); len(list) != 2 {
+		t.Fatalf("expected committed create, got %d trades", len(list))
+	}
+}
+
+func TestInMemoryRepositoryListUpdatedSince(t *testing.T) {
+	repo := NewInMemoryTradeRepository()
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	first := &trade.Trade{ID: "first", Instrument: "AAPL", CreatedAt: base}
+	second := &trade.Trade{ID: "second", Instrument: "MSFT", CreatedAt: base.Add(time.Minute)}
+	if err := repo.Create(ctx, first); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	cutoff := first.UpdatedAt
+	if err := repo.Create(ctx, second); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	first.UpdatedAt = base.Add(2 * time.Minute)
+	if err := repo.Update(ctx, first); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+
+	changed, err := repo.ListUpdatedSince(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(changed) != 2 || changed[0].ID != "second" || changed[1].ID != "first" {
+		t.Fatalf("expected second then first, got %+v", changed)
+	}
+
+	latest, err := repo.ListUpdatedSince(ctx, changed[1].UpdatedAt)
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(latest) != 0 {
+		t.Fatalf("expected nothing after the newest update, got %d trades", len(latest))
+	}
+}
+
+func TestInMemoryRepositoryKeepsCallerTimestamps(t *testing.T) {
+	repo := NewInMemoryTradeRepository()
+	ctx := context.Background()
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	updated := created.Add(2 * time.Hour)
+
+	tr := &trade.Trade{Instrument: "TSLA", CreatedAt: created, UpdatedAt: created}
+	if err := repo.Create(ctx, tr); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	tr.UpdatedAt = updated
+	if err := repo.Update(ctx, tr); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+
+	stored, err := repo.GetByID(ctx, tr.ID)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if !stored.CreatedAt.Equal(created) || !stored.UpdatedAt.Equal(updated) {
+		t.Fatalf("expected caller timestamps to be kept, got %v / %v", stored.CreatedAt, stored.UpdatedAt)
+	}
+}
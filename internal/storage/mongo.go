@@ -16,6 +16,9 @@ import (
 // MongoTradeRepository persists trades in MongoDB.
 type MongoTradeRepository struct {
 	collection *mongo.Collection
+	// session is set on the copy handed to a Tx body so its operations run
+	// inside the transaction.
+	session mongo.Session
 }
 
 // NewMongoTradeRepository constructs a Mongo backed repository.
@@ -26,6 +29,7 @@ func NewMongoTradeRepository(client *mongo.Client, database, collection string)
 
 // Create inserts a new trade document.
 func (r *MongoTradeRepository) Create(ctx context.Context, tr *trade.Trade) error {
+	ctx = r.bind(ctx)
 	if tr.ID == "" {
 		tr.ID = primitive.NewObjectID().Hex()
 	}
@@ -33,17 +37,22 @@ func (r *MongoTradeRepository) Create(ctx context.Context, tr *trade.Trade) erro
 	if tr.CreatedAt.IsZero() {
 		tr.CreatedAt = now
 	}
-	tr.UpdatedAt = now
+	if tr.UpdatedAt.IsZero() {
+		tr.UpdatedAt = tr.CreatedAt
+	}
 	_, err := r.collection.InsertOne(ctx, tr)
 	return err
 }
 
 // Update replaces an existing trade document.
 func (r *MongoTradeRepository) Update(ctx context.Context, tr *trade.Trade) error {
+	ctx = r.bind(ctx)
 	if tr.ID == "" {
 		return ErrNotFound
 	}
-	tr.UpdatedAt = time.Now().UTC()
+	if tr.UpdatedAt.IsZero() {
+		tr.UpdatedAt = time.Now().UTC()
+	}
 	filter := bson.M{"_id": tr.ID}
 	result, err := r.collection.ReplaceOne(ctx, filter, tr, options.Replace().SetUpsert(false))
 	if err != nil {
@@ -57,6 +66,7 @@ func (r *MongoTradeRepository) Update(ctx context.Context, tr *trade.Trade) erro
 
 // Delete removes a trade document.
 func (r *MongoTradeRepository) Delete(ctx context.Context, id string) error {
+	ctx = r.bind(ctx)
 	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	if err != nil {
 		return err
@@ -67,8 +77,20 @@ func (r *MongoTradeRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// PruneDeleted removes soft-deleted trade documents whose deleted_at is before
+// the cutoff.
+func (r *MongoTradeRepository) PruneDeleted(ctx context.Context, before time.Time) (int, error) {
+	ctx = r.bind(ctx)
+	result, err := r.collection.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return int(result.DeletedCount), nil
+}
+
 // GetByID fetches a trade document by id.
 func (r *MongoTradeRepository) GetByID(ctx context.Context, id string) (*trade.Trade, error) {
+	ctx = r.bind(ctx)
 	var tr trade.Trade
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&tr)
 	if err != nil {
@@ -80,13 +102,40 @@ func (r *MongoTradeRepository) GetByID(ctx context.Context, id string) (*trade.T
 	return &tr, nil
 }
 
+// GetMany fetches the trade documents with the given ids in one query.
+func (r *MongoTradeRepository) GetMany(ctx context.Context, ids []string) ([]*trade.Trade, error) {
+	ctx = r.bind(ctx)
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	return decodeTrades(ctx, cursor)
+}
+
 // List returns trades sorted by creation date (desc).
 func (r *MongoTradeRepository) List(ctx context.Context) ([]*trade.Trade, error) {
+	ctx = r.bind(ctx)
 	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
 	cursor, err := r.collection.Find(ctx, bson.D{}, opts)
 	if err != nil {
 		return nil, err
 	}
+	return decodeTrades(ctx, cursor)
+}
+
+// ListUpdatedSince returns trades whose updated_at is after since, sorted by
+// updated_at (asc).
+func (r *MongoTradeRepository) ListUpdatedSince(ctx context.Context, since time.Time) ([]*trade.Trade, error) {
+	ctx = r.bind(ctx)
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"updated_at": bson.M{"$gt": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTrades(ctx, cursor)
+}
+
+func decodeTrades(ctx context.Context, cursor *mongo.Cursor) ([]*trade.Trade, error) {
 	defer cursor.Close(ctx)
 
 	var results []*trade.Trade
@@ -102,3 +151,118 @@ func (r *MongoTradeRepository) List(ctx context.Context) ([]*trade.Trade, error)
 	}
 	return results, nil
 }
+
+// Tx runs fn inside a MongoDB transaction and commits only if fn succeeds.
+// Transactions need a replica set or sharded cluster; on a standalone server
+// the transaction fails to start and nothing is written.
+func (r *MongoTradeRepository) Tx(ctx context.Context, fn func(TradeRepository) error) error {
+	if r.session != nil {
+		return fn(r)
+	}
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(mongo.SessionContext) (interface{}, error) {
+		return nil, fn(&MongoTradeRepository{collection: r.collection, session: session})
+	})
+	return err
+}
+
+// bind attaches the transaction session, if any, to ctx.
+func (r *MongoTradeRepository) bind(ctx context.Context) context.Context {
+	if r.session == nil {
+		return ctx
+	}
+	return mongo.NewSessionContext(ctx, r.session)
+}
+
+// MongoSavedViewRepository persists saved views in MongoDB, one document per
+// view keyed by name.
+type MongoSavedViewRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoSavedViewRepository constructs a Mongo backed saved view repository.
+func NewMongoSavedViewRepository(client *mongo.Client, database, collection string) (*MongoSavedViewRepository, error) {
+	coll := client.Database(database).Collection(collection)
+	return &MongoSavedViewRepository{collection: coll}, nil
+}
+
+// SaveView upserts the view document named v.Name.
+func (r *MongoSavedViewRepository) SaveView(ctx context.Context, v SavedView) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": v.Name}, v, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DeleteView removes the view document named name.
+func (r *MongoSavedViewRepository) DeleteView(ctx context.Context, name string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrViewNotFound
+	}
+	return nil
+}
+
+// ListViews returns every view document sorted by name.
+func (r *MongoSavedViewRepository) ListViews(ctx context.Context) ([]SavedView, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	views := []SavedView{}
+	if err := cursor.All(ctx, &views); err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+// MongoImportMappingRepository persists CSV import mappings in MongoDB, one
+// document per mapping keyed by name.
+type MongoImportMappingRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoImportMappingRepository constructs a Mongo backed import mapping repository.
+func NewMongoImportMappingRepository(client *mongo.Client, database, collection string) (*MongoImportMappingRepository, error) {
+	coll := client.Database(database).Collection(collection)
+	return &MongoImportMappingRepository{collection: coll}, nil
+}
+
+// SaveMapping upserts the mapping document named m.Name.
+func (r *MongoImportMappingRepository) SaveMapping(ctx context.Context, m ImportMapping) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": m.Name}, m, options.Replace().SetUpsert(true))
+	return err
+}
+
+// GetMapping fetches the mapping document named name.
+func (r *MongoImportMappingRepository) GetMapping(ctx context.Context, name string) (ImportMapping, error) {
+	var m ImportMapping
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&m)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ImportMapping{}, ErrMappingNotFound
+		}
+		return ImportMapping{}, err
+	}
+	return m, nil
+}
+
+// ListMappings returns every mapping document sorted by name.
+func (r *MongoImportMappingRepository) ListMappings(ctx context.Context) ([]ImportMapping, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	mappings := []ImportMapping{}
+	if err := cursor.All(ctx, &mappings); err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
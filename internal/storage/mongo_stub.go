@@ -5,6 +5,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"time"
 
 	"best_trade_logs/internal/domain/trade"
 )
@@ -35,12 +36,80 @@ func (r *MongoTradeRepository) Delete(context.Context, string) error {
 	return ErrMongoUnavailable
 }
 
+// PruneDeleted returns an error because MongoDB is unavailable.
+func (r *MongoTradeRepository) PruneDeleted(context.Context, time.Time) (int, error) {
+	return 0, ErrMongoUnavailable
+}
+
 // GetByID returns an error because MongoDB is unavailable.
 func (r *MongoTradeRepository) GetByID(context.Context, string) (*trade.Trade, error) {
 	return nil, ErrMongoUnavailable
 }
 
+// ListUpdatedSince returns an error because MongoDB is unavailable.
+func (r *MongoTradeRepository) ListUpdatedSince(context.Context, time.Time) ([]*trade.Trade, error) {
+	return nil, ErrMongoUnavailable
+}
+
+// GetMany returns an error because MongoDB is unavailable.
+func (r *MongoTradeRepository) GetMany(context.Context, []string) ([]*trade.Trade, error) {
+	return nil, ErrMongoUnavailable
+}
+
 // List returns an error because MongoDB is unavailable.
 func (r *MongoTradeRepository) List(context.Context) ([]*trade.Trade, error) {
 	return nil, ErrMongoUnavailable
 }
+
+// Tx returns an error because MongoDB is unavailable.
+func (r *MongoTradeRepository) Tx(context.Context, func(TradeRepository) error) error {
+	return ErrMongoUnavailable
+}
+
+// MongoSavedViewRepository is a stub implementation used when MongoDB support
+// is disabled.
+type MongoSavedViewRepository struct{}
+
+// NewMongoSavedViewRepository returns an error indicating MongoDB support is unavailable.
+func NewMongoSavedViewRepository(_ interface{}, _ string, _ string) (*MongoSavedViewRepository, error) {
+	return nil, ErrMongoUnavailable
+}
+
+// SaveView returns an error because MongoDB is unavailable.
+func (r *MongoSavedViewRepository) SaveView(context.Context, SavedView) error {
+	return ErrMongoUnavailable
+}
+
+// DeleteView returns an error because MongoDB is unavailable.
+func (r *MongoSavedViewRepository) DeleteView(context.Context, string) error {
+	return ErrMongoUnavailable
+}
+
+// ListViews returns an error because MongoDB is unavailable.
+func (r *MongoSavedViewRepository) ListViews(context.Context) ([]SavedView, error) {
+	return nil, ErrMongoUnavailable
+}
+
+// MongoImportMappingRepository is a stub implementation used when MongoDB
+// support is disabled.
+type MongoImportMappingRepository struct{}
+
+// NewMongoImportMappingRepository returns an error indicating MongoDB support is unavailable.
+func NewMongoImportMappingRepository(_ interface{}, _ string, _ string) (*MongoImportMappingRepository, error) {
+	return nil, ErrMongoUnavailable
+}
+
+// SaveMapping returns an error because MongoDB is unavailable.
+func (r *MongoImportMappingRepository) SaveMapping(context.Context, ImportMapping) error {
+	return ErrMongoUnavailable
+}
+
+// GetMapping returns an error because MongoDB is unavailable.
+func (r *MongoImportMappingRepository) GetMapping(context.Context, string) (ImportMapping, error) {
+	return ImportMapping{}, ErrMongoUnavailable
+}
+
+// ListMappings returns an error because MongoDB is unavailable.
+func (r *MongoImportMappingRepository) ListMappings(context.Context) ([]ImportMapping, error) {
+	return nil, ErrMongoUnavailable
+}
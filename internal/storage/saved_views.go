@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrViewNotFound is returned when no saved view has the requested name.
+var ErrViewNotFound = errors.New("saved view not found")
+
+// SavedView is a named set of trade list filters. Query holds the filters
+// serialised as a URL query string, as used by the trade list.
+type SavedView struct {
+	Name      string    `bson:"_id" json:"name"`
+	Query     string    `bson:"query" json:"query"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// SavedViewRepository stores saved views by name.
+type SavedViewRepository interface {
+	// SaveView stores v, replacing any view with the same name.
+	SaveView(ctx context.Context, v SavedView) error
+	DeleteView(ctx context.Context, name string) error
+	// ListViews returns every view ordered by name.
+	ListViews(ctx context.Context) ([]SavedView, error)
+}
+
+// InMemorySavedViewRepository keeps saved views for the lifetime of the
+// process.
+type InMemorySavedViewRepository struct {
+	mu    sync.RWMutex
+	views map[string]SavedView
+}
+
+// NewInMemorySavedViewRepository constructs an empty view repository.
+func NewInMemorySavedViewRepository() *InMemorySavedViewRepository {
+	return &InMemorySavedViewRepository{views: make(map[string]SavedView)}
+}
+
+// SaveView stores v under its name.
+func (r *InMemorySavedViewRepository) SaveView(_ context.Context, v SavedView) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.views[v.Name] = v
+	return nil
+}
+
+// DeleteView removes the view saved under name.
+func (r *InMemorySavedViewRepository) DeleteView(_ context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.views[name]; !ok {
+		return ErrViewNotFound
+	}
+	delete(r.views, name)
+	return nil
+}
+
+// ListViews returns every saved view ordered by name.
+func (r *InMemorySavedViewRepository) ListViews(context.Context) ([]SavedView, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	views := make([]SavedView, 0, len(r.views))
+	for _, v := range r.views {
+		views = append(views, v)
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Name < views[j].Name })
+	return views, nil
+}
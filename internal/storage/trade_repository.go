@@ -2,15 +2,31 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"best_trade_logs/internal/domain/trade"
 )
 
 // TradeRepository describes the persistence operations required by the service layer.
+// Create and Update keep the CreatedAt and UpdatedAt the caller set, so the
+// service clock decides them; only zero timestamps are filled in.
 type TradeRepository interface {
 	Create(ctx context.Context, tr *trade.Trade) error
 	Update(ctx context.Context, tr *trade.Trade) error
 	Delete(ctx context.Context, id string) error
 	GetByID(ctx context.Context, id string) (*trade.Trade, error)
+	// GetMany returns the trades with the given IDs in no particular order;
+	// unknown IDs are skipped rather than reported as errors.
+	GetMany(ctx context.Context, ids []string) ([]*trade.Trade, error)
 	List(ctx context.Context) ([]*trade.Trade, error)
+	// ListUpdatedSince returns the trades, including deleted and archived ones,
+	// whose UpdatedAt is after since, oldest update first.
+	ListUpdatedSince(ctx context.Context, since time.Time) ([]*trade.Trade, error)
+	// PruneDeleted permanently removes soft-deleted trades whose DeletedAt is
+	// before the cutoff and returns how many were removed.
+	PruneDeleted(ctx context.Context, before time.Time) (int, error)
+	// Tx runs fn against a repository whose writes are committed together: if
+	// fn returns an error none of them are kept. Calling Tx on the repository
+	// passed to fn joins the open transaction.
+	Tx(ctx context.Context, fn func(TradeRepository) error) error
 }
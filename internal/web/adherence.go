@@ -0,0 +1,39 @@
+package web
+
+import (
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// planAdherence splits closed trades by whether the review says they were
+// executed according to plan. Trades without an assessment are left out.
+type planAdherence struct {
+	Followed dashboardMetrics
+	Deviated dashboardMetrics
+}
+
+// Samples is the number of closed trades with a plan adherence assessment.
+func (a planAdherence) Samples() int {
+	return a.Followed.Closed + a.Deviated.Closed
+}
+
+// summarizeTradesByAdherence is summarizeAdherence over raw trades.
+func summarizeTradesByAdherence(trades []*domain.Trade, now time.Time, epsilon float64) planAdherence {
+	return summarizeAdherence(buildTradeSummaries(trades, now, epsilon))
+}
+
+func summarizeAdherence(rows []tradeSummary) planAdherence {
+	var followed, deviated []tradeSummary
+	for _, row := range rows {
+		if row.IsOpen || row.Review.FollowedPlan == nil {
+			continue
+		}
+		if *row.Review.FollowedPlan {
+			followed = append(followed, row)
+		} else {
+			deviated = append(deviated, row)
+		}
+	}
+	return planAdherence{Followed: summarizeRows(followed), Deviated: summarizeRows(deviated)}
+}
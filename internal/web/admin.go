@@ -0,0 +1,72 @@
+package web
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPruneDays is the grace period used by /admin/prune when older_than_days is omitted.
+const defaultPruneDays = 90
+
+// WithAdminToken enables the /admin endpoints, which require the token as a
+// bearer credential. Without a token the admin endpoints stay disabled.
+func WithAdminToken(token string) Option {
+	return func(s *Server) {
+		s.adminToken = strings.TrimSpace(token)
+	}
+}
+
+// requireAdmin rejects requests that do not carry the configured admin token.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			http.Error(w, "管理功能未啟用", http.StatusForbidden)
+			return
+		}
+		token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "未授權", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleAdminNormalize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	updated, err := s.svc.NormalizeAll(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"updated": updated})
+}
+
+func (s *Server) handleAdminPrune(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	days := defaultPruneDays
+	if raw := strings.TrimSpace(r.URL.Query().Get("older_than_days")); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 0 {
+			writeJSONError(w, http.StatusBadRequest, "older_than_days 格式錯誤")
+			return
+		}
+		days = v
+	}
+	pruned, err := s.svc.Prune(r.Context(), time.Duration(days)*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"pruned": pruned})
+}
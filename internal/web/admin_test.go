@@ -0,0 +1,141 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+	tradesvc "best_trade_logs/internal/service/trade"
+	"best_trade_logs/internal/storage"
+)
+
+func TestAdminNormalizeRequiresToken(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := tradesvc.NewService(repo)
+	if err := repo.Create(testContext(), &domain.Trade{Instrument: "AAPL", Review: domain.TradeReview{Tags: []string{" Swing "}}}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	disabled, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	disabled.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/normalize", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected admin endpoints to be disabled, got %d", rec.Code)
+	}
+
+	server, err := NewServer(svc, WithAdminToken("secret"))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/admin/normalize", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for wrong token, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/admin/normalize", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"updated":1`) {
+		t.Fatalf("expected one trade to be normalized, got %s", rec.Body.String())
+	}
+}
+
+func TestAdminPruneRemovesOldArchivedTrades(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := tradesvc.NewService(repo)
+	old := time.Now().UTC().AddDate(0, 0, -120)
+	recent := time.Now().UTC().AddDate(0, 0, -10)
+	for _, tr := range []*domain.Trade{
+		{Instrument: "OLD", DeletedAt: &old},
+		{Instrument: "RECENT", DeletedAt: &recent},
+		{Instrument: "LIVE"},
+	} {
+		if err := repo.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	server, err := NewServer(svc, WithAdminToken("secret"))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	prune := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := prune("/admin/prune?older_than_days=abc"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid threshold, got %d", rec.Code)
+	}
+	rec := prune("/admin/prune?older_than_days=90")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"pruned":1`) {
+		t.Fatalf("expected one trade pruned, got %d %s", rec.Code, rec.Body.String())
+	}
+	remaining, _ := repo.List(testContext())
+	if len(remaining) != 2 {
+		t.Fatalf("expected archived-but-recent and live trades to remain, got %d", len(remaining))
+	}
+}
+
+func TestAdminCloseStaleClosesMarkedTrades(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	mark := 55.0
+	old := time.Now().UTC().AddDate(0, 0, -200)
+	marked := &domain.Trade{Instrument: "MARKED", Entry: domain.EntryDetail{Date: old, Price: 50, Quantity: 2}, MarkPrice: &mark}
+	unmarked := &domain.Trade{Instrument: "UNMARKED", Entry: domain.EntryDetail{Date: old, Price: 50, Quantity: 2}}
+	for _, tr := range []*domain.Trade{marked, unmarked} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	server, err := NewServer(svc, WithAdminToken("secret"), WithStaleAfter(90*24*time.Hour))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "久未處理的未平倉部位") || !strings.Contains(body, "UNMARKED &middot; 已持有 200 天 &middot; 無參考價") {
+		t.Fatalf("expected stale trades to be listed on the dashboard")
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/admin/close-stale", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"closed":1`) || !strings.Contains(rec.Body.String(), marked.ID) {
+		t.Fatalf("expected the marked trade to be closed, got %d %s", rec.Code, rec.Body.String())
+	}
+	got, err := svc.Get(testContext(), marked.ID)
+	if err != nil || got.Exit == nil || got.Exit.Price != mark || got.Exit.Reason != tradesvc.StaleExitReason {
+		t.Fatalf("unexpected exit after auto-close: %+v (%v)", got, err)
+	}
+
+	unconfigured, err := NewServer(svc, WithAdminToken("secret"))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/admin/close-stale", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	unconfigured.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a stale age, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,377 @@
+package web
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// groupMetrics holds the dashboard metrics for a single group of trades.
+type groupMetrics struct {
+	Key     string
+	Metrics dashboardMetrics
+}
+
+// groupKeyFunc returns the groups a trade belongs to. Returning several keys lets
+// a trade contribute to more than one group (for example one per tag).
+type groupKeyFunc func(tr *domain.Trade) []string
+
+// groupTrades buckets trade summaries by key and summarises each bucket, ordered by key.
+func groupTrades(rows []tradeSummary, keyFn groupKeyFunc) []groupMetrics {
+	buckets := make(map[string][]tradeSummary)
+	for _, row := range rows {
+		for _, key := range keyFn(row.Trade) {
+			buckets[key] = append(buckets[key], row)
+		}
+	}
+	if len(buckets) == 0 {
+		return nil
+	}
+	groups := make([]groupMetrics, 0, len(buckets))
+	for key, members := range buckets {
+		groups = append(groups, groupMetrics{Key: key, Metrics: summarizeRows(members)})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+const unassignedAccount = "未指定帳戶"
+
+func accountKey(tr *domain.Trade) []string {
+	account := strings.TrimSpace(tr.Account)
+	if account == "" {
+		return []string{unassignedAccount}
+	}
+	return []string{account}
+}
+
+func collectAccounts(trades []*domain.Trade) []string {
+	seen := make(map[string]struct{})
+	var accounts []string
+	for _, tr := range trades {
+		account := strings.TrimSpace(tr.Account)
+		if account == "" {
+			continue
+		}
+		key := strings.ToLower(account)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// tagKeys explodes a trade into one group per normalised tag.
+func tagKeys(tr *domain.Trade) []string {
+	var keys []string
+	seen := make(map[string]struct{})
+	for _, tag := range tr.Review.Tags {
+		normalised := domain.NormalizeTag(tag)
+		if normalised == "" {
+			continue
+		}
+		if _, ok := seen[normalised]; ok {
+			continue
+		}
+		seen[normalised] = struct{}{}
+		keys = append(keys, normalised)
+	}
+	return keys
+}
+
+// monthlyPoint is one calendar month of realised results.
+type monthlyPoint struct {
+	Month      string  `json:"month"`
+	Trades     int     `json:"trades"`
+	Net        float64 `json:"net"`
+	Cumulative float64 `json:"cumulative"`
+}
+
+// monthlySeries buckets closed trades by exit month and accumulates their net result.
+func monthlySeries(rows []tradeSummary) []monthlyPoint {
+	type monthTotal struct {
+		trades int
+		net    domain.Money
+	}
+	buckets := make(map[string]*monthTotal)
+	for _, row := range rows {
+		if row.IsOpen || row.Exit.Date.IsZero() {
+			continue
+		}
+		month := row.Exit.Date.Format("2006-01")
+		total, ok := buckets[month]
+		if !ok {
+			total = &monthTotal{}
+			buckets[month] = total
+		}
+		total.trades++
+		total.net += domain.ToMoney(row.NetResult)
+	}
+	months := make([]string, 0, len(buckets))
+	for month := range buckets {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+	series := make([]monthlyPoint, 0, len(months))
+	var running domain.Money
+	for _, month := range months {
+		total := buckets[month]
+		running += total.net
+		series = append(series, monthlyPoint{Month: month, Trades: total.trades, Net: total.net.Float64(), Cumulative: running.Float64()})
+	}
+	return series
+}
+
+// tagCloudEntry is one tag sized by how often it is used and coloured by its net result.
+type tagCloudEntry struct {
+	Tag    string  `json:"tag"`
+	Count  int     `json:"count"`
+	Net    float64 `json:"net"`
+	Weight int     `json:"weight"`
+}
+
+// tagCloudLevels is the number of size steps the dashboard renders.
+const tagCloudLevels = 5
+
+// tagCloud aggregates trade count and net result per tag, in collectTags order.
+// Weight scales the count linearly between 1 and tagCloudLevels.
+func tagCloud(rows []tradeSummary, order string) []tagCloudEntry {
+	trades := make([]*domain.Trade, len(rows))
+	for i, row := range rows {
+		trades[i] = row.Trade
+	}
+	groups := groupTrades(rows, tagKeys)
+	byTag := make(map[string]dashboardMetrics, len(groups))
+	for _, g := range groups {
+		byTag[g.Key] = g.Metrics
+	}
+
+	tags := collectTags(trades, order)
+	entries := make([]tagCloudEntry, 0, len(tags))
+	minCount, maxCount := 0, 0
+	for _, tag := range tags {
+		m := byTag[tag.Tag]
+		entries = append(entries, tagCloudEntry{Tag: tag.Tag, Count: m.Total, Net: m.TotalNet})
+		if minCount == 0 || m.Total < minCount {
+			minCount = m.Total
+		}
+		if m.Total > maxCount {
+			maxCount = m.Total
+		}
+	}
+	for i := range entries {
+		entries[i].Weight = 1
+		if maxCount > minCount {
+			entries[i].Weight = 1 + (entries[i].Count-minCount)*(tagCloudLevels-1)/(maxCount-minCount)
+		}
+	}
+	return entries
+}
+
+// mistakeCount is how often a checklist mistake was ticked and what those trades netted.
+type mistakeCount struct {
+	Mistake string
+	Count   int
+	Net     float64
+}
+
+func mistakeKeys(tr *domain.Trade) []string {
+	return tr.Review.Mistakes
+}
+
+// mistakeBreakdown counts mistakes across trades, most frequent first.
+func mistakeBreakdown(rows []tradeSummary) []mistakeCount {
+	groups := groupTrades(rows, mistakeKeys)
+	counts := make([]mistakeCount, 0, len(groups))
+	for _, g := range groups {
+		counts = append(counts, mistakeCount{Mistake: g.Key, Count: g.Metrics.Total, Net: g.Metrics.TotalNet})
+	}
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].Count > counts[j].Count
+	})
+	return counts
+}
+
+// extremeTrade is a compact reference to a notable trade.
+type extremeTrade struct {
+	ID         string  `json:"id"`
+	Instrument string  `json:"instrument"`
+	NetResult  float64 `json:"net_result"`
+	RMultiple  float64 `json:"r_multiple"`
+	HoldDays   float64 `json:"hold_days"`
+}
+
+// tradeExtremes lists the standout closed trades. Entries are nil when no
+// closed trade qualifies; R-multiple extremes only consider trades with defined risk.
+type tradeExtremes struct {
+	LargestWinner *extremeTrade `json:"largest_winner"`
+	LargestLoser  *extremeTrade `json:"largest_loser"`
+	BestR         *extremeTrade `json:"best_r"`
+	WorstR        *extremeTrade `json:"worst_r"`
+	LongestHeld   *extremeTrade `json:"longest_held"`
+}
+
+func (e tradeExtremes) Any() bool {
+	return e.LargestWinner != nil || e.LargestLoser != nil || e.LongestHeld != nil
+}
+
+func newExtremeTrade(row tradeSummary) *extremeTrade {
+	return &extremeTrade{
+		ID:         row.ID,
+		Instrument: row.Instrument,
+		NetResult:  row.NetResult,
+		RMultiple:  row.RMultiple,
+		HoldDays:   row.HoldDays,
+	}
+}
+
+// findExtremes reduces closed trade summaries to their extremes.
+func findExtremes(rows []tradeSummary) tradeExtremes {
+	var ext tradeExtremes
+	var winner, loser, bestR, worstR, longest *tradeSummary
+	for i := range rows {
+		row := &rows[i]
+		if row.IsOpen {
+			continue
+		}
+		if row.NetResult > 0 && (winner == nil || row.NetResult > winner.NetResult) {
+			winner = row
+		}
+		if row.NetResult < 0 && (loser == nil || row.NetResult < loser.NetResult) {
+			loser = row
+		}
+		if row.TotalRisk > 0 {
+			if bestR == nil || row.RMultiple > bestR.RMultiple {
+				bestR = row
+			}
+			if worstR == nil || row.RMultiple < worstR.RMultiple {
+				worstR = row
+			}
+		}
+		if row.HasHold && (longest == nil || row.HoldDays > longest.HoldDays) {
+			longest = row
+		}
+	}
+	for _, pick := range []struct {
+		row *tradeSummary
+		dst **extremeTrade
+	}{
+		{winner, &ext.LargestWinner},
+		{loser, &ext.LargestLoser},
+		{bestR, &ext.BestR},
+		{worstR, &ext.WorstR},
+		{longest, &ext.LongestHeld},
+	} {
+		if pick.row != nil {
+			*pick.dst = newExtremeTrade(*pick.row)
+		}
+	}
+	return ext
+}
+
+// equityPoint is the cumulative net result after a closed trade.
+type equityPoint struct {
+	Date    time.Time `json:"date"`
+	TradeID string    `json:"trade_id"`
+	Equity  float64   `json:"equity"`
+}
+
+// equityCurve accumulates the net result of closed trades in exit order.
+func equityCurve(rows []tradeSummary) []equityPoint {
+	closed := make([]tradeSummary, 0, len(rows))
+	for _, row := range rows {
+		if !row.IsOpen && !row.Exit.Date.IsZero() {
+			closed = append(closed, row)
+		}
+	}
+	sort.SliceStable(closed, func(i, j int) bool {
+		return closed[i].Exit.Date.Before(closed[j].Exit.Date)
+	})
+	curve := make([]equityPoint, 0, len(closed))
+	var equity domain.Money
+	for _, row := range closed {
+		equity += domain.ToMoney(row.NetResult)
+		curve = append(curve, equityPoint{Date: row.Exit.Date, TradeID: row.ID, Equity: equity.Float64()})
+	}
+	return curve
+}
+
+// holdBucket is the performance of closed trades held for a range of calendar days.
+type holdBucket struct {
+	Key     string  `json:"key"`
+	Label   string  `json:"label"`
+	Trades  int     `json:"trades"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	WinRate float64 `json:"win_rate"`
+	AvgR    float64 `json:"avg_r"`
+}
+
+// holdRanges are the holding-period buckets, by the number of calendar days
+// between entry and exit; maxDays < 0 leaves a bucket open-ended.
+var holdRanges = []struct {
+	key, label string
+	maxDays    int
+}{
+	{"intraday", "當日", 0},
+	{"1-3d", "1–3 天", 3},
+	{"4-10d", "4–10 天", 10},
+	{"10d+", "10 天以上", -1},
+}
+
+// calendarDays counts the date boundaries crossed between from and to, so a
+// trade opened and closed on the same day is 0 however long it was held.
+func calendarDays(from, to time.Time) int {
+	fy, fm, fd := from.Date()
+	ty, tm, td := to.Date()
+	start := time.Date(fy, fm, fd, 0, 0, 0, 0, time.UTC)
+	end := time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+func holdRangeKey(tr *domain.Trade) []string {
+	days := calendarDays(tr.Entry.Date, tr.Exit.Date)
+	for _, rng := range holdRanges {
+		if rng.maxDays < 0 || days <= rng.maxDays {
+			return []string{rng.key}
+		}
+	}
+	return nil
+}
+
+// holdTimeBuckets groups closed trades with a known holding period by
+// holdRanges and reports each bucket's win rate and average R, including empty
+// buckets so the ranges are always listed in order.
+func holdTimeBuckets(rows []tradeSummary) []holdBucket {
+	closed := make([]tradeSummary, 0, len(rows))
+	for _, row := range rows {
+		if !row.IsOpen && row.HasHold {
+			closed = append(closed, row)
+		}
+	}
+	byKey := make(map[string]groupMetrics)
+	for _, g := range groupTrades(closed, holdRangeKey) {
+		byKey[g.Key] = g
+	}
+	buckets := make([]holdBucket, 0, len(holdRanges))
+	for _, rng := range holdRanges {
+		m := byKey[rng.key].Metrics
+		buckets = append(buckets, holdBucket{
+			Key:     rng.key,
+			Label:   rng.label,
+			Trades:  m.Closed,
+			Wins:    m.Wins,
+			Losses:  m.Losses,
+			WinRate: m.WinRate,
+			AvgR:    m.AvgR,
+		})
+	}
+	return buckets
+}
@@ -0,0 +1,300 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+	tradesvc "best_trade_logs/internal/service/trade"
+	"best_trade_logs/internal/storage"
+)
+
+// handleAPI routes the JSON API under /api.
+func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/"), "/")
+	parts := strings.Split(path, "/")
+	switch {
+	case path == "trades/import" && r.Method == http.MethodPost:
+		s.handleAPIImport(w, r)
+	case path == "trades/import/mapped" && r.Method == http.MethodPost:
+		s.handleAPIMappedUpload(w, r)
+	case path == "trades/import/mappings" && r.Method == http.MethodGet:
+		s.handleAPIImportMappings(w, r)
+	case len(parts) == 4 && path == "trades/import/mapped/"+parts[3] && r.Method == http.MethodPost:
+		s.handleAPIMappedImport(w, r, parts[3])
+	case path == "trades/incomplete" && r.Method == http.MethodGet:
+		s.handleAPIIncomplete(w, r)
+	case path == "trades/recent" && r.Method == http.MethodGet:
+		s.handleAPIRecentlyUpdated(w, r)
+	case path == "trades/batch" && r.Method == http.MethodPost:
+		s.handleAPIBatchGet(w, r)
+	case path == "trades":
+		switch r.Method {
+		case http.MethodGet:
+			s.handleAPIListTrades(w, r)
+		case http.MethodPost:
+			s.handleAPICreateTrade(w, r)
+		default:
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case len(parts) == 3 && parts[0] == "trades" && parts[2] == "exit":
+		if r.Method != http.MethodPost && r.Method != http.MethodPatch {
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.handleAPICloseTrade(w, r, parts[1])
+	case len(parts) == 2 && parts[0] == "trades":
+		id := parts[1]
+		switch r.Method {
+		case http.MethodGet:
+			s.handleAPIGetTrade(w, r, id)
+		case http.MethodPut:
+			s.handleAPIUpdateTrade(w, r, id)
+		case http.MethodDelete:
+			s.handleAPIDeleteTrade(w, r, id)
+		default:
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case path == "form-options" && r.Method == http.MethodGet:
+		s.handleAPIFormOptions(w, r)
+	case path == "metrics/by-tag/timeseries" && r.Method == http.MethodGet:
+		s.handleAPITagTimeSeries(w, r)
+	case path == "metrics/tag-cloud" && r.Method == http.MethodGet:
+		s.handleAPITagCloud(w, r)
+	case path == "metrics/tag-correlation" && r.Method == http.MethodGet:
+		s.handleAPITagCorrelation(w, r)
+	case path == "metrics/extremes" && r.Method == http.MethodGet:
+		s.handleAPIExtremes(w, r)
+	case path == "metrics/open-risk" && r.Method == http.MethodGet:
+		s.handleAPIOpenRisk(w, r)
+	case path == "metrics/by-hold-time" && r.Method == http.MethodGet:
+		s.handleAPIByHoldTime(w, r)
+	case path == "metrics/selection" && r.Method == http.MethodPost:
+		s.handleAPISelectionMetrics(w, r)
+	case path == "metrics/equity.svg" && r.Method == http.MethodGet:
+		s.handleAPIEquitySVG(w, r)
+	default:
+		writeJSONError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) handleAPIListTrades(w http.ResponseWriter, r *http.Request) {
+	filters := s.indexFilters(r)
+	trades, err := s.listTrades(r.Context(), filters)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	filtered := applyIndexFilters(filterArchived(trades, filters.Archived), filters, s.breakevenEpsilon)
+	if filtered == nil {
+		filtered = []*domain.Trade{}
+	}
+	writeJSON(w, http.StatusOK, s.apiTrades(filtered))
+}
+
+func (s *Server) handleAPIGetTrade(w http.ResponseWriter, r *http.Request, id string) {
+	tr, err := s.svc.Get(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.apiTrade(tr))
+}
+
+// maxBatchIDs caps how many trades one batch request may fetch.
+const maxBatchIDs = 500
+
+// batchResponse is the result of a batch fetch: the trades found, in request
+// order, and the IDs that matched no trade.
+type batchResponse struct {
+	Trades  []*domain.Trade `json:"trades"`
+	Missing []string        `json:"missing"`
+}
+
+// handleAPIBatchGet fetches the trades for a JSON array of IDs in one round trip.
+func (s *Server) handleAPIBatchGet(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "expected a JSON array of trade IDs")
+		return
+	}
+	if len(ids) > maxBatchIDs {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("at most %d IDs per request", maxBatchIDs))
+		return
+	}
+	trades, missing, err := s.svc.GetMany(r.Context(), ids)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, batchResponse{Trades: s.apiTrades(trades), Missing: missing})
+}
+
+// recentResponse lists the trades changed after a point in time. NextSince is
+// the UpdatedAt of the newest trade returned, to pass as ?since= on the next
+// pull; it repeats the requested since when nothing changed.
+type recentResponse struct {
+	Trades    []*domain.Trade `json:"trades"`
+	NextSince time.Time       `json:"next_since"`
+}
+
+// handleAPIRecentlyUpdated serves incremental syncs: ?since= takes an RFC 3339
+// timestamp and may be omitted to fetch everything.
+func (s *Server) handleAPIRecentlyUpdated(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if raw := strings.TrimSpace(r.URL.Query().Get("since")); raw != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
+			return
+		}
+		since = parsed
+	}
+	trades, err := s.svc.ListUpdatedSince(r.Context(), since)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := recentResponse{Trades: s.apiTrades(trades), NextSince: since}
+	if n := len(trades); n > 0 {
+		resp.NextSince = trades[n-1].UpdatedAt
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleAPICreateTrade(w http.ResponseWriter, r *http.Request) {
+	tr, ok := decodeAPITrade(w, r)
+	if !ok {
+		return
+	}
+	tr.ID = ""
+	if err := s.svc.Create(r.Context(), tr); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, s.apiTrade(tr))
+}
+
+func (s *Server) handleAPIUpdateTrade(w http.ResponseWriter, r *http.Request, id string) {
+	existing, err := s.svc.Get(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if isDryRun(r) {
+		tr, err := readAPITrade(r)
+		var errs []string
+		if err != nil {
+			tr = &domain.Trade{}
+			errs = append(errs, err.Error())
+		}
+		mergeExisting(tr, existing)
+		s.writeValidation(w, tr, errs)
+		return
+	}
+	tr, ok := decodeAPITrade(w, r)
+	if !ok {
+		return
+	}
+	mergeExisting(tr, existing)
+	if err := s.svc.Update(r.Context(), tr); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.apiTrade(tr))
+}
+
+// handleAPICloseTrade records just the exit of a trade. Pass ?override=1 to
+// replace the exit of an already closed trade.
+func (s *Server) handleAPICloseTrade(w http.ResponseWriter, r *http.Request, id string) {
+	var exit domain.ExitDetail
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&exit); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	tr, err := s.svc.CloseTrade(r.Context(), id, exit, r.URL.Query().Get("override") == "1")
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.apiTrade(tr))
+}
+
+func (s *Server) handleAPIDeleteTrade(w http.ResponseWriter, r *http.Request, id string) {
+	if err := s.svc.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeAPITrade(w http.ResponseWriter, r *http.Request) (*domain.Trade, bool) {
+	tr, err := readAPITrade(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return tr, true
+}
+
+// readAPITrade decodes and sanitizes a JSON trade body.
+func readAPITrade(r *http.Request) (*domain.Trade, error) {
+	var tr domain.Trade
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&tr); err != nil {
+		return nil, errors.New("invalid JSON body: " + err.Error())
+	}
+	if err := sanitizeAPITrade(&tr); err != nil {
+		return nil, err
+	}
+	if errs := tradeErrors(&tr); len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return &tr, nil
+}
+
+// sanitizeAPITrade applies the minimal checks shared by the JSON create and import paths.
+func sanitizeAPITrade(tr *domain.Trade) error {
+	tr.Instrument = strings.TrimSpace(tr.Instrument)
+	if tr.Instrument == "" {
+		return errors.New("instrument is required")
+	}
+	tr.Direction = domain.Direction(strings.ToUpper(string(tr.Direction)))
+	if tr.Direction != domain.DirectionLong && tr.Direction != domain.DirectionShort {
+		tr.Direction = domain.DirectionLong
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		log.Printf("json write error: %v", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, tradesvc.ErrTradeClosed), errors.Is(err, tradesvc.ErrTradeOpen):
+		status = http.StatusConflict
+	case errors.Is(err, tradesvc.ErrInvalidExit), errors.Is(err, tradesvc.ErrInvalidHedge), errors.Is(err, tradesvc.ErrInvalidScreenshotURL):
+		status = http.StatusBadRequest
+	}
+	writeJSONError(w, status, err.Error())
+}
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// selectionResponse is the dashboard metrics of an ad-hoc selection of trades
+// and the requested IDs that matched no trade.
+type selectionResponse struct {
+	Metrics dashboardMetrics `json:"metrics"`
+	Missing []string         `json:"missing"`
+}
+
+// handleAPISelectionMetrics computes the dashboard metrics over the trades of
+// a JSON array of IDs, such as the rows checked in a list.
+func (s *Server) handleAPISelectionMetrics(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "expected a JSON array of trade IDs")
+		return
+	}
+	if len(ids) > maxBatchIDs {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("at most %d IDs per request", maxBatchIDs))
+		return
+	}
+	trades, missing, err := s.svc.GetMany(r.Context(), ids)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	metrics := summarizeTrades(trades, s.svc.Now(), s.breakevenEpsilon).withMinSamples(s.minSamples)
+	writeJSON(w, http.StatusOK, selectionResponse{Metrics: s.apiPrecision.dashboardMetrics(metrics), Missing: missing})
+}
+
+func (s *Server) handleAPITagTimeSeries(w http.ResponseWriter, r *http.Request) {
+	tag := domain.NormalizeTag(r.URL.Query().Get("tag"))
+	if tag == "" {
+		writeJSONError(w, http.StatusBadRequest, "tag is required")
+		return
+	}
+	trades, err := s.svc.List(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rows := buildTradeSummaries(trades, s.svc.Now(), s.breakevenEpsilon)
+
+	var tagged []tradeSummary
+	for _, row := range rows {
+		for _, key := range tagKeys(row.Trade) {
+			if key == tag {
+				tagged = append(tagged, row)
+				break
+			}
+		}
+	}
+	if len(tagged) == 0 {
+		writeJSONError(w, http.StatusNotFound, "unknown tag")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Tag    string         `json:"tag"`
+		Series []monthlyPoint `json:"series"`
+	}{Tag: tag, Series: s.apiPrecision.monthlySeries(monthlySeries(tagged))})
+}
+
+func (s *Server) handleAPITagCloud(w http.ResponseWriter, r *http.Request) {
+	trades, err := s.svc.List(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	filtered := applyIndexFilters(trades, s.indexFilters(r), s.breakevenEpsilon)
+	rows := buildTradeSummaries(filtered, s.svc.Now(), s.breakevenEpsilon)
+	writeJSON(w, http.StatusOK, s.apiPrecision.tagCloud(tagCloud(rows, s.tagOrder)))
+}
+
+func (s *Server) handleAPITagCorrelation(w http.ResponseWriter, r *http.Request) {
+	a := domain.NormalizeTag(r.URL.Query().Get("a"))
+	b := domain.NormalizeTag(r.URL.Query().Get("b"))
+	if a == "" || b == "" {
+		writeJSONError(w, http.StatusBadRequest, "tags a and b are required")
+		return
+	}
+	if a == b {
+		writeJSONError(w, http.StatusBadRequest, "tags a and b must differ")
+		return
+	}
+	trades, err := s.svc.List(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	filtered := applyIndexFilters(trades, s.indexFilters(r), s.breakevenEpsilon)
+	rows := buildTradeSummaries(filtered, s.svc.Now(), s.breakevenEpsilon)
+	writeJSON(w, http.StatusOK, s.apiPrecision.tagCorrelation(correlateTags(rows, a, b)))
+}
+
+func (s *Server) handleAPIExtremes(w http.ResponseWriter, r *http.Request) {
+	trades, err := s.svc.List(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	filtered := applyIndexFilters(trades, s.indexFilters(r), s.breakevenEpsilon)
+	rows := buildTradeSummaries(filtered, s.svc.Now(), s.breakevenEpsilon)
+	writeJSON(w, http.StatusOK, s.apiPrecision.extremes(findExtremes(rows)))
+}
+
+func (s *Server) handleAPIByHoldTime(w http.ResponseWriter, r *http.Request) {
+	trades, err := s.svc.List(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	filtered := applyIndexFilters(trades, s.indexFilters(r), s.breakevenEpsilon)
+	rows := buildTradeSummaries(filtered, s.svc.Now(), s.breakevenEpsilon)
+	writeJSON(w, http.StatusOK, s.apiPrecision.holdBuckets(holdTimeBuckets(rows)))
+}
+
+func (s *Server) handleAPIEquitySVG(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	width, err := parseChartSize(q.Get("width"), defaultChartWidth)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid width")
+		return
+	}
+	height, err := parseChartSize(q.Get("height"), defaultChartHeight)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid height")
+		return
+	}
+	trades, err := s.svc.List(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	filtered := applyIndexFilters(trades, s.indexFilters(r), s.breakevenEpsilon)
+	rows := buildTradeSummaries(filtered, s.svc.Now(), s.breakevenEpsilon)
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(renderEquitySVG(equityCurve(rows), width, height)))
+}
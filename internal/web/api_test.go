@@ -0,0 +1,855 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+	tradesvc "best_trade_logs/internal/service/trade"
+	"best_trade_logs/internal/storage"
+)
+
+func newAPITestServer(t *testing.T, opts ...Option) (*Server, *tradesvc.Service) {
+	t.Helper()
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	server, err := NewServer(svc, opts...)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	return server, svc
+}
+
+func TestAPICreateAndGetTrade(t *testing.T) {
+	server, _ := newAPITestServer(t)
+
+	body := `{"instrument":"AAPL","direction":"short","entry":{"price":180.5,"quantity":100}}`
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/trades", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created domain.Trade
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.ID == "" || created.Direction != domain.DirectionShort {
+		t.Fatalf("unexpected created trade: %+v", created)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/trades/"+created.ID, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/trades/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown trade, got %d", rec.Code)
+	}
+}
+
+func TestAPICORSPreflight(t *testing.T) {
+	server, _ := newAPITestServer(t, WithCORS([]string{"https://app.example.com"}, nil, nil))
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/trades", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 preflight, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("unexpected allow origin: %q", got)
+	}
+	if !strings.Contains(rec.Header().Get("Access-Control-Allow-Methods"), http.MethodPost) {
+		t.Fatalf("expected POST to be allowed")
+	}
+
+	req = httptest.NewRequest(http.MethodOptions, "/api/trades", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected unknown origin to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestCORSNotAppliedOutsideAPI(t *testing.T) {
+	server, _ := newAPITestServer(t, WithCORS([]string{"*"}, nil, nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected CORS headers only on /api routes")
+	}
+}
+
+func TestAPITagTimeSeries(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	closed := func(month time.Month, exit float64, tags ...string) *domain.Trade {
+		return &domain.Trade{
+			Instrument: "X",
+			Direction:  domain.DirectionLong,
+			Entry:      domain.EntryDetail{Date: time.Date(2024, month, 1, 0, 0, 0, 0, time.UTC), Price: 100, Quantity: 1},
+			Exit:       &domain.ExitDetail{Date: time.Date(2024, month, 10, 0, 0, 0, 0, time.UTC), Price: exit, Quantity: 1},
+			Review:     domain.TradeReview{Tags: tags},
+		}
+	}
+	for _, tr := range []*domain.Trade{
+		closed(time.February, 90, "breakout"),
+		closed(time.January, 110, "breakout", "earnings"),
+		closed(time.January, 105, "breakout"),
+		closed(time.January, 150, "earnings"),
+	} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/metrics/by-tag/timeseries?tag=Breakout", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Series []monthlyPoint `json:"series"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(payload.Series) != 2 {
+		t.Fatalf("expected 2 months, got %+v", payload.Series)
+	}
+	if payload.Series[0].Month != "2024-01" || payload.Series[0].Cumulative != 15 || payload.Series[1].Cumulative != 5 {
+		t.Fatalf("unexpected series: %+v", payload.Series)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/metrics/by-tag/timeseries?tag=unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown tag, got %d", rec.Code)
+	}
+}
+
+func TestAPIFormOptions(t *testing.T) {
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository(),
+		tradesvc.WithClock(tradesvc.ClockFunc(func() time.Time { return now })),
+		tradesvc.WithKnownSetups([]string{"Breakout", "Pullback"}),
+		tradesvc.WithExitReasons([]string{"達標出場"}),
+		tradesvc.WithInstrumentAliases(map[string]string{"台積電": "2330"}),
+	)
+	server, err := NewServer(svc, WithTagOrder(TagOrderUsage))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	for _, tr := range []*domain.Trade{
+		{Instrument: "AAPL", Currency: "usd", Account: "IB", Review: domain.TradeReview{Tags: []string{"swing", "earnings"}}},
+		{Instrument: "EURUSD", Currency: "USD", FeeCurrency: "EUR", Review: domain.TradeReview{Tags: []string{"swing"}}},
+	} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/form-options", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got formOptions
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if strings.Join(got.Instruments, ",") != "2330,AAPL,EURUSD" || got.InstrumentAliases["台積電"] != "2330" {
+		t.Fatalf("unexpected instruments: %v %v", got.Instruments, got.InstrumentAliases)
+	}
+	if strings.Join(got.Setups, ",") != "Breakout,Pullback" || strings.Join(got.ExitReasons, ",") != "達標出場" || len(got.Mistakes) == 0 {
+		t.Fatalf("unexpected configured lists: %+v", got)
+	}
+	if strings.Join(got.Currencies, ",") != "EUR,USD" || strings.Join(got.Accounts, ",") != "IB" {
+		t.Fatalf("unexpected currencies or accounts: %v %v", got.Currencies, got.Accounts)
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != (TagCount{Tag: "swing", Count: 2}) {
+		t.Fatalf("expected tags most used first, got %+v", got.Tags)
+	}
+	if len(got.Directions) != 2 || len(got.FeeModels) != 2 {
+		t.Fatalf("unexpected fixed options: %+v %+v", got.Directions, got.FeeModels)
+	}
+	if got.Defaults != (formDefaults{EntryDate: "2024-05-06", Direction: "LONG"}) {
+		t.Fatalf("unexpected defaults: %+v", got.Defaults)
+	}
+}
+
+func TestAPITagCloud(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	closed := func(exit float64, tags ...string) *domain.Trade {
+		return &domain.Trade{
+			Instrument: "AAPL",
+			Direction:  domain.DirectionLong,
+			Entry:      domain.EntryDetail{Price: 100, Quantity: 1},
+			Exit:       &domain.ExitDetail{Price: exit, Quantity: 1},
+			Review:     domain.TradeReview{Tags: tags},
+		}
+	}
+	for _, tr := range []*domain.Trade{
+		closed(110, "breakout"),
+		closed(95, "breakout", "fomo"),
+		closed(104, "breakout"),
+	} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/metrics/tag-cloud", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var cloud []tagCloudEntry
+	if err := json.NewDecoder(rec.Body).Decode(&cloud); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(cloud) != 2 {
+		t.Fatalf("expected 2 tags, got %+v", cloud)
+	}
+	if cloud[0].Tag != "breakout" || cloud[0].Count != 3 || cloud[0].Net != 9 || cloud[0].Weight != tagCloudLevels {
+		t.Fatalf("unexpected breakout entry: %+v", cloud[0])
+	}
+	if cloud[1].Tag != "fomo" || cloud[1].Count != 1 || cloud[1].Net != -5 || cloud[1].Weight != 1 {
+		t.Fatalf("unexpected fomo entry: %+v", cloud[1])
+	}
+}
+
+func TestAPISelectionMetrics(t *testing.T) {
+	server, svc := newAPITestServer(t, WithMinSamples(2))
+	stop := 90.0
+	trades := []*domain.Trade{
+		{Instrument: "WIN", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 1, StopLoss: &stop}, Exit: &domain.ExitDetail{Price: 120, Quantity: 1}},
+		{Instrument: "LOSS", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 1, StopLoss: &stop}, Exit: &domain.ExitDetail{Price: 95, Quantity: 1}},
+		{Instrument: "OPEN", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 1}},
+		{Instrument: "IGNORED", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 1}, Exit: &domain.ExitDetail{Price: 200, Quantity: 1}},
+	}
+	for _, tr := range trades {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	body := fmt.Sprintf(`[%q,%q,%q,%q]`, trades[0].ID, trades[1].ID, trades[2].ID, "missing")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/metrics/selection", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got selectionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	m := got.Metrics
+	if m.Total != 3 || m.Closed != 2 || m.Open != 1 || m.Wins != 1 || m.Losses != 1 || m.TotalNet != 15 {
+		t.Fatalf("expected metrics over the selection only, got %+v", m)
+	}
+	if m.WinRate != 50 || m.AvgR != 0.75 || m.MinSamples != 2 {
+		t.Fatalf("unexpected selection statistics: %+v", m)
+	}
+	if len(got.Missing) != 1 || got.Missing[0] != "missing" {
+		t.Fatalf("expected the unknown ID to be reported, got %v", got.Missing)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/metrics/selection", strings.NewReader(`{"ids":[]}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a body that is not an ID array, got %d", rec.Code)
+	}
+}
+
+func TestAPITagCorrelation(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	stop := 90.0
+	closed := func(exit float64, tags ...string) *domain.Trade {
+		return &domain.Trade{
+			Instrument: "AAPL",
+			Direction:  domain.DirectionLong,
+			Entry:      domain.EntryDetail{Price: 100, Quantity: 1, StopLoss: &stop},
+			Exit:       &domain.ExitDetail{Price: exit, Quantity: 1},
+			Review:     domain.TradeReview{Tags: tags},
+		}
+	}
+	for _, tr := range []*domain.Trade{
+		closed(120, "Breakout", "earnings"),
+		closed(110, "breakout", "earnings"),
+		closed(95, "breakout"),
+		closed(105, "breakout"),
+		closed(90, "earnings"),
+		closed(130, "gap"),
+		{Instrument: "OPEN", Entry: domain.EntryDetail{Price: 100, Quantity: 1}, Review: domain.TradeReview{Tags: []string{"breakout", "earnings"}}},
+	} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := get("/api/metrics/tag-correlation?a=breakout&b=Earnings")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got tagCorrelation
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := tagCorrelation{
+		A:     "breakout",
+		B:     "earnings",
+		Both:  tagComboStats{Trades: 2, Wins: 2, WinRate: 100, Expectancy: 1.5, RSamples: 2, TotalNet: 30},
+		AOnly: tagComboStats{Trades: 2, Wins: 1, Losses: 1, WinRate: 50, Expectancy: 0, RSamples: 2, TotalNet: 0},
+		BOnly: tagComboStats{Trades: 1, Losses: 1, Expectancy: -1, RSamples: 1, TotalNet: -10},
+	}
+	if got != want {
+		t.Fatalf("unexpected correlation:\n got %+v\nwant %+v", got, want)
+	}
+
+	rec = get("/api/metrics/tag-correlation?a=gap&b=earnings")
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Both != (tagComboStats{}) || got.AOnly.Trades != 1 || got.BOnly.Trades != 3 {
+		t.Fatalf("expected empty intersection for tags without overlap, got %+v", got)
+	}
+
+	for _, target := range []string{"/api/metrics/tag-correlation?a=breakout", "/api/metrics/tag-correlation?a=gap&b=GAP"} {
+		if rec := get(target); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", target, rec.Code)
+		}
+	}
+}
+
+func TestAPIExtremes(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	closed := func(instrument string, exit float64, stop *float64, held int) *domain.Trade {
+		return &domain.Trade{
+			Instrument: instrument,
+			Direction:  domain.DirectionLong,
+			Entry:      domain.EntryDetail{Date: day, Price: 100, Quantity: 1, StopLoss: stop},
+			Exit:       &domain.ExitDetail{Date: day.AddDate(0, 0, held), Price: exit, Quantity: 1},
+		}
+	}
+	tight, wide := 99.0, 90.0
+	trades := []*domain.Trade{
+		closed("WIN", 120, &wide, 3),    // +20, 2R
+		closed("RSTAR", 105, &tight, 1), // +5, 5R
+		closed("LOSS", 85, &wide, 30),   // -15, -1.5R
+		{Instrument: "OPEN", Entry: domain.EntryDetail{Date: day, Price: 100, Quantity: 1}},
+	}
+	for _, tr := range trades {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/metrics/extremes", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ext tradeExtremes
+	if err := json.NewDecoder(rec.Body).Decode(&ext); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	checks := map[string]*extremeTrade{
+		"WIN":   ext.LargestWinner,
+		"LOSS":  ext.LargestLoser,
+		"RSTAR": ext.BestR,
+	}
+	for want, got := range checks {
+		if got == nil || got.Instrument != want || got.ID == "" {
+			t.Fatalf("expected %s, got %+v", want, got)
+		}
+	}
+	if ext.WorstR == nil || ext.WorstR.Instrument != "LOSS" || ext.LongestHeld == nil || ext.LongestHeld.Instrument != "LOSS" {
+		t.Fatalf("unexpected worst/longest: %+v %+v", ext.WorstR, ext.LongestHeld)
+	}
+}
+
+func TestAPIByHoldTime(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	day := time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)
+	stop := 95.0
+	closed := func(exit float64, held time.Duration) *domain.Trade {
+		return &domain.Trade{
+			Instrument: "ES",
+			Direction:  domain.DirectionLong,
+			Entry:      domain.EntryDetail{Date: day, Price: 100, Quantity: 1, StopLoss: &stop},
+			Exit:       &domain.ExitDetail{Date: day.Add(held), Price: exit, Quantity: 1},
+		}
+	}
+	trades := []*domain.Trade{
+		closed(110, 2*time.Hour),    // intraday, +2R
+		closed(95, 5*time.Hour),     // intraday, -1R
+		closed(105, 16*time.Hour),   // overnight counts as 1 day, +1R
+		closed(90, 20*24*time.Hour), // 10d+, -2R
+		{Instrument: "OPEN", Entry: domain.EntryDetail{Date: day, Price: 100, Quantity: 1}},
+	}
+	for _, tr := range trades {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/metrics/by-hold-time", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var buckets []holdBucket
+	if err := json.NewDecoder(rec.Body).Decode(&buckets); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []holdBucket{
+		{Key: "intraday", Label: "當日", Trades: 2, Wins: 1, Losses: 1, WinRate: 50, AvgR: 0.5},
+		{Key: "1-3d", Label: "1–3 天", Trades: 1, Wins: 1, WinRate: 100, AvgR: 1},
+		{Key: "4-10d", Label: "4–10 天"},
+		{Key: "10d+", Label: "10 天以上", Trades: 1, Losses: 1, AvgR: -2},
+	}
+	if len(buckets) != len(want) {
+		t.Fatalf("expected %d buckets, got %+v", len(want), buckets)
+	}
+	for i := range want {
+		if buckets[i] != want[i] {
+			t.Fatalf("bucket %d: got %+v want %+v", i, buckets[i], want[i])
+		}
+	}
+}
+
+func TestAPICloseTrade(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	tr := &domain.Trade{Instrument: "AAPL", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Price: 100, Quantity: 1}}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	closeTrade := func(target, body string) int {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if code := closeTrade("/api/trades/"+tr.ID+"/exit", `{"date":"2023-12-31T00:00:00Z","price":110}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for exit before entry, got %d", code)
+	}
+	if code := closeTrade("/api/trades/"+tr.ID+"/exit", `{"date":"2024-01-05T00:00:00Z","price":110}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := closeTrade("/api/trades/"+tr.ID+"/exit", `{"price":115}`); code != http.StatusConflict {
+		t.Fatalf("expected 409 for closed trade, got %d", code)
+	}
+	if code := closeTrade("/api/trades/"+tr.ID+"/exit?override=1", `{"price":115}`); code != http.StatusOK {
+		t.Fatalf("expected override to succeed, got %d", code)
+	}
+	if code := closeTrade("/api/trades/missing/exit", `{"price":115}`); code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", code)
+	}
+}
+
+func TestAPIEquitySVG(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, exit := range []float64{110, 95} {
+		tr := &domain.Trade{
+			Instrument: "AAPL",
+			Direction:  domain.DirectionLong,
+			Entry:      domain.EntryDetail{Date: day, Price: 100, Quantity: 1},
+			Exit:       &domain.ExitDetail{Date: day.AddDate(0, 0, i+1), Price: exit, Quantity: 1},
+		}
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/metrics/equity.svg?width=300&height=100", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, "<svg") || !strings.Contains(body, `width="300" height="100"`) {
+		t.Fatalf("unexpected svg: %s", body)
+	}
+	// Equity goes 0 -> 10 -> 5: three points, ending at the middle height.
+	if !strings.Contains(body, `points="10.0,90.0 150.0,10.0 290.0,50.0"`) {
+		t.Fatalf("unexpected polyline: %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/metrics/equity.svg?width=wide", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid width, got %d", rec.Code)
+	}
+}
+
+func TestAPIUpdateValidateOnly(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	tr := &domain.Trade{Instrument: "AAPL", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 1}}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	validate := func(body string) (int, validationResult) {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/trades/"+tr.ID+"?validate=1", strings.NewReader(body)))
+		var result validationResult
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return rec.Code, result
+	}
+
+	if code, result := validate(`{"instrument":" "}`); code != http.StatusBadRequest || result.Valid || len(result.Errors) != 1 {
+		t.Fatalf("expected invalid result, got %d %+v", code, result)
+	}
+	if code, result := validate(`{"instrument":"MSFT","entry":{"price":200,"quantity":1}}`); code != http.StatusOK || !result.Valid {
+		t.Fatalf("expected valid result, got %d %+v", code, result)
+	}
+	stored, err := svc.Get(testContext(), tr.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if stored.Instrument != "AAPL" {
+		t.Fatalf("expected dry run not to persist, got %q", stored.Instrument)
+	}
+}
+
+func TestAPIAndFormRejectTheSameTrades(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	cases := map[string]struct {
+		json string
+		form url.Values
+	}{
+		"zero price": {
+			`{"instrument":"AAPL","entry":{"date":"2024-03-01T00:00:00Z","price":0,"quantity":1}}`,
+			url.Values{"instrument": {"AAPL"}, "direction": {"LONG"}, "entry_date": {"2024-03-01"}, "entry_price": {"0"}, "entry_quantity": {"1"}},
+		},
+		"negative quantity": {
+			`{"instrument":"AAPL","entry":{"date":"2024-03-01T00:00:00Z","price":100,"quantity":-1}}`,
+			url.Values{"instrument": {"AAPL"}, "direction": {"LONG"}, "entry_date": {"2024-03-01"}, "entry_price": {"100"}, "entry_quantity": {"-1"}},
+		},
+		"exit before entry": {
+			`{"instrument":"AAPL","entry":{"date":"2024-03-05T00:00:00Z","price":100,"quantity":1},"exit":{"date":"2024-03-01T00:00:00Z","price":110,"quantity":1}}`,
+			url.Values{"instrument": {"AAPL"}, "direction": {"LONG"}, "entry_date": {"2024-03-05"}, "entry_price": {"100"}, "entry_quantity": {"1"}, "exit_date": {"2024-03-01"}, "exit_price": {"110"}},
+		},
+	}
+	for name, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/trades", strings.NewReader(c.json))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected the API to reject the trade, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+
+		req = httptest.NewRequest(http.MethodPost, "/trades", strings.NewReader(c.form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec = httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected the form to reject the trade, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+	if trades, _ := svc.List(testContext()); len(trades) != 0 {
+		t.Fatalf("expected nothing stored, got %d trades", len(trades))
+	}
+}
+
+func TestAPIRoundsNumericOutput(t *testing.T) {
+	precision, err := ParseAPIPrecision("price=tick,quantity=4,amount=2")
+	if err != nil {
+		t.Fatalf("parse precision: %v", err)
+	}
+	server, svc := newAPITestServer(t, WithAPIPrecision(precision))
+	stop, mark := 95.123456, 191.236
+	tr := &domain.Trade{Instrument: "AAPL", MarkPrice: &mark, Entry: domain.EntryDetail{Quantity: 1.123456789, Fees: 1.005001, StopLoss: &stop, Fills: []domain.Fill{
+		{Price: 190.20000000001, Quantity: 1},
+		{Price: 190.20000000001, Quantity: 0.123456789},
+	}}}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// Configured after the trade was saved, so the stored prices are off tick.
+	tradesvc.WithTickSizes(map[string]float64{"AAPL": 0.01})(svc)
+
+	get := func(server *Server) domain.Trade {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/trades/"+tr.ID, nil))
+		var got domain.Trade
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return got
+	}
+	got := get(server)
+	if got.Entry.Price != 190.2 || *got.Entry.StopLoss != 95.12 || got.Entry.Quantity != 1.1235 || got.Entry.Fees != 1.01 {
+		t.Fatalf("unexpected rounding: %+v", got.Entry)
+	}
+	if *got.MarkPrice != 191.24 || got.Entry.Fills[1].Price != 190.2 || got.Entry.Fills[1].Quantity != 0.1235 {
+		t.Fatalf("expected mark and fill prices rounded like the rest: %v %+v", *got.MarkPrice, got.Entry.Fills)
+	}
+	if stored, _ := svc.Get(testContext(), tr.ID); stored.Entry.Fills[0].Price != 190.20000000001 {
+		t.Fatalf("expected stored trade to keep full precision")
+	}
+
+	plain, _ := newAPITestServer(t)
+	plain.svc = svc
+	if got := get(plain); got.Entry.Fills[1].Quantity != 0.123456789 || got.Entry.Fees != 1.005001 {
+		t.Fatalf("expected no rounding by default, got %+v", got.Entry)
+	}
+
+	for _, raw := range []string{"volume=2", "price=2"} {
+		if _, err := ParseAPIPrecision(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestAPIIncompleteTrades(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	stop := 95.0
+	complete := &domain.Trade{
+		Instrument: "DONE",
+		Setup:      "突破",
+		Entry:      domain.EntryDetail{Price: 100, Quantity: 1, StopLoss: &stop},
+		Exit:       &domain.ExitDetail{Price: 110, Quantity: 1},
+		Review:     domain.TradeReview{OutcomeSummary: "按計畫", Tags: []string{"swing"}},
+	}
+	bare := &domain.Trade{
+		Instrument: "BARE",
+		Entry:      domain.EntryDetail{Price: 100, Quantity: 1},
+		Exit:       &domain.ExitDetail{Price: 90, Quantity: 1},
+	}
+	open := &domain.Trade{
+		Instrument: "OPEN",
+		Setup:      "回檔",
+		Entry:      domain.EntryDetail{Price: 100, Quantity: 1, StopLoss: &stop},
+	}
+	for _, tr := range []*domain.Trade{complete, bare, open} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/trades/incomplete", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report incompleteReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.Total != 2 {
+		t.Fatalf("expected 2 incomplete trades, got %d", report.Total)
+	}
+	want := map[string][]string{
+		"no_stop_loss": {bare.ID},
+		"no_setup":     {bare.ID},
+		"unreviewed":   {bare.ID},
+		"no_tags":      {bare.ID, open.ID},
+	}
+	for _, cat := range report.Categories {
+		ids := want[cat.Key]
+		if cat.Count != len(ids) || len(cat.TradeIDs) != len(ids) {
+			t.Fatalf("%s: expected %v, got %v", cat.Key, ids, cat.TradeIDs)
+		}
+		for _, id := range ids {
+			if !strings.Contains(strings.Join(cat.TradeIDs, ","), id) {
+				t.Fatalf("%s: expected %s in %v", cat.Key, id, cat.TradeIDs)
+			}
+		}
+	}
+}
+
+func TestAPIBatchGet(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	first := &domain.Trade{Instrument: "AAPL"}
+	second := &domain.Trade{Instrument: "MSFT"}
+	gone := &domain.Trade{Instrument: "GONE"}
+	for _, tr := range []*domain.Trade{first, second, gone} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	if err := svc.Delete(testContext(), gone.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	body := `["` + second.ID + `","nope","` + first.ID + `","` + second.ID + `","` + gone.ID + `"]`
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/trades/batch", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp batchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Trades) != 2 || resp.Trades[0].Instrument != "MSFT" || resp.Trades[1].Instrument != "AAPL" {
+		t.Fatalf("expected trades in request order without duplicates, got %+v", resp.Trades)
+	}
+	if len(resp.Missing) != 2 || resp.Missing[0] != "nope" || resp.Missing[1] != gone.ID {
+		t.Fatalf("expected unknown and deleted IDs reported missing, got %v", resp.Missing)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/trades/batch", strings.NewReader(`{"ids":[]}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a non-array body, got %d", rec.Code)
+	}
+}
+
+func TestAPIListFiltersByInstrumentAlias(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository(), tradesvc.WithInstrumentAliases(map[string]string{"TSM": "2330"}))
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	for _, instrument := range []string{"2330", "tsm", "AAPL"} {
+		if err := svc.Create(testContext(), &domain.Trade{Instrument: instrument, Entry: domain.EntryDetail{Price: 1, Quantity: 1}}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/trades?instrument=TSM", nil))
+	var got []domain.Trade
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got) != 2 || got[0].Instrument != "2330" || got[1].Instrument != "2330" {
+		t.Fatalf("expected both 2330 trades for the TSM alias, got %+v", got)
+	}
+}
+
+func TestAPIRecentlyUpdatedTrades(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	first := &domain.Trade{Instrument: "AAPL"}
+	second := &domain.Trade{Instrument: "MSFT"}
+	for _, tr := range []*domain.Trade{first, second} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	pull := func(query string) recentResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/trades/recent"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var resp recentResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	all := pull("")
+	if len(all.Trades) != 2 || all.Trades[0].ID != first.ID || all.Trades[1].ID != second.ID {
+		t.Fatalf("expected both trades oldest first, got %+v", all.Trades)
+	}
+	if !all.NextSince.Equal(all.Trades[1].UpdatedAt) {
+		t.Fatalf("expected next_since to be the newest update, got %v", all.NextSince)
+	}
+
+	if err := svc.Delete(testContext(), first.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	since := "?since=" + url.QueryEscape(all.NextSince.Format(time.RFC3339Nano))
+	changed := pull(since)
+	if len(changed.Trades) != 1 || changed.Trades[0].ID != first.ID || changed.Trades[0].DeletedAt == nil {
+		t.Fatalf("expected only the deleted trade, got %+v", changed.Trades)
+	}
+	if empty := pull("?since=" + url.QueryEscape(changed.NextSince.Format(time.RFC3339Nano))); len(empty.Trades) != 0 || !empty.NextSince.Equal(changed.NextSince) {
+		t.Fatalf("expected no changes and the same cursor, got %+v", empty)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/trades/recent?since=yesterday", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid since, got %d", rec.Code)
+	}
+}
+
+func TestAPIOpenRisk(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	open := func(instrument string, direction domain.Direction, quantity, stop float64) *domain.Trade {
+		return &domain.Trade{
+			Instrument: instrument,
+			Direction:  direction,
+			Entry:      domain.EntryDetail{Date: day, Price: 100, Quantity: quantity, StopLoss: &stop},
+		}
+	}
+	trades := []*domain.Trade{
+		open("AAPL", domain.DirectionLong, 10, 95),   // 50 at risk
+		open("AAPL", domain.DirectionLong, 5, 98),    // 10 at risk
+		open("TSLA", domain.DirectionShort, 10, 104), // 40 at risk
+		{Instrument: "NOSTOP", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: day, Price: 100, Quantity: 1}},
+		{Instrument: "CLOSED", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: day, Price: 100, Quantity: 1}, Exit: &domain.ExitDetail{Date: day, Price: 101, Quantity: 1}},
+	}
+	for _, tr := range trades {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/metrics/open-risk", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var breakdown openRiskBreakdown
+	if err := json.NewDecoder(rec.Body).Decode(&breakdown); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if breakdown.Total != 100 {
+		t.Fatalf("expected 100 total open risk, got %v", breakdown.Total)
+	}
+	if len(breakdown.ByInstrument) != 3 {
+		t.Fatalf("expected three open instruments, got %+v", breakdown.ByInstrument)
+	}
+	if top := breakdown.ByInstrument[0]; top.Key != "AAPL" || top.Trades != 2 || top.Risk != 60 || top.SharePct != 60 {
+		t.Fatalf("unexpected largest instrument: %+v", top)
+	}
+	if last := breakdown.ByInstrument[2]; last.Key != "NOSTOP" || last.Trades != 1 || last.Risk != 0 {
+		t.Fatalf("expected the trade without a stop counted without risk, got %+v", last)
+	}
+	if len(breakdown.ByDirection) != 2 || breakdown.ByDirection[0].Key != "LONG" || breakdown.ByDirection[0].Risk != 60 || breakdown.ByDirection[1].SharePct != 40 {
+		t.Fatalf("unexpected direction breakdown: %+v", breakdown.ByDirection)
+	}
+
+	index := httptest.NewRecorder()
+	server.Handler().ServeHTTP(index, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(index.Body.String(), "未平倉風險分布") {
+		t.Fatalf("expected the open risk breakdown on the dashboard")
+	}
+}
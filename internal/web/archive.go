@@ -0,0 +1,45 @@
+package web
+
+import (
+	"context"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// Archived filter values accepted by the ?archived= query parameter. The empty
+// value hides archived trades.
+const (
+	archivedInclude = "include"
+	archivedOnly    = "only"
+)
+
+// WithArchivedInStats makes the dashboard statistics count archived trades
+// even when the list hides them.
+func WithArchivedInStats(include bool) Option {
+	return func(s *Server) {
+		s.archivedInStats = include
+	}
+}
+
+// listTrades loads the trades the archived filter may need: archived trades are
+// only read when they are shown or counted in the statistics.
+func (s *Server) listTrades(ctx context.Context, filters indexFilters) ([]*domain.Trade, error) {
+	if filters.Archived == "" && !s.archivedInStats {
+		return s.svc.List(ctx)
+	}
+	return s.svc.ListWithArchived(ctx)
+}
+
+// filterArchived applies the archived filter mode to trades.
+func filterArchived(trades []*domain.Trade, mode string) []*domain.Trade {
+	if mode == archivedInclude {
+		return trades
+	}
+	kept := make([]*domain.Trade, 0, len(trades))
+	for _, tr := range trades {
+		if tr.IsArchived() == (mode == archivedOnly) {
+			kept = append(kept, tr)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,110 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+	"best_trade_logs/internal/storage"
+)
+
+// Share card geometry, sized for social media link previews.
+const (
+	cardWidth   = 600
+	cardHeight  = 315
+	cardPadding = 32
+)
+
+var (
+	cardBackground = color.RGBA{R: 0x0f, G: 0x17, B: 0x2a, A: 0xff}
+	cardText       = color.RGBA{R: 0xf8, G: 0xfa, B: 0xfc, A: 0xff}
+	cardMuted      = color.RGBA{R: 0x94, G: 0xa3, B: 0xb8, A: 0xff}
+	cardPositive   = color.RGBA{R: 0x22, G: 0xc5, B: 0x5e, A: 0xff}
+	cardNegative   = color.RGBA{R: 0xef, G: 0x44, B: 0x44, A: 0xff}
+)
+
+// handleTradeCard renders a PNG summary of a trade. Absolute amounts are left
+// out unless ?amounts=1 is given.
+func (s *Server) handleTradeCard(w http.ResponseWriter, r *http.Request, id string) {
+	tr, err := s.svc.Get(r.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, renderTradeCard(tr, r.URL.Query().Get("amounts") == "1", s.rPrecision)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	buf.WriteTo(w)
+}
+
+// renderTradeCard lays out instrument, direction, R multiple and return; the
+// accent bar follows the sign of the result.
+func renderTradeCard(tr *domain.Trade, showAmounts bool, rPrecision int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	fillRect(img, 0, 0, cardWidth, cardHeight, cardBackground)
+
+	metrics := buildTradeMetrics(tr, "")
+	accent := cardMuted
+	switch {
+	case tr.HasExited() && metrics.Net > 0:
+		accent = cardPositive
+	case tr.HasExited() && metrics.Net < 0:
+		accent = cardNegative
+	}
+	fillRect(img, 0, 0, 12, cardHeight, accent)
+
+	x := cardPadding + 12
+	drawText(img, x, cardPadding, 8, cardInstrument(tr.Instrument), cardText)
+
+	status := "OPEN"
+	if tr.HasExited() {
+		status = "CLOSED"
+	}
+	drawText(img, x, cardPadding+72, 3, fmt.Sprintf("%s  %s", tr.Direction, status), cardMuted)
+
+	y := cardPadding + 120
+	if tr.HasDefinedRisk() {
+		drawText(img, x, y, 6, fmt.Sprintf("%+.*fR", rPrecision, metrics.RMultiple), accent)
+		y += 56
+	}
+	drawText(img, x, y, 5, fmt.Sprintf("%+.2f%%", metrics.NetPercent), accent)
+	if showAmounts {
+		amount := fmt.Sprintf("%+.2f", metrics.Net)
+		if tr.Currency != "" {
+			amount += " " + tr.Currency
+		}
+		drawText(img, x, y+45, 3, amount, cardMuted)
+	}
+
+	footer := "BEST TRADE LOGS"
+	if !tr.Entry.Date.IsZero() {
+		footer = tr.Entry.Date.Format("2006-01-02") + "  " + footer
+	}
+	drawText(img, cardWidth-cardPadding-textWidth(footer, 2), cardHeight-20-glyphHeight*2, 2, footer, cardMuted)
+	return img
+}
+
+// cardInstrument truncates long instrument names so they fit the card at the
+// headline size.
+func cardInstrument(instrument string) string {
+	const maxRunes = 10
+	runes := []rune(strings.TrimSpace(instrument))
+	if len(runes) > maxRunes {
+		return string(runes[:maxRunes-1]) + "."
+	}
+	return string(runes)
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// convictionMetrics compares closed trades at their actual size with the same
+// trades re-weighted by the confidence recorded before entry. Weights are the
+// confidence divided by the average confidence, so a trade of average conviction
+// counts once and the weighted total stays comparable to the flat one.
+type convictionMetrics struct {
+	// Samples counts closed trades with a positive ConfidenceBefore; only those
+	// contribute to either side of the comparison.
+	Samples         int
+	TotalNet        float64
+	WeightedNet     float64
+	WinRate         float64
+	WeightedWinRate float64
+}
+
+// summarizeTradesByConviction is summarizeTrades weighted by ConfidenceBefore.
+func summarizeTradesByConviction(trades []*domain.Trade, now time.Time, epsilon float64) convictionMetrics {
+	return summarizeConviction(buildTradeSummaries(trades, now, epsilon))
+}
+
+func summarizeConviction(rows []tradeSummary) convictionMetrics {
+	var metrics convictionMetrics
+	var confidenceTotal float64
+	for _, row := range rows {
+		if row.IsOpen || row.ConfidenceBefore == nil || *row.ConfidenceBefore <= 0 {
+			continue
+		}
+		metrics.Samples++
+		confidenceTotal += *row.ConfidenceBefore
+	}
+	if metrics.Samples == 0 {
+		return metrics
+	}
+	mean := confidenceTotal / float64(metrics.Samples)
+
+	var wins, decided int
+	var winWeight, decidedWeight float64
+	var net, weightedNet domain.Money
+	for _, row := range rows {
+		if row.IsOpen || row.ConfidenceBefore == nil || *row.ConfidenceBefore <= 0 {
+			continue
+		}
+		weight := *row.ConfidenceBefore / mean
+		net += domain.ToMoney(row.NetResult)
+		weightedNet += domain.ToMoney(row.NetResult * weight)
+		switch row.Outcome {
+		case domain.OutcomeWin:
+			wins++
+			decided++
+			winWeight += weight
+			decidedWeight += weight
+		case domain.OutcomeLoss:
+			decided++
+			decidedWeight += weight
+		}
+	}
+	metrics.TotalNet = net.Float64()
+	metrics.WeightedNet = weightedNet.Float64()
+	if decided > 0 {
+		metrics.WinRate = float64(wins) / float64(decided) * 100
+		metrics.WeightedWinRate = winWeight / decidedWeight * 100
+	}
+	return metrics
+}
@@ -0,0 +1,87 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// corsConfig lists what cross-origin callers of the API may do. With no allowed
+// origins the API stays same-origin only.
+type corsConfig struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+}
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization"}
+)
+
+// WithCORS allows the listed origins ("*" for any) to call the /api routes.
+// Empty method or header lists fall back to sensible defaults.
+func WithCORS(origins, methods, headers []string) Option {
+	return func(s *Server) {
+		s.cors = corsConfig{
+			AllowedOrigins: cleanList(origins),
+			AllowedMethods: cleanList(methods),
+			AllowedHeaders: cleanList(headers),
+		}
+		if len(s.cors.AllowedMethods) == 0 {
+			s.cors.AllowedMethods = defaultCORSMethods
+		}
+		if len(s.cors.AllowedHeaders) == 0 {
+			s.cors.AllowedHeaders = defaultCORSHeaders
+		}
+	}
+}
+
+func (c corsConfig) allowOrigin(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// withCORS applies the CORS policy and answers preflight requests.
+func (s *Server) withCORS(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if origin == "" {
+			next(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		if !s.cors.allowOrigin(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if preflight {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(s.cors.AllowedMethods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(s.cors.AllowedHeaders, ", "))
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(600))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func cleanList(values []string) []string {
+	var cleaned []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			cleaned = append(cleaned, v)
+		}
+	}
+	return cleaned
+}
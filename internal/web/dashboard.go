@@ -0,0 +1,275 @@
+package web
+
+import (
+	"fmt"
+	"strings"
+
+	"best_trade_logs/internal/web/templates"
+)
+
+// dashboardPanel is one rendered stat card on the index page.
+type dashboardPanel struct {
+	Key        string
+	Label      string
+	Value      string
+	ValueClass string
+	Meta       string
+}
+
+// dashboardPanelBuilders renders each known panel from the index view data, in
+// the default order.
+var dashboardPanelBuilders = []struct {
+	key   string
+	build func(d dashboardView) dashboardPanel
+}{
+	{"trades", tradesPanel},
+	{"win_rate", winRatePanel},
+	{"breakeven_win_rate", breakevenWinRatePanel},
+	{"avg_r", avgRPanel},
+	{"avg_return", avgReturnPanel},
+	{"sharpe", sharpePanel},
+	{"hold_days", holdDaysPanel},
+	{"hold_plan", holdPlanPanel},
+	{"plan_capture", planCapturePanel},
+	{"total_net", totalNetPanel},
+	{"open_pnl", openPnLPanel},
+	{"risk_usage", riskUsagePanel},
+	{"slippage", slippagePanel},
+	{"conviction", convictionPanel},
+	{"plan_adherence", planAdherencePanel},
+}
+
+// dashboardView is the subset of index data the panels need.
+type dashboardView struct {
+	Metrics       dashboardMetrics
+	Conviction    convictionMetrics
+	Adherence     planAdherence
+	Sharpe        sharpeMetrics
+	HoldPlan      holdPlanMetrics
+	PlanCapture   planCaptureMetrics
+	RPrecision    int
+	VisibleTrades int
+	TotalTrades   int
+	// Rows are the rows behind the stats, for panels too costly to compute
+	// unless they are shown.
+	Rows []tradeSummary
+}
+
+// WithDashboardMetrics selects which dashboard panels render and in which order.
+// An empty list keeps every panel in the default order; unknown keys make
+// NewServer fail.
+func WithDashboardMetrics(keys []string) Option {
+	return func(s *Server) {
+		s.dashboardMetrics = cleanList(keys)
+	}
+}
+
+// DashboardMetricKeys lists the panel keys accepted by WithDashboardMetrics.
+func DashboardMetricKeys() []string {
+	keys := make([]string, len(dashboardPanelBuilders))
+	for i, b := range dashboardPanelBuilders {
+		keys[i] = b.key
+	}
+	return keys
+}
+
+func validateDashboardMetrics(keys []string) error {
+	for _, key := range keys {
+		if !containsKey(DashboardMetricKeys(), key) {
+			return fmt.Errorf("unknown dashboard metric %q (known: %s)", key, strings.Join(DashboardMetricKeys(), ", "))
+		}
+	}
+	return nil
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// dashboardPanels renders the configured panels in order.
+func (s *Server) dashboardPanels(view dashboardView) []dashboardPanel {
+	keys := s.dashboardMetrics
+	if len(keys) == 0 {
+		keys = DashboardMetricKeys()
+	}
+	panels := make([]dashboardPanel, 0, len(keys))
+	for _, key := range keys {
+		for _, b := range dashboardPanelBuilders {
+			if b.key == key {
+				panel := b.build(view)
+				panel.Key = key
+				panels = append(panels, panel)
+			}
+		}
+	}
+	return panels
+}
+
+func signClass(v float64) string {
+	switch {
+	case v > 0:
+		return "text-positive"
+	case v < 0:
+		return "text-negative"
+	}
+	return ""
+}
+
+func tradesPanel(d dashboardView) dashboardPanel {
+	meta := fmt.Sprintf("%d 筆未平倉 • %d 筆已平倉", d.Metrics.Open, d.Metrics.Closed)
+	if d.VisibleTrades < d.TotalTrades {
+		meta += fmt.Sprintf(" · 共 %d 筆紀錄", d.TotalTrades)
+	}
+	return dashboardPanel{Label: "符合條件的交易", Value: fmt.Sprint(d.VisibleTrades), Meta: meta}
+}
+
+func winRatePanel(d dashboardView) dashboardPanel {
+	m := d.Metrics
+	value := "—"
+	if m.Wins+m.Losses > 0 && m.Enough(statWinRate) {
+		value = fmt.Sprintf("%.1f%%", m.WinRate)
+	}
+	meta := fmt.Sprintf("%d 勝 / %d 敗", m.Wins, m.Losses)
+	if !m.Enough(statWinRate) {
+		meta += " · " + insufficientMeta(m)
+	}
+	if m.Breakeven > 0 {
+		meta += fmt.Sprintf(" · %d 筆損益兩平不計入", m.Breakeven)
+	}
+	return dashboardPanel{Label: "勝率", Value: value, Meta: meta}
+}
+
+func breakevenWinRatePanel(d dashboardView) dashboardPanel {
+	m := d.Metrics
+	panel := dashboardPanel{Label: "損益兩平勝率", Value: "—", Meta: "需同時有獲利與虧損的已平倉交易"}
+	if !m.Enough(statBreakevenWinRate) {
+		panel.Meta = insufficientMeta(m)
+	} else if m.BreakevenWinRate > 0 {
+		margin := m.WinRate - m.BreakevenWinRate
+		panel.Value = fmt.Sprintf("%.1f%%", m.BreakevenWinRate)
+		panel.ValueClass = signClass(margin)
+		panel.Meta = fmt.Sprintf("實際 %.1f%% · 安全邊際 %+.1f 個百分點（平均獲利 %.2f / 平均虧損 %.2f）", m.WinRate, margin, m.AvgWin, m.AvgLoss)
+	}
+	return panel
+}
+
+func avgRPanel(d dashboardView) dashboardPanel {
+	if !d.Metrics.Enough(statAvgR) {
+		return dashboardPanel{Label: "平均 R 倍數", Value: "—", Meta: insufficientMeta(d.Metrics)}
+	}
+	return dashboardPanel{Label: "平均 R 倍數", Value: templates.FormatR(d.Metrics.AvgR, d.RPrecision), Meta: expectancyMeta(summarizeExpectancyInterval(d.Rows), d.RPrecision)}
+}
+
+func avgReturnPanel(d dashboardView) dashboardPanel {
+	if !d.Metrics.Enough(statAvgReturn) {
+		return dashboardPanel{Label: "平均報酬率", Value: "—", Meta: insufficientMeta(d.Metrics)}
+	}
+	value := "—"
+	if d.Metrics.Closed > 0 {
+		value = fmt.Sprintf("%.2f%%", d.Metrics.AvgReturnPct)
+	}
+	return dashboardPanel{Label: "平均報酬率", Value: value, Meta: "相對資金曝險的淨報酬"}
+}
+
+func holdDaysPanel(d dashboardView) dashboardPanel {
+	if !d.Metrics.Enough(statHoldDays) {
+		return dashboardPanel{Label: "平均持有天數", Value: "—", Meta: insufficientMeta(d.Metrics)}
+	}
+	return dashboardPanel{Label: "平均持有天數", Value: fmt.Sprintf("%.1f", d.Metrics.AvgHoldDays), Meta: "自進場至出場的天數"}
+}
+
+func totalNetPanel(d dashboardView) dashboardPanel {
+	return dashboardPanel{
+		Label:      "已實現淨損益",
+		Value:      fmt.Sprintf("%.2f", d.Metrics.TotalNet),
+		ValueClass: signClass(d.Metrics.TotalNet),
+		Meta:       fmt.Sprintf("%d 筆已平倉交易", d.Metrics.Closed),
+	}
+}
+
+func openPnLPanel(d dashboardView) dashboardPanel {
+	m := d.Metrics
+	panel := dashboardPanel{Label: "未實現損益", Value: "—", Meta: fmt.Sprintf("未實現風險：%.2f", m.OpenRisk)}
+	if m.MarkedOpen > 0 {
+		panel.Value = fmt.Sprintf("%.2f", m.OpenUnrealizedNet)
+		panel.ValueClass = signClass(m.OpenUnrealizedNet)
+	}
+	if m.Open > 0 {
+		panel.Meta = fmt.Sprintf("%d / %d 筆未平倉有最新參考價 · 已付費用：%.2f · %s", m.MarkedOpen, m.Open, m.OpenFees, panel.Meta)
+	}
+	return panel
+}
+
+func riskUsagePanel(d dashboardView) dashboardPanel {
+	m := d.Metrics
+	panel := dashboardPanel{Label: "實際風險 / 計畫風險", Value: "—", Meta: "需同時設定停損與最大風險"}
+	if m.RiskSamples > 0 && !m.Enough(statRiskUsage) {
+		panel.Meta = insufficientMeta(m)
+	} else if m.RiskSamples > 0 {
+		panel.Value = fmt.Sprintf("%.1f%%", m.RiskUsagePct)
+		panel.Meta = fmt.Sprintf("平均實際 %.2f vs 計畫 %.2f（%d 筆）", m.AvgRiskTaken, m.AvgRiskPlanned, m.RiskSamples)
+		if m.RiskUsagePct > 100 {
+			panel.ValueClass = "text-negative"
+		}
+	}
+	return panel
+}
+
+func slippagePanel(d dashboardView) dashboardPanel {
+	m := d.Metrics
+	panel := dashboardPanel{Label: "進場滑價成本", Value: "—", Meta: "需填寫計畫進場價"}
+	if m.SlippageSamples > 0 {
+		panel.Value = fmt.Sprintf("%.2f", m.TotalSlippage)
+		panel.ValueClass = signClass(-m.TotalSlippage)
+		panel.Meta = fmt.Sprintf("%d 筆有計畫進場價", m.SlippageSamples)
+	}
+	return panel
+}
+
+func convictionPanel(d dashboardView) dashboardPanel {
+	c := d.Conviction
+	panel := dashboardPanel{Label: "信心加權淨損益", Value: "—", Meta: "需填寫進場前信心"}
+	if c.Samples > 0 {
+		panel.Value = fmt.Sprintf("%.2f", c.WeightedNet)
+		panel.ValueClass = signClass(c.WeightedNet)
+		panel.Meta = fmt.Sprintf("等額 %.2f · 勝率 %.1f%% vs 加權 %.1f%%（%d 筆）", c.TotalNet, c.WinRate, c.WeightedWinRate, c.Samples)
+	}
+	return panel
+}
+
+func planAdherencePanel(d dashboardView) dashboardPanel {
+	a := d.Adherence
+	panel := dashboardPanel{Label: "依計畫 vs 偏離計畫", Value: "—", Meta: "需在回顧中填寫是否依計畫執行"}
+	if a.Samples() > 0 {
+		panel.Value = fmt.Sprintf("%s / %s", adherenceAvgR(a.Followed, d.RPrecision), adherenceAvgR(a.Deviated, d.RPrecision))
+		if a.Followed.Enough(statAvgR) && a.Deviated.Enough(statAvgR) {
+			panel.ValueClass = signClass(a.Followed.AvgR - a.Deviated.AvgR)
+		}
+		panel.Meta = fmt.Sprintf("平均 R · 勝率 %s vs %s（%d / %d 筆）", adherenceWinRate(a.Followed), adherenceWinRate(a.Deviated), a.Followed.Closed, a.Deviated.Closed)
+	}
+	return panel
+}
+
+func adherenceAvgR(m dashboardMetrics, precision int) string {
+	if !m.Enough(statAvgR) {
+		return "—"
+	}
+	return templates.FormatR(m.AvgR, precision)
+}
+
+func adherenceWinRate(m dashboardMetrics) string {
+	if m.Wins+m.Losses == 0 || !m.Enough(statWinRate) {
+		return "—"
+	}
+	return fmt.Sprintf("%.1f%%", m.WinRate)
+}
+
+// insufficientMeta explains why a statistic is hidden.
+func insufficientMeta(m dashboardMetrics) string {
+	return fmt.Sprintf("樣本不足（至少需 %d 筆）", m.MinSamples)
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"sort"
+
+	domain "best_trade_logs/internal/domain/trade"
+	tradesvc "best_trade_logs/internal/service/trade"
+)
+
+// exitReasonUnset groups closed trades that were closed without a reason.
+const exitReasonUnset = "未填原因"
+
+// exitReasonStats is the performance of the closed trades sharing one exit
+// reason.
+type exitReasonStats struct {
+	Reason  string
+	Metrics dashboardMetrics
+}
+
+func exitReasonKey(tr *domain.Trade) []string {
+	if tr.Exit == nil {
+		return nil
+	}
+	if reason := tradesvc.NormalizeExitReason(tr.Exit.Reason); reason != "" {
+		return []string{reason}
+	}
+	return []string{exitReasonUnset}
+}
+
+// exitReasonBreakdown groups closed trades by their normalized exit reason,
+// most used reason first.
+func exitReasonBreakdown(rows []tradeSummary, minSamples int) []exitReasonStats {
+	closed := make([]tradeSummary, 0, len(rows))
+	for _, row := range rows {
+		if !row.IsOpen {
+			closed = append(closed, row)
+		}
+	}
+	groups := groupTrades(closed, exitReasonKey)
+	stats := make([]exitReasonStats, 0, len(groups))
+	for _, g := range groups {
+		stats = append(stats, exitReasonStats{Reason: g.Key, Metrics: g.Metrics.withMinSamples(minSamples)})
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Metrics.Closed > stats[j].Metrics.Closed
+	})
+	return stats
+}
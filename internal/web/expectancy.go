@@ -0,0 +1,142 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"best_trade_logs/internal/web/templates"
+)
+
+// Bootstrap settings for the expectancy interval. The generator is seeded with
+// a constant so the same trades always show the same interval.
+const (
+	bootstrapResamples = 2000
+	bootstrapSeed      = 2024
+	// expectancyConfidence is the coverage of the interval and expectancyZ the
+	// matching normal quantile.
+	expectancyConfidence = 0.95
+	expectancyZ          = 1.96
+	// bootstrapMaxSamples bounds the bootstrap's cost, resamples × samples
+	// draws. Larger samples use the standard-error interval, which the
+	// bootstrap converges to anyway.
+	bootstrapMaxSamples = 500
+)
+
+// expectancyInterval is a confidence interval around the mean R-multiple of
+// the closed trades with a defined risk. Up to bootstrapMaxSamples it is a
+// percentile bootstrap: the R-multiples are resampled with replacement and the
+// interval spans the middle expectancyConfidence of the resampled means.
+type expectancyInterval struct {
+	Samples int
+	Mean    float64
+	Low     float64
+	High    float64
+	// HasInterval is false with fewer than two samples.
+	HasInterval bool
+}
+
+// HalfWidth is half the interval's width.
+func (e expectancyInterval) HalfWidth() float64 {
+	return (e.High - e.Low) / 2
+}
+
+// Significant reports whether the whole interval lies on one side of zero.
+func (e expectancyInterval) Significant() bool {
+	return e.HasInterval && (e.Low > 0 || e.High < 0)
+}
+
+func summarizeExpectancyInterval(rows []tradeSummary) expectancyInterval {
+	var rs []float64
+	for _, row := range rows {
+		if !row.IsOpen && row.TotalRisk > 0 {
+			rs = append(rs, row.RMultiple)
+		}
+	}
+	if len(rs) > bootstrapMaxSamples {
+		return standardErrorInterval(rs)
+	}
+	return bootstrapMeanInterval(rs, bootstrapResamples, rand.New(rand.NewPCG(bootstrapSeed, bootstrapSeed)))
+}
+
+// standardErrorInterval is the normal approximation mean ± z·s/√n.
+func standardErrorInterval(values []float64) expectancyInterval {
+	interval := expectancyInterval{Samples: len(values)}
+	if len(values) == 0 {
+		return interval
+	}
+	interval.Mean = mean(values)
+	if len(values) < 2 {
+		return interval
+	}
+	var squares float64
+	for _, v := range values {
+		squares += (v - interval.Mean) * (v - interval.Mean)
+	}
+	halfWidth := expectancyZ * math.Sqrt(squares/float64(len(values)-1)/float64(len(values)))
+	interval.Low = interval.Mean - halfWidth
+	interval.High = interval.Mean + halfWidth
+	interval.HasInterval = true
+	return interval
+}
+
+// bootstrapMeanInterval resamples values with replacement and returns the
+// percentile interval of the resampled means.
+func bootstrapMeanInterval(values []float64, resamples int, rng *rand.Rand) expectancyInterval {
+	interval := expectancyInterval{Samples: len(values)}
+	if len(values) == 0 {
+		return interval
+	}
+	interval.Mean = mean(values)
+	if len(values) < 2 || resamples < 1 {
+		return interval
+	}
+	means := make([]float64, resamples)
+	for i := range means {
+		var sum float64
+		for range values {
+			sum += values[rng.IntN(len(values))]
+		}
+		means[i] = sum / float64(len(values))
+	}
+	sort.Float64s(means)
+	tail := (1 - expectancyConfidence) / 2
+	interval.Low = quantileSorted(means, tail)
+	interval.High = quantileSorted(means, 1-tail)
+	interval.HasInterval = true
+	return interval
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// quantileSorted linearly interpolates the q-th quantile of sorted values.
+func quantileSorted(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(pos)
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	frac := pos - float64(lower)
+	return sorted[lower] + frac*(sorted[lower+1]-sorted[lower])
+}
+
+// expectancyMeta describes the interval for the average R panel. It shows the
+// bounds rather than a "±" because the bootstrap interval need not be
+// symmetric around the mean.
+func expectancyMeta(e expectancyInterval, precision int) string {
+	if !e.HasInterval {
+		return "僅計入已平倉部位"
+	}
+	meta := fmt.Sprintf("95%% 信賴區間 %s～%s", templates.FormatR(e.Low, precision), templates.FormatR(e.High, precision))
+	if !e.Significant() {
+		meta += "，區間包含 0，期望值可能只是雜訊"
+	}
+	return meta
+}
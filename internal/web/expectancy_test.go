@@ -0,0 +1,116 @@
+package web
+
+import (
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+func TestBootstrapMeanIntervalMatchesStandardError(t *testing.T) {
+	// For a large sample the percentile bootstrap interval of the mean
+	// approaches mean ± 1.96·s/√n.
+	sampler := rand.New(rand.NewPCG(1, 2))
+	normal := make([]float64, 400)
+	for i := range normal {
+		normal[i] = 0.5 + sampler.NormFloat64()
+	}
+	twoPoint := make([]float64, 100)
+	for i := range twoPoint {
+		twoPoint[i] = -1
+		if i%2 == 0 {
+			twoPoint[i] = 2
+		}
+	}
+
+	for name, values := range map[string][]float64{"normal": normal, "two-point": twoPoint} {
+		got := bootstrapMeanInterval(values, 4000, rand.New(rand.NewPCG(3, 4)))
+		m := mean(values)
+		var squares float64
+		for _, v := range values {
+			squares += (v - m) * (v - m)
+		}
+		want := 1.96 * math.Sqrt(squares/float64(len(values)-1)) / math.Sqrt(float64(len(values)))
+		if !got.HasInterval || got.Mean != m {
+			t.Fatalf("%s: unexpected interval %+v", name, got)
+		}
+		if math.Abs(got.HalfWidth()-want)/want > 0.1 {
+			t.Fatalf("%s: half width %.4f, want about %.4f", name, got.HalfWidth(), want)
+		}
+		if got.Low > m || got.High < m {
+			t.Fatalf("%s: interval %.4f–%.4f does not contain the mean %.4f", name, got.Low, got.High, m)
+		}
+	}
+}
+
+func TestBootstrapMeanIntervalEdgeCases(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 1))
+	if got := bootstrapMeanInterval(nil, 100, rng); got.HasInterval || got.Samples != 0 {
+		t.Fatalf("expected no interval without samples, got %+v", got)
+	}
+	if got := bootstrapMeanInterval([]float64{1.5}, 100, rng); got.HasInterval || got.Mean != 1.5 {
+		t.Fatalf("expected a single sample to give its mean without an interval, got %+v", got)
+	}
+	if got := bootstrapMeanInterval([]float64{2, 2, 2}, 100, rng); !got.HasInterval || got.Low != 2 || got.High != 2 {
+		t.Fatalf("expected a constant sample to give a zero-width interval, got %+v", got)
+	}
+}
+
+func TestExpectancyIntervalOnDashboard(t *testing.T) {
+	stop := 95.0
+	closed := func(exit float64) *domain.Trade {
+		return &domain.Trade{
+			Direction: domain.DirectionLong,
+			Entry:     domain.EntryDetail{Price: 100, Quantity: 1, StopLoss: &stop},
+			Exit:      &domain.ExitDetail{Price: exit, Quantity: 1},
+		}
+	}
+	var noisy, steady []*domain.Trade
+	for _, exit := range []float64{110, 95, 95, 110, 95} { // 2R and -1R
+		noisy = append(noisy, closed(exit))
+	}
+	for i := 0; i < 30; i++ {
+		steady = append(steady, closed(106+float64(i%3))) // 1.2R to 1.6R
+	}
+
+	noisyRows := buildTradeSummaries(noisy, time.Now(), domain.DefaultBreakevenEpsilon)
+	first := summarizeExpectancyInterval(noisyRows)
+	if first != summarizeExpectancyInterval(noisyRows) {
+		t.Fatalf("expected the interval to be reproducible")
+	}
+	if first.Samples != 5 || first.Significant() {
+		t.Fatalf("expected a noisy sample to straddle zero, got %+v", first)
+	}
+	if meta := expectancyMeta(first, 2); !strings.Contains(meta, "95% 信賴區間") || !strings.Contains(meta, "區間包含 0") {
+		t.Fatalf("unexpected meta for a noisy sample: %q", meta)
+	}
+
+	interval := summarizeExpectancyInterval(buildTradeSummaries(steady, time.Now(), domain.DefaultBreakevenEpsilon))
+	if !interval.Significant() || interval.Low < 1.2 || interval.High > 1.6 {
+		t.Fatalf("expected a steady edge to be significant, got %+v", interval)
+	}
+	if meta := expectancyMeta(interval, 2); strings.Contains(meta, "區間包含 0") || !strings.HasPrefix(meta, "95% 信賴區間 1.") {
+		t.Fatalf("unexpected meta for a steady edge: %q", meta)
+	}
+}
+
+func TestExpectancyIntervalFallsBackToStandardError(t *testing.T) {
+	rows := make([]tradeSummary, bootstrapMaxSamples+1)
+	for i := range rows {
+		rows[i] = tradeSummary{TotalRisk: 1, RMultiple: float64(i%2)*3 - 1} // -1R and 2R
+	}
+	got := summarizeExpectancyInterval(rows)
+	values := make([]float64, len(rows))
+	for i, row := range rows {
+		values[i] = row.RMultiple
+	}
+	if want := standardErrorInterval(values); got != want {
+		t.Fatalf("expected the standard-error interval above %d samples, got %+v want %+v", bootstrapMaxSamples, got, want)
+	}
+	if math.Abs(got.Mean+got.HalfWidth()-got.High) > 1e-12 || !got.Significant() {
+		t.Fatalf("expected a symmetric interval above zero, got %+v", got)
+	}
+}
@@ -0,0 +1,190 @@
+package web
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// Decimal separators accepted by WithExportDecimal and the CSV export's
+// ?decimal= parameter. With ExportDecimalComma numbers use a decimal comma and
+// fields are separated by semicolons, as spreadsheets in those locales expect.
+const (
+	ExportDecimalPoint = "point"
+	ExportDecimalComma = "comma"
+)
+
+// WithExportDecimal sets the default decimal separator of the CSV export.
+func WithExportDecimal(mode string) Option {
+	return func(s *Server) {
+		s.exportDecimal = strings.ToLower(strings.TrimSpace(mode))
+	}
+}
+
+var exportHeader = []string{
+	"id",
+	"instrument",
+	"market",
+	"account",
+	"direction",
+	"setup",
+	"entry_date",
+	"entry_price",
+	"entry_quantity",
+	"entry_fees",
+	"stop_loss",
+	"target",
+	"exit_date",
+	"exit_price",
+	"exit_quantity",
+	"exit_fees",
+	"exit_reason",
+	"net_result",
+	"result_percent",
+	"r_multiple",
+	"tags",
+	"financing_cost",
+	"is_paper",
+}
+
+func (s *Server) handleExportCSV(w http.ResponseWriter, r *http.Request) {
+	decimal := s.exportDecimal
+	if raw := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("decimal"))); raw != "" {
+		decimal = raw
+	}
+	if decimal != ExportDecimalPoint && decimal != ExportDecimalComma {
+		http.Error(w, fmt.Sprintf("unknown decimal separator %q", decimal), http.StatusBadRequest)
+		return
+	}
+	decimalComma := decimal == ExportDecimalComma
+	trades, ok := s.exportTrades(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", exportDisposition("csv", s.svc.Now()))
+	writer := csv.NewWriter(w)
+	if decimalComma {
+		writer.Comma = ';'
+	}
+	if err := writer.Write(exportHeader); err != nil {
+		log.Printf("csv export write error: %v", err)
+		return
+	}
+	for _, tr := range trades {
+		if err := writer.Write(exportRecord(tr, decimalComma)); err != nil {
+			log.Printf("csv export write error: %v", err)
+			return
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("csv export flush error: %v", err)
+	}
+}
+
+func (s *Server) handleExportJSON(w http.ResponseWriter, r *http.Request) {
+	trades, ok := s.exportTrades(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Disposition", exportDisposition("json", s.svc.Now()))
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(trades); err != nil {
+		log.Printf("json export write error: %v", err)
+	}
+}
+
+// exportTrades loads the trades and narrows them with the same filters used by the index.
+func (s *Server) exportTrades(w http.ResponseWriter, r *http.Request) ([]*domain.Trade, bool) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	filters := s.indexFilters(r)
+	trades, err := s.listTrades(r.Context(), filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	filtered := applyIndexFilters(filterArchived(trades, filters.Archived), filters, s.breakevenEpsilon)
+	if filtered == nil {
+		filtered = []*domain.Trade{}
+	}
+	return filtered, true
+}
+
+func exportDisposition(ext string, now time.Time) string {
+	return fmt.Sprintf("attachment; filename=\"trades-%s.%s\"", now.Format("20060102"), ext)
+}
+
+// exportRecord lays a trade out in exportHeader order, with decimal commas in
+// numbers when decimalComma is set.
+func exportRecord(tr *domain.Trade, decimalComma bool) []string {
+	record := []string{
+		tr.ID,
+		tr.Instrument,
+		tr.Market,
+		tr.Account,
+		string(tr.Direction),
+		tr.Setup,
+		formatExportDate(tr.Entry.Date),
+		formatExportFloat(tr.Entry.Price, decimalComma),
+		formatExportFloat(tr.Entry.Quantity, decimalComma),
+		formatExportFloat(tr.Entry.Fees, decimalComma),
+		formatExportPtrFloat(tr.Entry.StopLoss, decimalComma),
+		formatExportPtrFloat(tr.Entry.Target, decimalComma),
+		"",
+		"",
+		"",
+		"",
+		"",
+		formatExportFloat(tr.NetResult(), decimalComma),
+		formatExportFloat(tr.ResultPercent(), decimalComma),
+		formatExportFloat(tr.RMultiple(), decimalComma),
+		strings.Join(tr.Review.Tags, ","),
+		formatExportFloat(tr.FinancingCost, decimalComma),
+		strconv.FormatBool(tr.IsPaper),
+	}
+	if tr.Exit != nil {
+		record[12] = formatExportDate(tr.Exit.Date)
+		record[13] = formatExportFloat(tr.Exit.Price, decimalComma)
+		record[14] = formatExportFloat(tr.Exit.Quantity, decimalComma)
+		record[15] = formatExportFloat(tr.Exit.Fees, decimalComma)
+		record[16] = tr.Exit.Reason
+	}
+	return record
+}
+
+func formatExportDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func formatExportFloat(val float64, decimalComma bool) string {
+	formatted := strconv.FormatFloat(val, 'f', -1, 64)
+	if decimalComma {
+		return strings.Replace(formatted, ".", ",", 1)
+	}
+	return formatted
+}
+
+func formatExportPtrFloat(val *float64, decimalComma bool) string {
+	if val == nil {
+		return ""
+	}
+	return formatExportFloat(*val, decimalComma)
+}
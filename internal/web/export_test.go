@@ -0,0 +1,159 @@
+package web
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+	tradesvc "best_trade_logs/internal/service/trade"
+	"best_trade_logs/internal/storage"
+)
+
+func seedExportTrades(t *testing.T) *Server {
+	t.Helper()
+	repo := storage.NewInMemoryTradeRepository()
+	svc := tradesvc.NewService(repo)
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	trades := []*domain.Trade{
+		{
+			Instrument: "AAPL",
+			Direction:  domain.DirectionLong,
+			Entry:      domain.EntryDetail{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Price: 100, Quantity: 10},
+			Exit:       &domain.ExitDetail{Date: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), Price: 90, Quantity: 10},
+			Review:     domain.TradeReview{Tags: []string{"breakout"}},
+		},
+		{
+			Instrument: "MSFT",
+			Direction:  domain.DirectionLong,
+			Entry:      domain.EntryDetail{Date: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Price: 100, Quantity: 10},
+			Exit:       &domain.ExitDetail{Date: time.Date(2024, 4, 5, 0, 0, 0, 0, time.UTC), Price: 110, Quantity: 10},
+			Review:     domain.TradeReview{Tags: []string{"breakout"}},
+		},
+		{
+			Instrument: "TSLA",
+			Direction:  domain.DirectionShort,
+			Entry:      domain.EntryDetail{Date: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), Price: 100, Quantity: 10},
+			Exit:       &domain.ExitDetail{Date: time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC), Price: 120, Quantity: 10},
+			Review:     domain.TradeReview{Tags: []string{"breakout"}},
+		},
+	}
+	for _, tr := range trades {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	return server
+}
+
+func TestExportCSVHonoursIndexFilters(t *testing.T) {
+	server := seedExportTrades(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/trades/export.csv?tag=breakout&status=losses&from=2024-01-01&to=2024-12-31", nil)
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header and 1 row, got %d rows", len(records))
+	}
+	if records[1][1] != "AAPL" {
+		t.Fatalf("expected AAPL to be exported, got %v", records[1][1])
+	}
+}
+
+func TestExportCSVDecimalComma(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := tradesvc.NewService(repo)
+	server, err := NewServer(svc, WithExportDecimal("comma"))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	tr := &domain.Trade{
+		Instrument: "AAPL",
+		Direction:  domain.DirectionLong,
+		Entry:      domain.EntryDetail{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Price: 100.5, Quantity: 10},
+		Review:     domain.TradeReview{Tags: []string{"breakout", "gap"}},
+	}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	read := func(query string) [][]string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/trades/export.csv"+query, nil)
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", query, rec.Code)
+		}
+		reader := csv.NewReader(rec.Body)
+		if strings.HasPrefix(rec.Body.String(), "id;") {
+			reader.Comma = ';'
+		}
+		records, err := reader.ReadAll()
+		if err != nil {
+			t.Fatalf("%s: read csv: %v", query, err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("%s: expected header and 1 row, got %d rows", query, len(records))
+		}
+		return records
+	}
+
+	comma := read("")
+	if len(comma[0]) != len(exportHeader) {
+		t.Fatalf("expected semicolon separated fields, got header %v", comma[0])
+	}
+	if comma[1][7] != "100,5" {
+		t.Fatalf("expected decimal comma price, got %q", comma[1][7])
+	}
+	point := read("?decimal=point")
+	if len(point[0]) != len(exportHeader) || point[1][7] != "100.5" {
+		t.Fatalf("expected decimal point export, got %v", point[1])
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/trades/export.csv?decimal=space", nil)
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown separator, got %d", rec.Code)
+	}
+
+	if _, err := NewServer(svc, WithExportDecimal("space")); err == nil {
+		t.Fatal("expected unknown export decimal separator to be rejected")
+	}
+}
+
+func TestExportJSONHonoursIndexFilters(t *testing.T) {
+	server := seedExportTrades(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/trades/export.json?direction=SHORT", nil)
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var trades []domain.Trade
+	if err := json.NewDecoder(rec.Body).Decode(&trades); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	if len(trades) != 1 || trades[0].Instrument != "TSLA" {
+		t.Fatalf("expected only the short trade, got %+v", trades)
+	}
+}
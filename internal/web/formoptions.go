@@ -0,0 +1,107 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// formOption is one choice of a fixed dropdown, with the label the form shows.
+type formOption struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// formDefaults are the values the create form starts with.
+type formDefaults struct {
+	EntryDate string `json:"entry_date"`
+	Direction string `json:"direction"`
+	FeeModel  string `json:"fee_model"`
+}
+
+// formOptions is the reference data the trade form is built from: the
+// configured lists plus the values already used by existing trades.
+type formOptions struct {
+	Instruments       []string          `json:"instruments"`
+	InstrumentAliases map[string]string `json:"instrument_aliases"`
+	Setups            []string          `json:"setups"`
+	ExitReasons       []string          `json:"exit_reasons"`
+	Mistakes          []string          `json:"mistakes"`
+	Currencies        []string          `json:"currencies"`
+	Accounts          []string          `json:"accounts"`
+	Tags              []TagCount        `json:"tags"`
+	Directions        []formOption      `json:"directions"`
+	FeeModels         []formOption      `json:"fee_models"`
+	Defaults          formDefaults      `json:"defaults"`
+}
+
+var (
+	directionOptions = []formOption{
+		{Value: string(domain.DirectionLong), Label: "多頭"},
+		{Value: string(domain.DirectionShort), Label: "空頭"},
+	}
+	feeModelOptions = []formOption{
+		{Value: string(domain.FeeModelFlat), Label: "固定金額"},
+		{Value: string(domain.FeeModelPercent), Label: "成交金額百分比"},
+	}
+)
+
+func (s *Server) handleAPIFormOptions(w http.ResponseWriter, r *http.Request) {
+	trades, err := s.svc.List(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	aliases := s.svc.InstrumentAliases()
+	var instruments, currencies []string
+	for _, tr := range trades {
+		instruments = append(instruments, tr.Instrument)
+		currencies = append(currencies, tr.Currency, tr.FeeCurrency)
+	}
+	for _, symbol := range aliases {
+		instruments = append(instruments, symbol)
+	}
+	form := s.tradeFormData(&domain.Trade{Direction: domain.DirectionLong}, true)
+	writeJSON(w, http.StatusOK, formOptions{
+		Instruments:       uniqueSorted(instruments, false),
+		InstrumentAliases: aliases,
+		Setups:            s.svc.KnownSetups(),
+		ExitReasons:       s.svc.ExitReasons(),
+		Mistakes:          s.svc.MistakeChecklist(),
+		Currencies:        uniqueSorted(currencies, true),
+		Accounts:          collectAccounts(trades),
+		Tags:              collectTags(trades, s.tagOrder),
+		Directions:        directionOptions,
+		FeeModels:         feeModelOptions,
+		Defaults: formDefaults{
+			EntryDate: form.EntryDate,
+			Direction: form.Direction,
+			FeeModel:  form.FeeModel,
+		},
+	})
+}
+
+// uniqueSorted trims values, drops empty ones and duplicates, and sorts the
+// rest. With upper set, values are upper-cased first, as currency codes are.
+func uniqueSorted(values []string, upper bool) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := []string{}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if upper {
+			v = strings.ToUpper(v)
+		}
+		if v == "" {
+			continue
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,50 @@
+package web
+
+import (
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// hedgeView is a trade's hedge group as shown on the detail page: the primary
+// trade first, then its hedges, and the net result of the group as a whole.
+type hedgeView struct {
+	Legs []*domain.Trade
+	// Nets sums the legs' NetResult per currency, in the order the currencies
+	// first appear; open legs only contribute their fees. Legs in different
+	// currencies are not converted, so a mixed group has more than one total.
+	Nets    []hedgeNet
+	HasOpen bool
+}
+
+type hedgeNet struct {
+	Currency string
+	Net      float64
+}
+
+func newHedgeView(legs []*domain.Trade) *hedgeView {
+	if len(legs) == 0 {
+		return nil
+	}
+	view := &hedgeView{Legs: legs}
+	var currencies []string
+	nets := make(map[string]domain.Money)
+	for _, leg := range legs {
+		currency := domain.NormalizeCurrency(leg.Currency)
+		if _, ok := nets[currency]; !ok {
+			currencies = append(currencies, currency)
+		}
+		nets[currency] += domain.ToMoney(leg.NetResult())
+		if !leg.HasExited() {
+			view.HasOpen = true
+		}
+	}
+	for _, currency := range currencies {
+		view.Nets = append(view.Nets, hedgeNet{Currency: currency, Net: nets[currency].Float64()})
+	}
+	return view
+}
+
+// MixedCurrency reports whether the group's legs are in more than one
+// currency, in which case the totals are listed per currency.
+func (v *hedgeView) MixedCurrency() bool {
+	return len(v.Nets) > 1
+}
@@ -0,0 +1,73 @@
+package web
+
+import (
+	"fmt"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// holdPlanMetrics is how far closed trades were held from their expected hold
+// time, in calendar days. Deviations are signed, positive when held longer
+// than planned, and split by outcome so holding losers too long or cutting
+// winners short shows up.
+type holdPlanMetrics struct {
+	// Samples counts closed trades with an expected hold and valid dates.
+	Samples            int
+	AvgDeviation       float64
+	Winners            int
+	WinnerAvgDeviation float64
+	Losers             int
+	LoserAvgDeviation  float64
+}
+
+func summarizeHoldPlan(rows []tradeSummary) holdPlanMetrics {
+	var metrics holdPlanMetrics
+	var total, winTotal, lossTotal float64
+	for _, row := range rows {
+		if row.IsOpen {
+			continue
+		}
+		_, deviation, ok := row.HoldDeviation()
+		if !ok {
+			continue
+		}
+		metrics.Samples++
+		total += deviation
+		switch row.Outcome {
+		case domain.OutcomeWin:
+			metrics.Winners++
+			winTotal += deviation
+		case domain.OutcomeLoss:
+			metrics.Losers++
+			lossTotal += deviation
+		}
+	}
+	if metrics.Samples > 0 {
+		metrics.AvgDeviation = total / float64(metrics.Samples)
+	}
+	if metrics.Winners > 0 {
+		metrics.WinnerAvgDeviation = winTotal / float64(metrics.Winners)
+	}
+	if metrics.Losers > 0 {
+		metrics.LoserAvgDeviation = lossTotal / float64(metrics.Losers)
+	}
+	return metrics
+}
+
+func holdPlanPanel(d dashboardView) dashboardPanel {
+	h := d.HoldPlan
+	panel := dashboardPanel{Label: "持有天數偏差", Value: "—", Meta: "需填寫預期持有天數"}
+	if h.Samples == 0 {
+		return panel
+	}
+	panel.Value = fmt.Sprintf("%+.1f 天", h.AvgDeviation)
+	panel.Meta = fmt.Sprintf("獲利 %s · 虧損 %s（%d 筆，正值為超出預期）", holdDeviationLabel(h.WinnerAvgDeviation, h.Winners), holdDeviationLabel(h.LoserAvgDeviation, h.Losers), h.Samples)
+	return panel
+}
+
+func holdDeviationLabel(avg float64, samples int) string {
+	if samples == 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%+.1f 天", avg)
+}
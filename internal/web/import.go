@@ -0,0 +1,291 @@
+package web
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// maxImportBytes caps the size of an uploaded import file.
+const maxImportBytes = 10 << 20
+
+// importRowError describes why a single row could not be imported.
+type importRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// importReport summarises a bulk import. Rows are numbered from 1, excluding
+// the CSV header. Successfully parsed rows are persisted even if others fail to
+// parse; if storing them fails, none are kept. A preview stores nothing and
+// lists the trades that would be created instead.
+type importReport struct {
+	Total   int              `json:"total"`
+	Created int              `json:"created"`
+	Skipped int              `json:"skipped"`
+	Errors  []importRowError `json:"errors"`
+	Preview *importPreview   `json:"preview,omitempty"`
+}
+
+// importPreview is the outcome of an import requested with ?preview=1: the
+// trades that would be created, as they would be stored.
+type importPreview struct {
+	Open   int             `json:"open"`
+	Closed int             `json:"closed"`
+	Trades []*domain.Trade `json:"trades"`
+}
+
+// isImportPreview reports whether an import request only asks for a preview.
+func isImportPreview(r *http.Request) bool {
+	return r.URL.Query().Get("preview") == "1"
+}
+
+func (rep *importReport) fail(row int, err error) {
+	rep.Skipped++
+	rep.Errors = append(rep.Errors, importRowError{Row: row, Error: err.Error()})
+}
+
+// handleAPIImport accepts a CSV file in the export layout, a JSON array of
+// trades, or with format=mt4 a MetaTrader history report, and reports per-row
+// outcomes instead of aborting on the first failure. With preview=1 nothing is
+// stored; posting the same file without it commits the import.
+func (s *Server) handleAPIImport(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var (
+		rows []importRow
+		err  error
+	)
+	switch format := r.URL.Query().Get("format"); {
+	case format == "mt4":
+		lotSize, perr := parseOptionalFloat(r.URL.Query().Get("lot_size"), defaultLotSize)
+		if perr != nil || lotSize <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid lot_size")
+			return
+		}
+		rows, err = readMT4Import(body, lotSize)
+	case mediaType == "application/json" || format == "json":
+		rows, err = readJSONImport(body)
+	default:
+		rows, err = readCSVImport(body)
+	}
+	if err != nil {
+		writeJSONError(w, importReadStatus(err), err.Error())
+		return
+	}
+
+	report, err := s.importRows(r.Context(), rows, isImportPreview(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// importRows stores the rows that parsed and reports the ones that did not.
+// With preview set the parsed rows are only prepared and returned.
+func (s *Server) importRows(ctx context.Context, rows []importRow, preview bool) (importReport, error) {
+	report := importReport{Total: len(rows), Errors: []importRowError{}}
+	valid := make([]*domain.Trade, 0, len(rows))
+	for _, row := range rows {
+		if row.err != nil {
+			report.fail(row.index, row.err)
+			continue
+		}
+		valid = append(valid, row.trade)
+	}
+	if preview {
+		s.svc.PrepareAll(valid)
+		report.Preview = &importPreview{Trades: valid}
+		for _, tr := range valid {
+			if tr.HasExited() {
+				report.Preview.Closed++
+			} else {
+				report.Preview.Open++
+			}
+		}
+		return report, nil
+	}
+	// The valid rows are stored together: a storage failure part way through
+	// must not leave half an import behind.
+	if err := s.svc.CreateAll(ctx, valid); err != nil {
+		return importReport{}, err
+	}
+	report.Created = len(valid)
+	return report, nil
+}
+
+// importRow is a parsed import record or the reason it could not be parsed.
+type importRow struct {
+	index int
+	trade *domain.Trade
+	err   error
+}
+
+func readJSONImport(r io.Reader) ([]importRow, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON array: %w", err)
+	}
+	rows := make([]importRow, 0, len(raw))
+	for i, msg := range raw {
+		row := importRow{index: i + 1}
+		var tr domain.Trade
+		if err := json.Unmarshal(msg, &tr); err != nil {
+			row.err = err
+		} else if err := sanitizeAPITrade(&tr); err != nil {
+			row.err = err
+		} else {
+			tr.ID = ""
+			row.trade = &tr
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func readCSVImport(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range requiredImportColumns() {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required column %q", required)
+		}
+	}
+
+	return readCSVRows(reader, func(record []string) (*domain.Trade, error) {
+		return parseCSVTrade(record, columns)
+	})
+}
+
+// readCSVRows parses each record after the header with parse. A malformed
+// record becomes an error row; any other read error, such as a body over
+// maxImportBytes, ends the import because the reader would keep returning it.
+func readCSVRows(reader *csv.Reader, parse func(record []string) (*domain.Trade, error)) ([]importRow, error) {
+	var rows []importRow
+	for index := 1; ; index++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		row := importRow{index: index}
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			row.err = err
+		case err != nil:
+			return nil, err
+		default:
+			row.trade, row.err = parse(record)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// importReadStatus is the response status for an import file that could not
+// be read: 413 when it is over maxImportBytes, 400 otherwise.
+func importReadStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// parseCSVTrade maps a record in the export layout onto a trade. Derived columns
+// such as net_result are ignored because they are recomputed from the inputs.
+func parseCSVTrade(record []string, columns map[string]int) (*domain.Trade, error) {
+	get := func(name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	tr := &domain.Trade{
+		Instrument: get("instrument"),
+		Market:     get("market"),
+		Account:    get("account"),
+		Setup:      get("setup"),
+		Direction:  domain.Direction(get("direction")),
+	}
+	if err := sanitizeAPITrade(tr); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if tr.Entry.Date, err = time.Parse("2006-01-02", get("entry_date")); err != nil {
+		return nil, fmt.Errorf("invalid entry_date %q", get("entry_date"))
+	}
+	if tr.Entry.Price, err = parseRequiredFloat(get("entry_price")); err != nil {
+		return nil, fmt.Errorf("invalid entry_price %q", get("entry_price"))
+	}
+	if tr.Entry.Quantity, err = parseRequiredFloat(get("entry_quantity")); err != nil {
+		return nil, fmt.Errorf("invalid entry_quantity %q", get("entry_quantity"))
+	}
+	if tr.Entry.Fees, err = parseOptionalFloat(get("entry_fees"), 0); err != nil {
+		return nil, fmt.Errorf("invalid entry_fees %q", get("entry_fees"))
+	}
+	if tr.Entry.StopLoss, err = parseOptionalPtrFloat(get("stop_loss")); err != nil {
+		return nil, fmt.Errorf("invalid stop_loss %q", get("stop_loss"))
+	}
+	if tr.Entry.Target, err = parseOptionalPtrFloat(get("target")); err != nil {
+		return nil, fmt.Errorf("invalid target %q", get("target"))
+	}
+	if tr.FinancingCost, err = parseOptionalFloat(get("financing_cost"), 0); err != nil {
+		return nil, fmt.Errorf("invalid financing_cost %q", get("financing_cost"))
+	}
+
+	if paper := get("is_paper"); paper != "" {
+		if tr.IsPaper, err = strconv.ParseBool(paper); err != nil {
+			return nil, fmt.Errorf("invalid is_paper %q", paper)
+		}
+	}
+
+	if exitDate := get("exit_date"); exitDate != "" {
+		exit := &domain.ExitDetail{Reason: get("exit_reason")}
+		if exit.Date, err = time.Parse("2006-01-02", exitDate); err != nil {
+			return nil, fmt.Errorf("invalid exit_date %q", exitDate)
+		}
+		if exit.Price, err = parseRequiredFloat(get("exit_price")); err != nil {
+			return nil, fmt.Errorf("invalid exit_price %q", get("exit_price"))
+		}
+		if exit.Quantity, err = parseOptionalFloat(get("exit_quantity"), tr.Entry.Quantity); err != nil {
+			return nil, fmt.Errorf("invalid exit_quantity %q", get("exit_quantity"))
+		}
+		if exit.Fees, err = parseOptionalFloat(get("exit_fees"), 0); err != nil {
+			return nil, fmt.Errorf("invalid exit_fees %q", get("exit_fees"))
+		}
+		tr.Exit = exit
+	}
+
+	if tags := get("tags"); tags != "" {
+		for _, tag := range strings.Split(tags, ",") {
+			if normalized := domain.NormalizeTag(tag); normalized != "" {
+				tr.Review.Tags = append(tr.Review.Tags, normalized)
+			}
+		}
+	}
+	return tr, nil
+}
@@ -0,0 +1,436 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tradesvc "best_trade_logs/internal/service/trade"
+	"best_trade_logs/internal/storage"
+)
+
+const (
+	// mappedUploadTTL is how long an uploaded file waits for its mapping.
+	mappedUploadTTL = 30 * time.Minute
+	// maxPendingUploads caps the uploads held in memory; the oldest is dropped
+	// to make room.
+	maxPendingUploads = 20
+	// mappedSampleRows is how many data rows are echoed back with the headers.
+	mappedSampleRows = 3
+)
+
+var (
+	// errUploadNotFound is returned when a mapped import refers to an upload
+	// that never existed, was already imported or has expired.
+	errUploadNotFound = errors.New("upload not found or expired")
+	// errInvalidMapping marks mapping errors the caller can correct.
+	errInvalidMapping = errors.New("invalid mapping")
+)
+
+// importField is a field the generic CSV import can fill, named like the
+// matching column of the export layout.
+type importField struct {
+	Name     string
+	Label    string
+	Required bool
+}
+
+var importFields = []importField{
+	{"instrument", "商品", true},
+	{"direction", "方向（LONG / SHORT）", false},
+	{"market", "市場", false},
+	{"account", "帳戶", false},
+	{"setup", "策略", false},
+	{"entry_date", "進場日期（YYYY-MM-DD）", true},
+	{"entry_price", "進場價格", true},
+	{"entry_quantity", "數量", true},
+	{"entry_fees", "進場手續費", false},
+	{"stop_loss", "停損價", false},
+	{"target", "目標價", false},
+	{"financing_cost", "隔夜利息／融資成本", false},
+	{"exit_date", "出場日期（YYYY-MM-DD）", false},
+	{"exit_price", "出場價格", false},
+	{"exit_quantity", "出場數量", false},
+	{"exit_fees", "出場手續費", false},
+	{"exit_reason", "出場原因", false},
+	{"tags", "標籤（逗號分隔）", false},
+	{"is_paper", "模擬交易（true / false）", false},
+}
+
+func importFieldNames() []string {
+	names := make([]string, len(importFields))
+	for i, f := range importFields {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// requiredImportColumns are the fields every CSV import must provide.
+func requiredImportColumns() []string {
+	var names []string
+	for _, f := range importFields {
+		if f.Required {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// pendingUpload is a CSV file waiting for its column mapping.
+type pendingUpload struct {
+	Headers []string
+	Records [][]string
+	Expires time.Time
+}
+
+type pendingUploads struct {
+	mu    sync.Mutex
+	items map[string]pendingUpload
+}
+
+func newPendingUploads() *pendingUploads {
+	return &pendingUploads{items: make(map[string]pendingUpload)}
+}
+
+func (p *pendingUploads) add(upload pendingUpload, now time.Time) (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(token)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var oldest string
+	for key, u := range p.items {
+		if now.After(u.Expires) {
+			delete(p.items, key)
+			continue
+		}
+		if oldest == "" || u.Expires.Before(p.items[oldest].Expires) {
+			oldest = key
+		}
+	}
+	if len(p.items) >= maxPendingUploads {
+		delete(p.items, oldest)
+	}
+	p.items[id] = upload
+	return id, nil
+}
+
+func (p *pendingUploads) get(id string, now time.Time) (pendingUpload, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	upload, ok := p.items[id]
+	if !ok || now.After(upload.Expires) {
+		delete(p.items, id)
+		return pendingUpload{}, false
+	}
+	return upload, true
+}
+
+func (p *pendingUploads) remove(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.items, id)
+}
+
+// mappedUpload is the first step of a generic CSV import: the detected headers,
+// a few sample rows and a suggested mapping from CSV header to import field.
+type mappedUpload struct {
+	UploadID string     `json:"upload_id"`
+	Headers  []string   `json:"headers"`
+	Sample   [][]string `json:"sample"`
+	Rows     int        `json:"rows"`
+	Fields   []string   `json:"fields"`
+	// Mapping is the suggested header → field mapping: a saved mapping for the
+	// same headers when there is one (named in MappingName), otherwise the
+	// headers that already carry a field name.
+	Mapping     map[string]string `json:"mapping"`
+	MappingName string            `json:"mapping_name,omitempty"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+}
+
+// mappedImportRequest is the second step: the mapping to apply, given inline
+// as header → field or by the name of a saved mapping, and optionally a name
+// to save the inline mapping under.
+type mappedImportRequest struct {
+	Columns map[string]string `json:"columns"`
+	Mapping string            `json:"mapping"`
+	SaveAs  string            `json:"save_as"`
+}
+
+// uploadMappedCSV reads a CSV file of any layout and holds it until its
+// mapping is posted.
+func (s *Server) uploadMappedCSV(ctx context.Context, r io.Reader) (mappedUpload, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return mappedUpload{}, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return mappedUpload{}, errors.New("missing CSV header")
+	}
+	headers := make([]string, len(records[0]))
+	for i, h := range records[0] {
+		headers[i] = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+	}
+	now := s.svc.Now()
+	upload := pendingUpload{Headers: headers, Records: records[1:], Expires: now.Add(mappedUploadTTL)}
+	id, err := s.uploads.add(upload, now)
+	if err != nil {
+		return mappedUpload{}, err
+	}
+
+	result := mappedUpload{
+		UploadID:  id,
+		Headers:   headers,
+		Sample:    upload.Records[:min(len(upload.Records), mappedSampleRows)],
+		Rows:      len(upload.Records),
+		Fields:    importFieldNames(),
+		Mapping:   suggestMapping(headers),
+		ExpiresAt: upload.Expires,
+	}
+	saved, ok, err := s.svc.MatchImportMapping(ctx, headers)
+	if err != nil {
+		return mappedUpload{}, err
+	}
+	if ok {
+		result.Mapping = saved.Columns
+		result.MappingName = saved.Name
+	}
+	return result, nil
+}
+
+// suggestMapping maps the headers that already name an import field, such as
+// those of a file exported by this application.
+func suggestMapping(headers []string) map[string]string {
+	mapping := make(map[string]string)
+	for _, h := range headers {
+		for _, f := range importFields {
+			if strings.EqualFold(h, f.Name) {
+				mapping[h] = f.Name
+			}
+		}
+	}
+	return mapping
+}
+
+// importMapped parses an upload with the requested mapping and imports it like
+// a CSV in the export layout. The upload is released once the rows are stored;
+// a preview keeps it, so the same upload ID commits the import afterwards.
+func (s *Server) importMapped(ctx context.Context, uploadID string, req mappedImportRequest, preview bool) (importReport, error) {
+	upload, ok := s.uploads.get(uploadID, s.svc.Now())
+	if !ok {
+		return importReport{}, errUploadNotFound
+	}
+	columns := req.Columns
+	if len(columns) == 0 && req.Mapping != "" {
+		saved, err := s.svc.ImportMapping(ctx, req.Mapping)
+		if err != nil {
+			return importReport{}, err
+		}
+		columns = saved.Columns
+	}
+	indexes, err := mappingColumns(upload.Headers, columns)
+	if err != nil {
+		return importReport{}, err
+	}
+	if name := strings.TrimSpace(req.SaveAs); name != "" && !preview {
+		mapping := storage.ImportMapping{Name: name, Headers: upload.Headers, Columns: columns}
+		if err := s.svc.SaveImportMapping(ctx, mapping); err != nil {
+			return importReport{}, err
+		}
+	}
+
+	rows := make([]importRow, 0, len(upload.Records))
+	for i, record := range upload.Records {
+		row := importRow{index: i + 1}
+		row.trade, row.err = parseCSVTrade(record, indexes)
+		rows = append(rows, row)
+	}
+	report, err := s.importRows(ctx, rows, preview)
+	if err != nil {
+		return importReport{}, err
+	}
+	if preview {
+		return report, nil
+	}
+	s.uploads.remove(uploadID)
+	return report, nil
+}
+
+// mappingColumns turns a header → field mapping into the field → column index
+// lookup parseCSVTrade uses. Headers mapped to "" are ignored.
+func mappingColumns(headers []string, columns map[string]string) (map[string]int, error) {
+	indexes := make(map[string]int, len(columns))
+	for header, field := range columns {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if !containsKey(importFieldNames(), field) {
+			return nil, fmt.Errorf("%w: unknown field %q for column %q", errInvalidMapping, field, header)
+		}
+		column := -1
+		for i, h := range headers {
+			if strings.EqualFold(h, strings.TrimSpace(header)) {
+				column = i
+				break
+			}
+		}
+		if column < 0 {
+			return nil, fmt.Errorf("%w: unknown column %q", errInvalidMapping, header)
+		}
+		if _, dup := indexes[field]; dup {
+			return nil, fmt.Errorf("%w: field %q is mapped more than once", errInvalidMapping, field)
+		}
+		indexes[field] = column
+	}
+	for _, required := range requiredImportColumns() {
+		if _, ok := indexes[required]; !ok {
+			return nil, fmt.Errorf("%w: missing required field %q", errInvalidMapping, required)
+		}
+	}
+	return indexes, nil
+}
+
+func (s *Server) handleAPIMappedUpload(w http.ResponseWriter, r *http.Request) {
+	upload, err := s.uploadMappedCSV(r.Context(), http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, upload)
+}
+
+func (s *Server) handleAPIMappedImport(w http.ResponseWriter, r *http.Request, uploadID string) {
+	var req mappedImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	report, err := s.importMapped(r.Context(), uploadID, req, isImportPreview(r))
+	if err != nil {
+		writeJSONError(w, mappedImportStatus(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) handleAPIImportMappings(w http.ResponseWriter, r *http.Request) {
+	mappings, err := s.svc.ImportMappings(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, mappings)
+}
+
+func mappedImportStatus(err error) int {
+	switch {
+	case errors.Is(err, errUploadNotFound), errors.Is(err, storage.ErrMappingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errInvalidMapping), errors.Is(err, tradesvc.ErrInvalidMapping),
+		errors.Is(err, tradesvc.ErrInvalidHedge), errors.Is(err, tradesvc.ErrInvalidScreenshotURL):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// importPageData drives import.gohtml: the upload form, the mapping step for an
+// uploaded file, or the outcome of an import.
+type importPageData struct {
+	Title    string
+	Error    string
+	Upload   *mappedUpload
+	Fields   []importField
+	Mappings []storage.ImportMapping
+	Report   *importReport
+}
+
+func (s *Server) handleImportPage(w http.ResponseWriter, r *http.Request) {
+	data := importPageData{Title: "匯入 CSV", Fields: importFields}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			data.Error = "請選擇要匯入的 CSV 檔案"
+			break
+		}
+		defer file.Close()
+		upload, err := s.uploadMappedCSV(r.Context(), io.LimitReader(file, maxImportBytes))
+		if err != nil {
+			data.Error = err.Error()
+			break
+		}
+		data.Upload = &upload
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	mappings, err := s.svc.ImportMappings(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data.Mappings = mappings
+	s.render(w, "import.gohtml", data)
+}
+
+// handleImportMapping applies the mapping chosen on the import page. Each
+// header's field arrives as column_<index>.
+func (s *Server) handleImportMapping(w http.ResponseWriter, r *http.Request) {
+	uploadID := strings.TrimPrefix(r.URL.Path, "/trades/import/")
+	if r.Method != http.MethodPost || uploadID == "" || strings.Contains(uploadID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "表單格式錯誤", http.StatusBadRequest)
+		return
+	}
+	data := importPageData{Title: "匯入 CSV", Fields: importFields}
+	upload, ok := s.uploads.get(uploadID, s.svc.Now())
+	if !ok {
+		data.Error = "上傳的檔案已過期，請重新上傳"
+		s.render(w, "import.gohtml", data)
+		return
+	}
+	req := mappedImportRequest{Columns: map[string]string{}, SaveAs: r.FormValue("save_as")}
+	for i, header := range upload.Headers {
+		if field := r.FormValue("column_" + strconv.Itoa(i)); field != "" {
+			req.Columns[header] = field
+		}
+	}
+	report, err := s.importMapped(r.Context(), uploadID, req, false)
+	if err != nil {
+		status := mappedImportStatus(err)
+		if status == http.StatusInternalServerError {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		data.Error = err.Error()
+		data.Upload = &mappedUpload{
+			UploadID: uploadID,
+			Headers:  upload.Headers,
+			Sample:   upload.Records[:min(len(upload.Records), mappedSampleRows)],
+			Rows:     len(upload.Records),
+			Mapping:  req.Columns,
+		}
+	} else {
+		data.Report = &report
+	}
+	s.render(w, "import.gohtml", data)
+}
@@ -0,0 +1,291 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// defaultLotSize is the number of units in one standard forex lot. MetaTrader
+// reports volume in lots, while trades store quantity in units.
+const defaultLotSize = 100000
+
+// mtTimeLayouts are the timestamp formats MetaTrader writes in its reports.
+var mtTimeLayouts = []string{"2006.01.02 15:04:05", "2006.01.02 15:04", "2006-01-02 15:04:05"}
+
+var (
+	mtRowPattern      = regexp.MustCompile(`(?is)<tr[^>]*>(.*?)</tr>`)
+	mtCellPattern     = regexp.MustCompile(`(?is)<td[^>]*>(.*?)</td>`)
+	mtTagPattern      = regexp.MustCompile(`(?s)<[^>]*>`)
+	mtCurrencyPattern = regexp.MustCompile(`Currency:\s*([A-Za-z]{3})`)
+)
+
+// readMT4Import parses a MetaTrader history report: either the HTML detailed
+// statement saved from the MT4/MT5 terminal or the CSV export of closed
+// positions. Only closed buy/sell positions become trades; balance entries,
+// cancelled orders and open positions are ignored. lotSize converts the
+// reported volume in lots into units.
+func readMT4Import(r io.Reader, lotSize float64) ([]importRow, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return readMT4HTML(string(data), lotSize)
+	}
+	return readMT4CSV(data, lotSize)
+}
+
+func readMT4HTML(doc string, lotSize float64) ([]importRow, error) {
+	var accountCurrency string
+	if m := mtCurrencyPattern.FindStringSubmatch(mtTagPattern.ReplaceAllString(doc, " ")); m != nil {
+		accountCurrency = m[1]
+	}
+
+	var (
+		rows     []importRow
+		found    bool
+		section  map[string]int
+		inClosed bool
+		index    int
+	)
+	for _, match := range mtRowPattern.FindAllStringSubmatch(doc, -1) {
+		var cells []string
+		for _, cell := range mtCellPattern.FindAllStringSubmatch(match[1], -1) {
+			text := html.UnescapeString(mtTagPattern.ReplaceAllString(cell[1], ""))
+			cells = append(cells, strings.TrimSpace(text))
+		}
+		if title, ok := mtSectionTitle(cells); ok {
+			// MT4 lists closed trades under "Closed Transactions:", MT5 under "Positions".
+			inClosed = title == "Closed Transactions:" || title == "Positions"
+			section = nil
+			continue
+		}
+		if !inClosed {
+			continue
+		}
+		if section == nil {
+			if len(cells) > 0 && (strings.EqualFold(cells[0], "Ticket") || strings.EqualFold(cells[0], "Time")) {
+				section = mtColumns(cells)
+				found = true
+			}
+			continue
+		}
+		if len(cells) < len(section) || !isMTPosition(mtCell(cells, section, "type")) {
+			continue
+		}
+		index++
+		row := importRow{index: index}
+		row.trade, row.err = parseMTTrade(cells, section, lotSize, accountCurrency)
+		rows = append(rows, row)
+	}
+	if !found {
+		return nil, errors.New("no closed transactions table found in MetaTrader report")
+	}
+	return rows, nil
+}
+
+// mtSectionTitle reports whether a report row is a section heading, which has
+// a single non-empty cell.
+func mtSectionTitle(cells []string) (string, bool) {
+	var title string
+	for _, cell := range cells {
+		if cell == "" {
+			continue
+		}
+		if title != "" {
+			return "", false
+		}
+		title = cell
+	}
+	return title, title != ""
+}
+
+func readMT4CSV(data []byte, lotSize float64) ([]importRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	if first, _, _ := bufio.NewReader(bytes.NewReader(data)).ReadLine(); bytes.Contains(first, []byte("\t")) {
+		reader.Comma = '\t'
+	}
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing CSV header: %w", err)
+	}
+	columns := mtColumns(header)
+	for _, required := range [][]string{{"symbol", "item"}, {"type"}, {"volume", "size"}, {"price"}} {
+		if mtCell(header, columns, required...) == "" {
+			return nil, fmt.Errorf("missing required column %q", required[0])
+		}
+	}
+
+	return readCSVRows(reader, func(record []string) (*domain.Trade, error) {
+		if kind := mtCell(record, columns, "type"); !isMTPosition(kind) {
+			return nil, fmt.Errorf("unsupported type %q", kind)
+		}
+		return parseMTTrade(record, columns, lotSize, "")
+	})
+}
+
+// mtColumns indexes report columns by lower-cased name. Repeated names get an
+// occurrence suffix, so the closing "Price" and "Time" become "price#2" and
+// "time#2".
+func mtColumns(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		for n := 2; ; n++ {
+			if _, taken := columns[key]; !taken {
+				break
+			}
+			key = strings.ToLower(strings.TrimSpace(name)) + "#" + strconv.Itoa(n)
+		}
+		columns[key] = i
+	}
+	return columns
+}
+
+// mtCell returns the first of names present in the record.
+func mtCell(record []string, columns map[string]int, names ...string) string {
+	for _, name := range names {
+		if idx, ok := columns[name]; ok && idx < len(record) {
+			return strings.TrimSpace(record[idx])
+		}
+	}
+	return ""
+}
+
+func isMTPosition(kind string) bool {
+	kind = strings.ToLower(kind)
+	return kind == "buy" || kind == "sell"
+}
+
+// parseMTTrade maps one closed MetaTrader position onto a trade. Commission is
+// booked as the entry fee, taxes as the exit fee and swap as the financing
+// cost. All three are in the account currency, which for forex pairs usually
+// differs from the quote currency the trade is priced in.
+func parseMTTrade(record []string, columns map[string]int, lotSize float64, accountCurrency string) (*domain.Trade, error) {
+	get := func(names ...string) string { return mtCell(record, columns, names...) }
+
+	tr := &domain.Trade{
+		Instrument: strings.ToUpper(get("symbol", "item")),
+		Direction:  domain.DirectionLong,
+	}
+	if strings.EqualFold(get("type"), "sell") {
+		tr.Direction = domain.DirectionShort
+	}
+	if err := sanitizeAPITrade(tr); err != nil {
+		return nil, err
+	}
+	if isForexPair(tr.Instrument) {
+		tr.Currency = tr.Instrument[3:6]
+		if accountCurrency != "" && !strings.EqualFold(accountCurrency, tr.Currency) {
+			tr.FeeCurrency = accountCurrency
+		}
+	}
+
+	lots, err := parseRequiredFloat(get("volume", "size"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid volume %q", get("volume", "size"))
+	}
+	tr.Entry.Quantity = lots * lotSize
+	if tr.Entry.Date, err = parseMTTime(get("open time", "time")); err != nil {
+		return nil, fmt.Errorf("invalid open time %q", get("open time", "time"))
+	}
+	if tr.Entry.Price, err = parseRequiredFloat(get("price")); err != nil {
+		return nil, fmt.Errorf("invalid open price %q", get("price"))
+	}
+	if tr.Entry.StopLoss, err = parseMTLevel(get("s / l", "s/l")); err != nil {
+		return nil, fmt.Errorf("invalid stop loss %q", get("s / l", "s/l"))
+	}
+	if tr.Entry.Target, err = parseMTLevel(get("t / p", "t/p")); err != nil {
+		return nil, fmt.Errorf("invalid take profit %q", get("t / p", "t/p"))
+	}
+	commission, err := parseOptionalFloat(get("commission"), 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commission %q", get("commission"))
+	}
+	tr.Entry.Fees = mtCost(commission)
+
+	exit := &domain.ExitDetail{Quantity: tr.Entry.Quantity}
+	if exit.Date, err = parseMTTime(get("close time", "time#2")); err != nil {
+		return nil, fmt.Errorf("invalid close time %q", get("close time", "time#2"))
+	}
+	if exit.Price, err = parseRequiredFloat(get("price#2")); err != nil {
+		return nil, fmt.Errorf("invalid close price %q", get("price#2"))
+	}
+	swap, err := parseOptionalFloat(get("swap"), 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid swap %q", get("swap"))
+	}
+	taxes, err := parseOptionalFloat(get("taxes"), 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid taxes %q", get("taxes"))
+	}
+	exit.Fees = mtCost(taxes)
+	tr.FinancingCost = mtCost(swap)
+	tr.Exit = exit
+
+	tr.AdditionalNotes = "匯入自 MetaTrader"
+	if ticket := get("ticket", "position"); ticket != "" {
+		tr.AdditionalNotes += " #" + ticket
+	}
+	if profit := get("profit"); profit != "" {
+		tr.AdditionalNotes += "，報表損益 " + profit
+		if accountCurrency != "" {
+			tr.AdditionalNotes += " " + accountCurrency
+		}
+	}
+	return tr, nil
+}
+
+// mtCost turns a reported charge, which MetaTrader writes as a negative amount,
+// into a positive cost.
+func mtCost(amount float64) float64 {
+	if amount == 0 {
+		return 0
+	}
+	return -amount
+}
+
+func parseMTTime(val string) (time.Time, error) {
+	val = strings.TrimSpace(val)
+	for _, layout := range mtTimeLayouts {
+		if t, err := time.Parse(layout, val); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised time %q", val)
+}
+
+// parseMTLevel reads a stop loss or take profit; MetaTrader writes 0 when unset.
+func parseMTLevel(val string) (*float64, error) {
+	level, err := parseOptionalPtrFloat(val)
+	if err != nil || level == nil || *level == 0 {
+		return nil, err
+	}
+	return level, nil
+}
+
+// isForexPair reports whether a symbol looks like a currency pair such as
+// EURUSD, optionally followed by a broker suffix (EURUSD.m).
+func isForexPair(symbol string) bool {
+	if len(symbol) < 6 {
+		return false
+	}
+	for _, r := range symbol[:6] {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return len(symbol) == 6 || !('A' <= symbol[6] && symbol[6] <= 'Z')
+}
@@ -0,0 +1,328 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+func TestAPIImportCSVReportsPartialFailures(t *testing.T) {
+	server, svc := newAPITestServer(t)
+
+	csvBody := strings.Join([]string{
+		"instrument,direction,entry_date,entry_price,entry_quantity,exit_date,exit_price,tags",
+		"AAPL,LONG,2024-01-02,100,10,2024-01-05,110,breakout",
+		",LONG,2024-01-02,100,10,,,",
+		"MSFT,SHORT,not-a-date,100,10,,,",
+		"TSLA,SHORT,2024-02-01,200,5,,,",
+	}, "\n")
+	req := httptest.NewRequest(http.MethodPost, "/api/trades/import", strings.NewReader(csvBody))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var report importReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.Total != 4 || report.Created != 2 || report.Skipped != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if len(report.Errors) != 2 || report.Errors[0].Row != 2 || report.Errors[1].Row != 3 {
+		t.Fatalf("unexpected row errors: %+v", report.Errors)
+	}
+
+	trades, err := svc.List(testContext())
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(trades) != 2 {
+		t.Fatalf("expected successful rows to persist, got %d trades", len(trades))
+	}
+}
+
+func TestAPIImportRejectsOversizedBody(t *testing.T) {
+	server, svc := newAPITestServer(t)
+
+	var body strings.Builder
+	body.WriteString("instrument,direction,entry_date,entry_price,entry_quantity\n")
+	for body.Len() <= maxImportBytes {
+		body.WriteString("AAPL,LONG,2024-01-02,100,10\n")
+	}
+	for _, target := range []string{"/api/trades/import", "/api/trades/import?format=mt4"} {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body.String()))
+		req.Header.Set("Content-Type", "text/csv")
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("%s: expected 413, got %d: %.200s", target, rec.Code, rec.Body.String())
+		}
+	}
+	if trades, _ := svc.List(testContext()); len(trades) != 0 {
+		t.Fatalf("expected nothing imported from an oversized body, got %d trades", len(trades))
+	}
+}
+
+func TestAPIImportPreviewStoresNothing(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	csvBody := strings.Join([]string{
+		"instrument,direction,entry_date,entry_price,entry_quantity,exit_date,exit_price,tags",
+		"AAPL,LONG,2024-01-02,100,10,2024-01-05,110,Breakout",
+		",LONG,2024-01-02,100,10,,,",
+		"TSLA,SHORT,2024-02-01,200,5,,,",
+	}, "\n")
+	post := func(target string) importReport {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(csvBody)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var report importReport
+		if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return report
+	}
+
+	preview := post("/api/trades/import?preview=1")
+	if preview.Created != 0 || preview.Skipped != 1 || preview.Preview == nil {
+		t.Fatalf("unexpected preview report: %+v", preview)
+	}
+	if preview.Preview.Open != 1 || preview.Preview.Closed != 1 || len(preview.Preview.Trades) != 2 {
+		t.Fatalf("unexpected proposed trades: %+v", preview.Preview)
+	}
+	if got := preview.Preview.Trades[0].Review.Tags; len(got) != 1 || got[0] != "breakout" {
+		t.Fatalf("expected proposed trades to be normalized as stored, got tags %v", got)
+	}
+	if trades, _ := svc.List(testContext()); len(trades) != 0 {
+		t.Fatalf("expected a preview to store nothing, got %d trades", len(trades))
+	}
+
+	if committed := post("/api/trades/import"); committed.Created != 2 || committed.Preview != nil {
+		t.Fatalf("unexpected commit report: %+v", committed)
+	}
+	if trades, _ := svc.List(testContext()); len(trades) != 2 {
+		t.Fatalf("expected the commit to store the previewed trades, got %d", len(trades))
+	}
+}
+
+func TestAPIImportJSONReportsPartialFailures(t *testing.T) {
+	server, _ := newAPITestServer(t)
+
+	body := `[{"instrument":"AAPL","entry":{"price":100,"quantity":1}},{"instrument":""},{"instrument":42}]`
+	req := httptest.NewRequest(http.MethodPost, "/api/trades/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+
+	var report importReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.Total != 3 || report.Created != 1 || len(report.Errors) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestAPIImportMT4Statement(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	fixture, err := os.ReadFile("testdata/mt4_statement.htm")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/trades/import?format=mt4", bytes.NewReader(fixture))
+	req.Header.Set("Content-Type", "text/html")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report importReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.Total != 2 || report.Created != 2 {
+		t.Fatalf("expected the two closed positions only, got %+v", report)
+	}
+
+	trades, _ := svc.List(testContext())
+	byInstrument := make(map[string]*domain.Trade)
+	for _, tr := range trades {
+		byInstrument[tr.Instrument] = tr
+	}
+	eur := byInstrument["EURUSD"]
+	if eur == nil || eur.Direction != domain.DirectionLong || eur.Entry.Quantity != 100000 || eur.Currency != "USD" {
+		t.Fatalf("unexpected EURUSD trade: %+v", eur)
+	}
+	if eur.Entry.StopLoss == nil || *eur.Entry.StopLoss != 1.08 || eur.Exit == nil || eur.Exit.Price != 1.0921 {
+		t.Fatalf("unexpected EURUSD levels: %+v %+v", eur.Entry, eur.Exit)
+	}
+	if math.Abs(eur.NetResult()-(710-7-2.35)) > 1e-6 {
+		t.Fatalf("expected commission and swap deducted, got %v", eur.NetResult())
+	}
+	gbp := byInstrument["GBPUSD"]
+	if gbp == nil || gbp.Direction != domain.DirectionShort || gbp.Entry.StopLoss != nil || gbp.Entry.Quantity != 50000 {
+		t.Fatalf("unexpected GBPUSD trade: %+v", gbp)
+	}
+}
+
+func TestAPIImportMT5PositionsCSV(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	fixture, err := os.ReadFile("testdata/mt5_positions.csv")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/trades/import?format=mt4", bytes.NewReader(fixture))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	var report importReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.Total != 3 || report.Created != 2 || len(report.Errors) != 1 || report.Errors[0].Row != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	trades, _ := svc.List(testContext())
+	for _, tr := range trades {
+		if tr.Instrument != "USDJPY" {
+			continue
+		}
+		if tr.Direction != domain.DirectionShort || tr.Currency != "JPY" || tr.Entry.Quantity != 200000 {
+			t.Fatalf("unexpected USDJPY trade: %+v", tr)
+		}
+		if tr.Entry.Fees != 14 || tr.Exit.Fees != 0 || tr.FinancingCost != 6.6 || tr.Exit.Date.Day() != 5 {
+			t.Fatalf("unexpected USDJPY costs: %+v %+v", tr.Entry, tr.Exit)
+		}
+		return
+	}
+	t.Fatalf("USDJPY trade not imported")
+}
+
+func TestAPIMappedCSVImportReusesSavedMapping(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	csvBody := strings.Join([]string{
+		"Symbol,Side,Opened,Open Price,Qty,Comment",
+		"AAPL,long,2024-01-02,100,10,first",
+		"MSFT,short,2024-01-03,200,5,second",
+	}, "\n")
+	upload := func() mappedUpload {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/trades/import/mapped", strings.NewReader(csvBody)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var up mappedUpload
+		if err := json.NewDecoder(rec.Body).Decode(&up); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return up
+	}
+	post := func(id, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/trades/import/mapped/"+id, strings.NewReader(body)))
+		return rec
+	}
+
+	first := upload()
+	if len(first.Headers) != 6 || first.Rows != 2 || first.Headers[0] != "Symbol" || first.MappingName != "" {
+		t.Fatalf("unexpected upload: %+v", first)
+	}
+	if rec := post(first.UploadID, `{"columns":{"Symbol":"instrument","Opened":"entry_date"}}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a mapping without required fields, got %d", rec.Code)
+	}
+	mapping := `{"columns":{"Symbol":"instrument","Side":"direction","Opened":"entry_date","Open Price":"entry_price","Qty":"entry_quantity"},"save_as":"broker"}`
+	rec := post(first.UploadID+"?preview=1", mapping)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"open":2`) {
+		t.Fatalf("expected a preview of two open trades, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if trades, _ := svc.List(testContext()); len(trades) != 0 {
+		t.Fatalf("expected a preview to store nothing, got %d trades", len(trades))
+	}
+	if mappings, _ := svc.ImportMappings(testContext()); len(mappings) != 0 {
+		t.Fatalf("expected a preview not to save the mapping, got %+v", mappings)
+	}
+	rec = post(first.UploadID, mapping)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report importReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.Created != 2 || report.Skipped != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if rec := post(first.UploadID, mapping); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected an imported upload to be released, got %d", rec.Code)
+	}
+
+	trades, _ := svc.List(testContext())
+	if len(trades) != 2 {
+		t.Fatalf("expected two imported trades, got %d", len(trades))
+	}
+	for _, tr := range trades {
+		if tr.Instrument == "MSFT" && (tr.Direction != domain.DirectionShort || tr.Entry.Quantity != 5) {
+			t.Fatalf("unexpected MSFT trade: %+v", tr)
+		}
+	}
+
+	second := upload()
+	if second.MappingName != "broker" || second.Mapping["Qty"] != "entry_quantity" {
+		t.Fatalf("expected the saved mapping to be suggested, got %+v", second)
+	}
+	if rec := post(second.UploadID, `{"mapping":"broker"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected import by saved mapping name, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestImportPageMapsColumns(t *testing.T) {
+	server, svc := newAPITestServer(t)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "broker.csv")
+	part.Write([]byte("Ticker,Date,Price,Shares\nTSLA,2024-02-01,200,3\n"))
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/trades/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	page := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(page, `name="column_3"`) {
+		t.Fatalf("expected the mapping step, got %d", rec.Code)
+	}
+	start := strings.Index(page, `action="/trades/import/`) + len(`action="`)
+	action := page[start : start+strings.Index(page[start:], `"`)]
+
+	values := url.Values{}
+	values.Set("column_0", "instrument")
+	values.Set("column_1", "entry_date")
+	values.Set("column_2", "entry_price")
+	values.Set("column_3", "entry_quantity")
+	req = httptest.NewRequest(http.MethodPost, action, strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "成功匯入 1 筆") {
+		t.Fatalf("expected an import report, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if trades, _ := svc.List(testContext()); len(trades) != 1 || trades[0].Instrument != "TSLA" {
+		t.Fatalf("expected the TSLA trade to be imported, got %v", trades)
+	}
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// incompleteCheck is one data-quality rule applied to every trade.
+type incompleteCheck struct {
+	key     string
+	reason  string
+	missing func(tr *domain.Trade) bool
+}
+
+// incompleteChecks lists the data-quality rules in the order they are reported.
+var incompleteChecks = []incompleteCheck{
+	{"no_stop_loss", "未設定停損", func(tr *domain.Trade) bool { return tr.Entry.StopLoss == nil }},
+	{"no_setup", "未填寫進場型態", func(tr *domain.Trade) bool { return strings.TrimSpace(tr.Setup) == "" }},
+	{"unreviewed", "已平倉但尚未回顧", func(tr *domain.Trade) bool { return tr.HasExited() && !tr.HasReview() }},
+	{"no_tags", "沒有標籤", func(tr *domain.Trade) bool { return len(tr.Review.Tags) == 0 }},
+}
+
+// incompleteCategory groups the trades failing a single check.
+type incompleteCategory struct {
+	Key      string   `json:"key"`
+	Reason   string   `json:"reason"`
+	Count    int      `json:"count"`
+	TradeIDs []string `json:"trade_ids"`
+}
+
+// incompleteReport lists the trades missing key data, grouped by what is
+// missing. Total counts distinct trades, since a trade can fail several checks.
+type incompleteReport struct {
+	Total      int                  `json:"total"`
+	Categories []incompleteCategory `json:"categories"`
+}
+
+func findIncomplete(trades []*domain.Trade) incompleteReport {
+	report := incompleteReport{Categories: make([]incompleteCategory, len(incompleteChecks))}
+	for i, check := range incompleteChecks {
+		report.Categories[i] = incompleteCategory{Key: check.key, Reason: check.reason, TradeIDs: []string{}}
+	}
+	for _, tr := range trades {
+		flagged := false
+		for i, check := range incompleteChecks {
+			if !check.missing(tr) {
+				continue
+			}
+			report.Categories[i].TradeIDs = append(report.Categories[i].TradeIDs, tr.ID)
+			report.Categories[i].Count++
+			flagged = true
+		}
+		if flagged {
+			report.Total++
+		}
+	}
+	return report
+}
+
+// handleAPIIncomplete reports active trades that are missing a stop loss, a
+// setup, a review after closing, or tags.
+func (s *Server) handleAPIIncomplete(w http.ResponseWriter, r *http.Request) {
+	trades, err := s.svc.List(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, findIncomplete(trades))
+}
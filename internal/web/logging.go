@@ -0,0 +1,65 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// DefaultSlowRequestThreshold is how long a request may take before it is logged as slow.
+const DefaultSlowRequestThreshold = 500 * time.Millisecond
+
+// WithSlowRequestThreshold logs a warning with the full path and query for any
+// request slower than d. Zero or negative disables the warning.
+func WithSlowRequestThreshold(d time.Duration) Option {
+	return func(s *Server) {
+		s.slowThreshold = d
+	}
+}
+
+// statusRecorder captures the response status for logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// quietPaths are polled by uptime checks or requested by browsers on their own;
+// logging them would bury the requests worth reading.
+var quietPaths = map[string]bool{
+	"/version":     true,
+	"/favicon.ico": true,
+	"/robots.txt":  true,
+}
+
+// logRequests writes one compact line per request, except for quietPaths, and a
+// WARN line for any request exceeding the slow threshold.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		elapsed := time.Since(start)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		if !quietPaths[r.URL.Path] {
+			log.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, elapsed.Round(time.Microsecond))
+		}
+		if s.slowThreshold > 0 && elapsed > s.slowThreshold {
+			log.Printf("WARN slow request: %s %s took %s (threshold %s)", r.Method, r.URL.RequestURI(), elapsed.Round(time.Millisecond), s.slowThreshold)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package web
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prevOut, prevFlags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(prevOut)
+		log.SetFlags(prevFlags)
+	})
+	return &buf
+}
+
+func TestLogRequestsWarnsOnSlowRequests(t *testing.T) {
+	buf := captureLog(t)
+	s := &Server{slowThreshold: 5 * time.Millisecond}
+	slow := s.logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	slow.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/trades?tag=swing", nil))
+	out := buf.String()
+	if !strings.Contains(out, "GET /trades 202") {
+		t.Fatalf("expected access line with status, got %q", out)
+	}
+	if !strings.Contains(out, "WARN slow request: GET /trades?tag=swing") {
+		t.Fatalf("expected slow warning with full query, got %q", out)
+	}
+}
+
+func TestLogRequestsSkipsWarningUnderThreshold(t *testing.T) {
+	buf := captureLog(t)
+	s := &Server{slowThreshold: time.Second}
+	fast := s.logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	fast.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	out := buf.String()
+	if !strings.Contains(out, "GET / 200") {
+		t.Fatalf("expected access line, got %q", out)
+	}
+	if strings.Contains(out, "WARN") {
+		t.Fatalf("did not expect slow warning, got %q", out)
+	}
+}
+
+func TestLogRequestsSkipsQuietPaths(t *testing.T) {
+	buf := captureLog(t)
+	s := &Server{slowThreshold: time.Second}
+	h := s.logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	for _, path := range []string{"/version", "/favicon.ico", "/robots.txt"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if out := buf.String(); out != "" {
+		t.Fatalf("expected health checks and browser requests not to be logged, got %q", out)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/trades", nil))
+	if out := buf.String(); !strings.Contains(out, "GET /trades 200") {
+		t.Fatalf("expected other requests to still be logged, got %q", out)
+	}
+}
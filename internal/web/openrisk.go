@@ -0,0 +1,92 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// openRiskGroup is the open risk of the open trades sharing an instrument or
+// a direction.
+type openRiskGroup struct {
+	Key    string  `json:"key"`
+	Trades int     `json:"trades"`
+	Risk   float64 `json:"risk"`
+	// SharePct is the group's part of the total open risk, in percent.
+	SharePct float64 `json:"share_pct"`
+}
+
+// openRiskBreakdown shows where the live risk is concentrated: the summed
+// TotalRiskAmount of open trades per instrument and per direction, largest
+// first. Open trades without a defined risk are counted but add no risk.
+type openRiskBreakdown struct {
+	Total        float64         `json:"total"`
+	ByInstrument []openRiskGroup `json:"by_instrument"`
+	ByDirection  []openRiskGroup `json:"by_direction"`
+}
+
+func summarizeOpenRisk(rows []tradeSummary) openRiskBreakdown {
+	var total domain.Money
+	byInstrument := map[string]*openRiskGroup{}
+	byDirection := map[string]*openRiskGroup{}
+	instrumentRisk := map[string]domain.Money{}
+	directionRisk := map[string]domain.Money{}
+	for _, row := range rows {
+		if !row.IsOpen {
+			continue
+		}
+		risk := domain.ToMoney(row.TotalRisk)
+		total += risk
+		for _, group := range []struct {
+			groups map[string]*openRiskGroup
+			sums   map[string]domain.Money
+			key    string
+		}{
+			{byInstrument, instrumentRisk, row.Instrument},
+			{byDirection, directionRisk, string(row.Direction)},
+		} {
+			g, ok := group.groups[group.key]
+			if !ok {
+				g = &openRiskGroup{Key: group.key}
+				group.groups[group.key] = g
+			}
+			g.Trades++
+			group.sums[group.key] += risk
+		}
+	}
+	return openRiskBreakdown{
+		Total:        total.Float64(),
+		ByInstrument: rankOpenRisk(byInstrument, instrumentRisk, total),
+		ByDirection:  rankOpenRisk(byDirection, directionRisk, total),
+	}
+}
+
+func rankOpenRisk(groups map[string]*openRiskGroup, sums map[string]domain.Money, total domain.Money) []openRiskGroup {
+	ranked := make([]openRiskGroup, 0, len(groups))
+	for key, g := range groups {
+		g.Risk = sums[key].Float64()
+		if total > 0 {
+			g.SharePct = g.Risk / total.Float64() * 100
+		}
+		ranked = append(ranked, *g)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Risk != ranked[j].Risk {
+			return ranked[i].Risk > ranked[j].Risk
+		}
+		return ranked[i].Key < ranked[j].Key
+	})
+	return ranked
+}
+
+func (s *Server) handleAPIOpenRisk(w http.ResponseWriter, r *http.Request) {
+	trades, err := s.svc.List(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	filtered := applyIndexFilters(trades, s.indexFilters(r), s.breakevenEpsilon)
+	rows := buildTradeSummaries(filtered, s.svc.Now(), s.breakevenEpsilon)
+	writeJSON(w, http.StatusOK, s.apiPrecision.openRisk(summarizeOpenRisk(rows)))
+}
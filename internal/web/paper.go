@@ -0,0 +1,22 @@
+package web
+
+import domain "best_trade_logs/internal/domain/trade"
+
+// Trade modes accepted by the ?mode= query parameter. The empty value keeps
+// only real trades, so paper trades never leak into real-money statistics
+// unless asked for.
+const (
+	modePaper = "paper"
+	modeAll   = "all"
+)
+
+// hasPaperTrades reports whether any trade is a paper trade and would be hidden
+// by the default mode.
+func hasPaperTrades(trades []*domain.Trade) bool {
+	for _, tr := range trades {
+		if tr.IsPaper {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package web
+
+import (
+	"fmt"
+
+	"best_trade_logs/internal/web/templates"
+)
+
+// planCaptureMetrics compares the R closed trades planned to make, from their
+// target, with the R they realised. Only trades with a stop, a target beyond
+// entry and a defined risk count, on both sides, so the ratio shows how much of
+// the planned edge was captured rather than how many trades had targets.
+type planCaptureMetrics struct {
+	Samples   int
+	PlannedR  float64
+	RealizedR float64
+	// Ratio is RealizedR / PlannedR; HasRatio is false without samples.
+	Ratio    float64
+	HasRatio bool
+}
+
+func summarizePlanCapture(rows []tradeSummary) planCaptureMetrics {
+	var metrics planCaptureMetrics
+	for _, row := range rows {
+		if row.IsOpen {
+			continue
+		}
+		planned, achieved, ok := row.RiskRewardAchieved()
+		if !ok || planned <= 0 {
+			continue
+		}
+		metrics.Samples++
+		metrics.PlannedR += planned
+		metrics.RealizedR += achieved
+	}
+	if metrics.PlannedR > 0 {
+		metrics.Ratio = metrics.RealizedR / metrics.PlannedR
+		metrics.HasRatio = true
+	}
+	return metrics
+}
+
+func planCapturePanel(d dashboardView) dashboardPanel {
+	p := d.PlanCapture
+	panel := dashboardPanel{Label: "計畫捕捉率", Value: "—", Meta: "需有停損與目標價的已平倉交易"}
+	if !p.HasRatio {
+		return panel
+	}
+	panel.Value = fmt.Sprintf("%.0f%%", p.Ratio*100)
+	panel.ValueClass = signClass(p.RealizedR)
+	panel.Meta = fmt.Sprintf("實現 %s / 計畫 %s（%d 筆）", templates.FormatR(p.RealizedR, d.RPrecision), templates.FormatR(p.PlannedR, d.RPrecision), p.Samples)
+	return panel
+}
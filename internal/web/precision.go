@@ -0,0 +1,236 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+	tradesvc "best_trade_logs/internal/service/trade"
+)
+
+// APIPrecision sets how the JSON API rounds each field category. Rounding is
+// opt-in: a negative number of decimals, or TickPrices unset, leaves that
+// category as stored. Rates such as FX rates, fee rates and probabilities are
+// always emitted as stored.
+type APIPrecision struct {
+	// TickPrices rounds every price, including fills, mark and follow-up
+	// prices, to the instrument's configured tick size. Instruments without a
+	// tick keep full precision.
+	TickPrices bool
+	// Quantity covers entry, exit and fill sizes.
+	Quantity int
+	// Amount covers fees, risk budgets, account sizes and results.
+	Amount int
+	// Ratio covers R multiples, percentages and day counts.
+	Ratio int
+}
+
+// DefaultAPIPrecision rounds nothing, so a client that reads a trade and
+// writes it back does not lose precision.
+var DefaultAPIPrecision = APIPrecision{Quantity: -1, Amount: -1, Ratio: -1}
+
+// ParseAPIPrecision overrides DefaultAPIPrecision from a list such as
+// "price=tick,amount=2". Prices only round to the instrument tick; quantity,
+// amount and ratio take a number of decimals.
+func ParseAPIPrecision(raw string) (APIPrecision, error) {
+	p := DefaultAPIPrecision
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, "=")
+		if strings.EqualFold(strings.TrimSpace(key), "price") {
+			if !ok || !strings.EqualFold(strings.TrimSpace(value), "tick") {
+				return p, fmt.Errorf("invalid api precision %q: prices round to the instrument tick, use price=tick", item)
+			}
+			p.TickPrices = true
+			continue
+		}
+		places, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || places > 10 {
+			return p, fmt.Errorf("invalid api precision %q", item)
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "quantity":
+			p.Quantity = places
+		case "amount":
+			p.Amount = places
+		case "ratio":
+			p.Ratio = places
+		default:
+			return p, fmt.Errorf("unknown api precision category %q", key)
+		}
+	}
+	return p, nil
+}
+
+// WithAPIPrecision sets the rounding applied to JSON API responses.
+func WithAPIPrecision(p APIPrecision) Option {
+	return func(s *Server) {
+		s.apiPrecision = p
+	}
+}
+
+func roundPlaces(v float64, places int) float64 {
+	if places < 0 {
+		return v
+	}
+	scale := math.Pow10(places)
+	return math.Round(v*scale) / scale
+}
+
+func roundPtrPlaces(v *float64, places int) *float64 {
+	if v == nil {
+		return nil
+	}
+	rounded := roundPlaces(*v, places)
+	return &rounded
+}
+
+// roundTick snaps v to tick; a tick of zero leaves v as is.
+func roundTick(v, tick float64) float64 {
+	if tick <= 0 {
+		return v
+	}
+	return tradesvc.SnapToTick(v, tick)
+}
+
+func roundPtrTick(v *float64, tick float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	rounded := roundTick(*v, tick)
+	return &rounded
+}
+
+// apiTrade returns tr rounded by the server's API precision, prices on the
+// instrument's tick; the stored trade is left untouched.
+func (s *Server) apiTrade(tr *domain.Trade) *domain.Trade {
+	var tick float64
+	if s.apiPrecision.TickPrices {
+		tick, _ = s.svc.TickSize(tr)
+	}
+	return s.apiPrecision.trade(tr, tick)
+}
+
+func (s *Server) apiTrades(trades []*domain.Trade) []*domain.Trade {
+	rounded := make([]*domain.Trade, len(trades))
+	for i, tr := range trades {
+		rounded[i] = s.apiTrade(tr)
+	}
+	return rounded
+}
+
+// trade returns a rounded copy of tr with its prices snapped to tick.
+func (p APIPrecision) trade(tr *domain.Trade, tick float64) *domain.Trade {
+	cp := tr.Clone()
+	cp.Entry.Price = roundTick(tr.Entry.Price, tick)
+	cp.Entry.Quantity = roundPlaces(tr.Entry.Quantity, p.Quantity)
+	cp.Entry.Fees = roundPlaces(tr.Entry.Fees, p.Amount)
+	cp.Entry.StopLoss = roundPtrTick(tr.Entry.StopLoss, tick)
+	cp.Entry.Target = roundPtrTick(tr.Entry.Target, tick)
+	cp.Entry.RiskPerShare = roundPtrTick(tr.Entry.RiskPerShare, tick)
+	cp.Entry.PlannedEntryPrice = roundPtrTick(tr.Entry.PlannedEntryPrice, tick)
+	cp.Entry.Fills = p.fills(cp.Entry.Fills, tick)
+	cp.Exit = p.exit(tr.Exit, tick)
+	cp.MarkPrice = roundPtrTick(tr.MarkPrice, tick)
+	cp.RiskManagement.MaxRiskAmount = roundPlaces(tr.RiskManagement.MaxRiskAmount, p.Amount)
+	cp.AccountSizeAtEntry = roundPtrPlaces(tr.AccountSizeAtEntry, p.Amount)
+	cp.FinancingCost = roundPlaces(tr.FinancingCost, p.Amount)
+	for i := range cp.FollowUps {
+		cp.FollowUps[i].Price = roundTick(cp.FollowUps[i].Price, tick)
+	}
+	for i := range cp.Events {
+		cp.Events[i].Exit = p.exit(cp.Events[i].Exit, tick)
+	}
+	return cp
+}
+
+func (p APIPrecision) exit(exit *domain.ExitDetail, tick float64) *domain.ExitDetail {
+	if exit == nil {
+		return nil
+	}
+	cp := *exit
+	cp.Price = roundTick(exit.Price, tick)
+	cp.Quantity = roundPlaces(exit.Quantity, p.Quantity)
+	cp.Fees = roundPlaces(exit.Fees, p.Amount)
+	cp.Fills = p.fills(append([]domain.Fill(nil), exit.Fills...), tick)
+	return &cp
+}
+
+// fills rounds fills in place and returns them.
+func (p APIPrecision) fills(fills []domain.Fill, tick float64) []domain.Fill {
+	for i := range fills {
+		fills[i].Price = roundTick(fills[i].Price, tick)
+		fills[i].Quantity = roundPlaces(fills[i].Quantity, p.Quantity)
+	}
+	return fills
+}
+
+func (p APIPrecision) tagCloud(entries []tagCloudEntry) []tagCloudEntry {
+	for i := range entries {
+		entries[i].Net = roundPlaces(entries[i].Net, p.Amount)
+	}
+	return entries
+}
+
+func (p APIPrecision) tagCorrelation(c tagCorrelation) tagCorrelation {
+	for _, stats := range []*tagComboStats{&c.Both, &c.AOnly, &c.BOnly} {
+		stats.WinRate = roundPlaces(stats.WinRate, p.Ratio)
+		stats.Expectancy = roundPlaces(stats.Expectancy, p.Ratio)
+		stats.TotalNet = roundPlaces(stats.TotalNet, p.Amount)
+	}
+	return c
+}
+
+func (p APIPrecision) monthlySeries(points []monthlyPoint) []monthlyPoint {
+	for i := range points {
+		points[i].Net = roundPlaces(points[i].Net, p.Amount)
+		points[i].Cumulative = roundPlaces(points[i].Cumulative, p.Amount)
+	}
+	return points
+}
+
+func (p APIPrecision) extremes(e tradeExtremes) tradeExtremes {
+	for _, ex := range []*extremeTrade{e.LargestWinner, e.LargestLoser, e.BestR, e.WorstR, e.LongestHeld} {
+		if ex == nil {
+			continue
+		}
+		ex.NetResult = roundPlaces(ex.NetResult, p.Amount)
+		ex.RMultiple = roundPlaces(ex.RMultiple, p.Ratio)
+		ex.HoldDays = roundPlaces(ex.HoldDays, p.Ratio)
+	}
+	return e
+}
+
+func (p APIPrecision) dashboardMetrics(m dashboardMetrics) dashboardMetrics {
+	for _, v := range []*float64{&m.WinRate, &m.AvgR, &m.AvgHoldDays, &m.AvgReturnPct, &m.RiskUsagePct, &m.BreakevenWinRate} {
+		*v = roundPlaces(*v, p.Ratio)
+	}
+	for _, v := range []*float64{&m.TotalNet, &m.OpenFees, &m.OpenRisk, &m.OpenUnrealizedNet, &m.AvgRiskTaken, &m.AvgRiskPlanned, &m.TotalSlippage, &m.AvgWin, &m.AvgLoss} {
+		*v = roundPlaces(*v, p.Amount)
+	}
+	return m
+}
+
+func (p APIPrecision) openRisk(b openRiskBreakdown) openRiskBreakdown {
+	b.Total = roundPlaces(b.Total, p.Amount)
+	for _, groups := range [][]openRiskGroup{b.ByInstrument, b.ByDirection} {
+		for i := range groups {
+			groups[i].Risk = roundPlaces(groups[i].Risk, p.Amount)
+			groups[i].SharePct = roundPlaces(groups[i].SharePct, p.Ratio)
+		}
+	}
+	return b
+}
+
+func (p APIPrecision) holdBuckets(buckets []holdBucket) []holdBucket {
+	for i := range buckets {
+		buckets[i].WinRate = roundPlaces(buckets[i].WinRate, p.Ratio)
+		buckets[i].AvgR = roundPlaces(buckets[i].AvgR, p.Ratio)
+	}
+	return buckets
+}
@@ -0,0 +1,117 @@
+package web
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+const (
+	recentCookieName   = "recent_trades"
+	defaultRecentLimit = 5
+)
+
+// WithRecentlyViewed configures the signing secret and how many trade IDs the
+// recently viewed cookie remembers. An empty secret falls back to a random key,
+// which means the list resets whenever the server restarts.
+func WithRecentlyViewed(secret string, limit int) Option {
+	return func(s *Server) {
+		if secret != "" {
+			s.signingSecret = []byte(secret)
+		}
+		if limit >= 0 {
+			s.recentLimit = limit
+		}
+	}
+}
+
+func randomSecret() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Printf("failed to generate cookie secret: %v", err)
+	}
+	return key
+}
+
+// recordRecentlyViewed records the trade ID in the signed cookie. Callers only
+// record a trade once it has loaded, so unknown IDs never push out real ones,
+// and must do so before writing the response body.
+func (s *Server) recordRecentlyViewed(w http.ResponseWriter, r *http.Request, id string) {
+	if s.recentLimit <= 0 {
+		return
+	}
+	ids := []string{id}
+	for _, existing := range s.recentIDs(r) {
+		if existing != id && len(ids) < s.recentLimit {
+			ids = append(ids, existing)
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     recentCookieName,
+		Value:    s.signRecent(ids),
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// recentIDs returns the IDs stored in the cookie, or nil when it is missing or tampered with.
+func (s *Server) recentIDs(r *http.Request) []string {
+	cookie, err := r.Cookie(recentCookieName)
+	if err != nil {
+		return nil
+	}
+	payload, sig, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return nil
+	}
+	expected := s.sign(signPurposeRecent, payload)
+	if !hmac.Equal([]byte(sig), []byte(expected)) {
+		return nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(raw) == 0 {
+		return nil
+	}
+	return strings.Split(string(raw), ",")
+}
+
+func (s *Server) signRecent(ids []string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(strings.Join(ids, ",")))
+	return payload + "." + s.sign(signPurposeRecent, payload)
+}
+
+// Purposes mixed into signed values, so a value signed for one use is not
+// accepted by another sharing the same key.
+const (
+	signPurposeShare  = "share:"
+	signPurposeRecent = "recent:"
+)
+
+func (s *Server) sign(purpose, payload string) string {
+	mac := hmac.New(sha256.New, s.signingSecret)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// recentTrades resolves the remembered IDs, skipping trades that no longer exist.
+func (s *Server) recentTrades(ctx context.Context, r *http.Request) []*domain.Trade {
+	ids := s.recentIDs(r)
+	if len(ids) == 0 {
+		return nil
+	}
+	trades, _, err := s.svc.GetMany(ctx, ids)
+	if err != nil {
+		log.Printf("recently viewed lookup: %v", err)
+		return nil
+	}
+	return trades
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+	tradesvc "best_trade_logs/internal/service/trade"
+	"best_trade_logs/internal/storage"
+)
+
+func TestRecentlyViewedSkipsDeletedTrades(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := tradesvc.NewService(repo)
+	server, err := NewServer(svc, WithRecentlyViewed("test-secret", 3))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	kept := &domain.Trade{Instrument: "KEEP", Entry: domain.EntryDetail{Date: time.Now(), Price: 1, Quantity: 1}}
+	removed := &domain.Trade{Instrument: "GONE", Entry: domain.EntryDetail{Date: time.Now(), Price: 1, Quantity: 1}}
+	for _, tr := range []*domain.Trade{kept, removed} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	var cookie *http.Cookie
+	for _, id := range []string{kept.ID, removed.ID} {
+		req := httptest.NewRequest(http.MethodGet, "/trades/"+id, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == recentCookieName {
+				cookie = c
+			}
+		}
+	}
+	if cookie == nil {
+		t.Fatalf("expected recently viewed cookie to be set")
+	}
+	if err := svc.Delete(testContext(), removed.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	recent := server.recentTrades(req.Context(), req)
+	if len(recent) != 1 || recent[0].ID != kept.ID {
+		t.Fatalf("expected only the remaining trade, got %+v", recent)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/trades/does-not-exist", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown trade, got %d", rec.Code)
+	}
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == recentCookieName {
+			t.Fatalf("expected an unknown trade not to be recorded as recently viewed")
+		}
+	}
+
+	tampered := &http.Cookie{Name: recentCookieName, Value: strings.Replace(cookie.Value, ".", "x.", 1)}
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(tampered)
+	if ids := server.recentIDs(req); ids != nil {
+		t.Fatalf("expected tampered cookie to be ignored, got %v", ids)
+	}
+}
+
+func TestSignedValuesAreBoundToTheirPurpose(t *testing.T) {
+	server, err := NewServer(tradesvc.NewService(storage.NewInMemoryTradeRepository()), WithRecentlyViewed("test-secret", 3))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	// "trade:share" encodes to the same payload in both formats.
+	if _, _, ok := server.parseShareToken(server.signRecent([]string{"trade:share"})); ok {
+		t.Fatalf("expected a recently viewed cookie not to work as a share token")
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: recentCookieName, Value: server.shareToken("trade", "share")})
+	if ids := server.recentIDs(req); ids != nil {
+		t.Fatalf("expected a share token not to work as a recently viewed cookie, got %v", ids)
+	}
+}
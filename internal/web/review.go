@@ -0,0 +1,45 @@
+package web
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// reviewNudgeWindowDays is how long after exit an unreviewed trade keeps being surfaced.
+const reviewNudgeWindowDays = 7
+
+// reviewNudge is a recently closed trade that still lacks a written review.
+type reviewNudge struct {
+	Trade         *domain.Trade
+	DaysSinceExit int
+	// Opacity fades the prompt from fully visible on exit day towards the end of the window.
+	Opacity string
+}
+
+// reviewNudges lists closed, unreviewed trades exited within the last week,
+// most recent first so the freshest trades bubble up.
+func reviewNudges(trades []*domain.Trade, now time.Time) []reviewNudge {
+	var nudges []reviewNudge
+	for _, tr := range trades {
+		if tr.HasReview() {
+			continue
+		}
+		days, ok := tr.DaysSinceExit(now)
+		if !ok || days > reviewNudgeWindowDays {
+			continue
+		}
+		fade := 1 - 0.6*float64(days)/float64(reviewNudgeWindowDays)
+		nudges = append(nudges, reviewNudge{
+			Trade:         tr,
+			DaysSinceExit: days,
+			Opacity:       strconv.FormatFloat(fade, 'f', 2, 64),
+		})
+	}
+	sort.SliceStable(nudges, func(i, j int) bool {
+		return nudges[i].DaysSinceExit < nudges[j].DaysSinceExit
+	})
+	return nudges
+}
@@ -0,0 +1,52 @@
+package web
+
+// DefaultMinSamples is how many observations a statistic needs before the
+// dashboard shows it.
+const DefaultMinSamples = 5
+
+// WithMinSamples sets how many observations a dashboard statistic, and a setup
+// in the expectancy ranking, needs before it is shown; below that the value is
+// reported as insufficient data. Values below one keep the default.
+func WithMinSamples(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.minSamples = n
+		}
+	}
+}
+
+// Statistics of dashboardMetrics that can be flagged as insufficient.
+const (
+	statWinRate          = "win_rate"
+	statBreakevenWinRate = "breakeven_win_rate"
+	statAvgR             = "avg_r"
+	statAvgReturn        = "avg_return"
+	statHoldDays         = "hold_days"
+	statRiskUsage        = "risk_usage"
+)
+
+// withMinSamples flags the statistics backed by fewer than n observations.
+// Totals and counts are exact whatever the sample size and are never flagged.
+func (m dashboardMetrics) withMinSamples(n int) dashboardMetrics {
+	m.MinSamples = n
+	m.Insufficient = make(map[string]bool)
+	decided := m.Wins + m.Losses
+	for stat, samples := range map[string]int{
+		statWinRate:          decided,
+		statBreakevenWinRate: decided,
+		statAvgR:             m.RSamples,
+		statAvgReturn:        m.Closed,
+		statHoldDays:         m.HoldSamples,
+		statRiskUsage:        m.RiskSamples,
+	} {
+		if samples < n {
+			m.Insufficient[stat] = true
+		}
+	}
+	return m
+}
+
+// Enough reports whether stat has enough samples to be shown.
+func (m dashboardMetrics) Enough(stat string) bool {
+	return !m.Insufficient[stat]
+}
@@ -0,0 +1,46 @@
+package web
+
+import (
+	"fmt"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// Thresholds for the pre-trade sanity panel, as percentages of the entry price.
+const (
+	tightStopPercent = 0.5
+	farTargetPercent = 20.0
+)
+
+// sanityCheck summarises how far the stop and target sit from entry and flags
+// levels that are likely mistakes.
+type sanityCheck struct {
+	StopPercent      *float64
+	TargetPercent    *float64
+	Warnings         []string
+	TightStopPercent float64
+	FarTargetPercent float64
+}
+
+func buildSanityCheck(tr *domain.Trade) sanityCheck {
+	check := sanityCheck{TightStopPercent: tightStopPercent, FarTargetPercent: farTargetPercent}
+	if v, ok := tr.StopDistancePercent(); ok {
+		check.StopPercent = &v
+		switch {
+		case v <= 0:
+			check.Warnings = append(check.Warnings, "停損位於進場價的獲利方向，請確認方向與價格")
+		case v < tightStopPercent:
+			check.Warnings = append(check.Warnings, fmt.Sprintf("停損距離僅 %.2f%%，容易被雜訊掃出", v))
+		}
+	}
+	if v, ok := tr.TargetDistancePercent(); ok {
+		check.TargetPercent = &v
+		switch {
+		case v <= 0:
+			check.Warnings = append(check.Warnings, "目標價位於進場價的虧損方向，請確認方向與價格")
+		case v > farTargetPercent:
+			check.Warnings = append(check.Warnings, fmt.Sprintf("目標距離達 %.2f%%，請確認是否合理", v))
+		}
+	}
+	return check
+}
@@ -0,0 +1,21 @@
+package web
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// isImageURL reports whether a screenshot link points straight at an image, so
+// the detail page can show a thumbnail instead of only a link.
+func isImageURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp":
+		return true
+	}
+	return false
+}
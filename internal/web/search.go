@@ -0,0 +1,138 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"unicode"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// searchSnippetRunes is how much context is kept on each side of a match.
+const searchSnippetRunes = 30
+
+// textField is one labelled free-text field of a trade.
+type textField struct {
+	Label string
+	Value string
+}
+
+// tradeTextFields lists the journal text of a trade in display order.
+func tradeTextFields(tr *domain.Trade) []textField {
+	fields := []textField{
+		{"商品", tr.Instrument},
+		{"策略", tr.Setup},
+		{"進場備註", tr.Entry.Notes},
+		{"交易假設", tr.RiskManagement.Thesis},
+		{"交易計畫", tr.RiskManagement.Plan},
+		{"檢查清單確認", tr.RiskManagement.Checklist},
+		{"部位規模計算", tr.RiskManagement.PositionSizing},
+		{"應變方案", tr.RiskManagement.ContingencyPlan},
+		{"結果摘要", tr.Review.OutcomeSummary},
+		{"心理狀態", tr.Review.Psychology},
+		{"待改進處", tr.Review.Improvements},
+		{"市場背景", tr.MarketContext},
+		{"其他備註", tr.AdditionalNotes},
+	}
+	if tr.Exit != nil {
+		fields = append(fields, textField{"出場原因", tr.Exit.Reason}, textField{"出場備註", tr.Exit.Notes})
+	}
+	for _, fu := range tr.FollowUps {
+		fields = append(fields, textField{"後續追蹤", fu.Notes})
+	}
+	return fields
+}
+
+// searchHit is one field of a trade matching the query, split around the first
+// match so the template can highlight it.
+type searchHit struct {
+	Trade  *domain.Trade
+	Field  string
+	Before string
+	Match  string
+	After  string
+}
+
+// searchTrades returns a hit per matching text field, case-insensitively, in
+// trade order.
+func searchTrades(trades []*domain.Trade, query string) []searchHit {
+	needle := []rune(strings.TrimSpace(query))
+	if len(needle) == 0 {
+		return nil
+	}
+	var hits []searchHit
+	for _, tr := range trades {
+		for _, field := range tradeTextFields(tr) {
+			if hit, ok := matchSnippet(field.Value, needle); ok {
+				hit.Trade = tr
+				hit.Field = field.Label
+				hits = append(hits, hit)
+			}
+		}
+	}
+	return hits
+}
+
+// matchSnippet finds needle in text and keeps a window of context around it.
+func matchSnippet(text string, needle []rune) (searchHit, bool) {
+	runes := []rune(text)
+	idx := indexFold(runes, needle)
+	if idx < 0 {
+		return searchHit{}, false
+	}
+	start := max(0, idx-searchSnippetRunes)
+	end := min(len(runes), idx+len(needle)+searchSnippetRunes)
+	hit := searchHit{
+		Before: string(runes[start:idx]),
+		Match:  string(runes[idx : idx+len(needle)]),
+		After:  string(runes[idx+len(needle) : end]),
+	}
+	if start > 0 {
+		hit.Before = "…" + hit.Before
+	}
+	if end < len(runes) {
+		hit.After += "…"
+	}
+	return hit, true
+}
+
+// indexFold returns the rune index of the first case-insensitive occurrence of
+// needle in haystack, or -1.
+func indexFold(haystack, needle []rune) int {
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j, r := range needle {
+			if unicode.ToLower(haystack[i+j]) != unicode.ToLower(r) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	trades, err := s.svc.ListWithArchived(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	data := struct {
+		Title string
+		Query string
+		Hits  []searchHit
+	}{
+		Title: "搜尋筆記",
+		Query: query,
+		Hits:  searchTrades(trades, query),
+	}
+	s.render(w, "search.gohtml", data)
+}
@@ -4,15 +4,14 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"html/template"
 	"log"
 	"net/http"
 	"net/url"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
-	"unicode/utf8"
 
 	domain "best_trade_logs/internal/domain/trade"
 	tradesvc "best_trade_logs/internal/service/trade"
@@ -22,17 +21,173 @@ import (
 
 // Server wires the HTTP layer with the trade service.
 type Server struct {
-	svc       *tradesvc.Service
-	templates *templates.Engine
+	svc              *tradesvc.Service
+	templates        *templates.Engine
+	breakevenEpsilon float64
+	adminToken       string
+	signingSecret    []byte
+	recentLimit      int
+	cors             corsConfig
+	slowThreshold    time.Duration
+	dashboardMetrics []string
+	annualization    string
+	archivedInStats  bool
+	accountSize      float64
+	maxLeverage      float64
+	apiPrecision     APIPrecision
+	followUpTemplate string
+	homeView         string
+	tagOrder         string
+	staleAfter       time.Duration
+	rPrecision       int
+	shareTTL         time.Duration
+	minSamples       int
+	riskFreeRate     float64
+	marketHours      domain.MarketHours
+	exportDecimal    string
+	uploads          *pendingUploads
+}
+
+// Option customises a Server.
+type Option func(*Server)
+
+// WithBreakevenEpsilon sets the tolerance within which a closed trade's net result
+// is classified as breakeven and excluded from win/loss tallies.
+func WithBreakevenEpsilon(epsilon float64) Option {
+	return func(s *Server) {
+		if epsilon >= 0 {
+			s.breakevenEpsilon = epsilon
+		}
+	}
+}
+
+// WithLeverage sets the account size used when a trade has no
+// AccountSizeAtEntry, and the effective leverage above which trades are
+// flagged. Zero values disable the respective behaviour.
+func WithLeverage(accountSize, maxLeverage float64) Option {
+	return func(s *Server) {
+		if accountSize > 0 {
+			s.accountSize = accountSize
+		}
+		if maxLeverage > 0 {
+			s.maxLeverage = maxLeverage
+		}
+	}
+}
+
+// Annualization modes accepted by WithAnnualization.
+const (
+	AnnualizeSimple     = "simple"
+	AnnualizeCompounded = "compounded"
+	AnnualizeBoth       = "both"
+)
+
+// WithAnnualization selects how the detail page annualizes a trade's return:
+// simple scaling, compounding, or both side by side.
+func WithAnnualization(mode string) Option {
+	return func(s *Server) {
+		s.annualization = strings.ToLower(strings.TrimSpace(mode))
+	}
+}
+
+// Home views accepted by WithHomeView.
+const (
+	HomeViewHistory = "history"
+	HomeViewOpen    = "open"
+)
+
+// WithHomeView selects what "/" shows when no filters are given: the full trade
+// history or only open positions.
+func WithHomeView(view string) Option {
+	return func(s *Server) {
+		s.homeView = strings.ToLower(strings.TrimSpace(view))
+	}
+}
+
+// DefaultRPrecision is the number of decimals R multiples are shown with
+// unless WithRPrecision changes it; maxRPrecision is the most it accepts.
+const (
+	DefaultRPrecision = templates.DefaultRPrecision
+	maxRPrecision     = 4
+)
+
+// WithRPrecision sets how many decimals R multiples are shown with on the
+// pages, the dashboard and the share card.
+func WithRPrecision(decimals int) Option {
+	return func(s *Server) {
+		s.rPrecision = decimals
+	}
+}
+
+// WithFollowUpTemplate pre-fills the notes of the follow-up form on the detail
+// page. A literal "\n" in the template is turned into a line break so it can be
+// set from a single-line environment variable.
+func WithFollowUpTemplate(text string) Option {
+	return func(s *Server) {
+		s.followUpTemplate = strings.ReplaceAll(text, `\n`, "\n")
+	}
 }
 
 // NewServer builds a Server with embedded templates parsed.
-func NewServer(svc *tradesvc.Service) (*Server, error) {
-	tmpl, err := templates.New()
+func NewServer(svc *tradesvc.Service, opts ...Option) (*Server, error) {
+	s := &Server{
+		svc:              svc,
+		breakevenEpsilon: domain.DefaultBreakevenEpsilon,
+		signingSecret:    randomSecret(),
+		recentLimit:      defaultRecentLimit,
+		annualization:    AnnualizeSimple,
+		apiPrecision:     DefaultAPIPrecision,
+		homeView:         HomeViewHistory,
+		tagOrder:         TagOrderAlpha,
+		shareTTL:         tradesvc.DefaultShareTTL,
+		minSamples:       DefaultMinSamples,
+		rPrecision:       DefaultRPrecision,
+		exportDecimal:    ExportDecimalPoint,
+		uploads:          newPendingUploads(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.rPrecision < 0 || s.rPrecision > maxRPrecision {
+		return nil, fmt.Errorf("r precision must be between 0 and %d", maxRPrecision)
+	}
+	tmpl, err := templates.New(templates.WithRPrecision(s.rPrecision))
 	if err != nil {
 		return nil, err
 	}
-	return &Server{svc: svc, templates: tmpl}, nil
+	s.templates = tmpl
+	if err := validateDashboardMetrics(s.dashboardMetrics); err != nil {
+		return nil, err
+	}
+	switch s.annualization {
+	case "":
+		s.annualization = AnnualizeSimple
+	case AnnualizeSimple, AnnualizeCompounded, AnnualizeBoth:
+	default:
+		return nil, fmt.Errorf("unknown annualization mode %q", s.annualization)
+	}
+	switch s.homeView {
+	case "":
+		s.homeView = HomeViewHistory
+	case HomeViewHistory, HomeViewOpen:
+	default:
+		return nil, fmt.Errorf("unknown home view %q", s.homeView)
+	}
+	switch s.tagOrder {
+	case "":
+		s.tagOrder = TagOrderAlpha
+	case TagOrderAlpha, TagOrderUsage:
+	default:
+		return nil, fmt.Errorf("unknown tag order %q", s.tagOrder)
+	}
+	switch s.exportDecimal {
+	case "":
+		s.exportDecimal = ExportDecimalPoint
+	case ExportDecimalPoint, ExportDecimalComma:
+	default:
+		return nil, fmt.Errorf("unknown export decimal separator %q", s.exportDecimal)
+	}
+	return s, nil
 }
 
 // Handler exposes the configured HTTP handler.
@@ -41,8 +196,21 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/", s.handleIndex)
 	mux.HandleFunc("/trades", s.handleTrades)
 	mux.HandleFunc("/trades/new", s.handleNewTrade)
+	mux.HandleFunc("/trades/export.csv", s.handleExportCSV)
+	mux.HandleFunc("/trades/export.json", s.handleExportJSON)
+	mux.HandleFunc("/trades/import", s.handleImportPage)
+	mux.HandleFunc("/trades/import/", s.handleImportMapping)
 	mux.HandleFunc("/trades/", s.handleTradeRoutes)
-	return mux
+	mux.HandleFunc("/search", s.handleSearch)
+	mux.HandleFunc("/views", s.handleSaveView)
+	mux.HandleFunc("/views/delete", s.handleDeleteView)
+	mux.HandleFunc("/s/", s.handleSharedTrade)
+	mux.HandleFunc("/version", s.handleVersion)
+	mux.HandleFunc("/admin/normalize", s.requireAdmin(s.handleAdminNormalize))
+	mux.HandleFunc("/admin/prune", s.requireAdmin(s.handleAdminPrune))
+	mux.HandleFunc("/admin/close-stale", s.requireAdmin(s.handleAdminCloseStale))
+	mux.HandleFunc("/api/", s.withCORS(s.handleAPI))
+	return s.logRequests(mux)
 }
 
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
@@ -51,52 +219,71 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	ctx := r.Context()
-	trades, err := s.svc.List(ctx)
+	filters := s.indexFilters(r)
+	// The open-positions home applies until a status is chosen explicitly, so
+	// "全部交易" in the filter form still reaches the history.
+	openHome := s.homeView == HomeViewOpen && !r.URL.Query().Has("status")
+	if openHome {
+		filters.Status = "open"
+	}
+	all, err := s.listTrades(ctx, filters)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-
-	filters := parseIndexFilters(r)
-	filtered := applyIndexFilters(trades, filters)
-
-	summaries := make([]tradeSummary, 0, len(filtered))
-	now := time.Now().UTC()
-	for _, tr := range filtered {
-		summary := tradeSummary{
-			Trade:         tr,
-			NetResult:     tr.NetResult(),
-			ResultPercent: tr.ResultPercent(),
-			RMultiple:     tr.RMultiple(),
-			Status:        tradeStatus(tr),
-			IsOpen:        !tr.HasExited(),
-		}
-		if v, ok := tr.FollowUpChangePercent(7); ok {
-			val := v
-			summary.FollowUp7 = &val
-		}
-		if v, ok := tr.FollowUpChangePercent(30); ok {
-			val := v
-			summary.FollowUp30 = &val
-		}
-		if hold, ok := holdDays(tr, now); ok {
-			summary.HoldDays = hold
-			summary.HasHold = true
-		}
-		summaries = append(summaries, summary)
-	}
-
-	metrics := summarizeTrades(filtered, now)
-	tags := collectTags(trades)
+	trades := filterArchived(all, filters.Archived)
+	filtered := applyIndexFilters(trades, filters, s.breakevenEpsilon)
+
+	now := s.svc.Now()
+	summaries := buildTradeSummaries(filtered, now, s.breakevenEpsilon)
+	s.flagLeverage(summaries)
+	// Every stats panel reads statRows, which also covers archived trades when
+	// they are configured to count towards the statistics.
+	statRows := summaries
+	if s.archivedInStats && filters.Archived == "" {
+		statRows = buildTradeSummaries(applyIndexFilters(all, filters, s.breakevenEpsilon), now, s.breakevenEpsilon)
+		s.flagLeverage(statRows)
+	}
+	metrics := summarizeRows(statRows).withMinSamples(s.minSamples)
+	conviction := summarizeConviction(statRows)
+	adherence := summarizeAdherence(statRows)
+	sharpe := summarizeSharpe(statRows, s.riskFreeRate)
+	holdPlan := summarizeHoldPlan(statRows)
+	planCapture := summarizePlanCapture(statRows)
+	adherence.Followed = adherence.Followed.withMinSamples(s.minSamples)
+	adherence.Deviated = adherence.Deviated.withMinSamples(s.minSamples)
+	tags := collectTags(trades, s.tagOrder)
+	accounts := collectAccounts(trades)
 	data := struct {
-		Title         string
-		Trades        []tradeSummary
-		Flash         string
-		Metrics       dashboardMetrics
-		Filters       indexFilters
-		TotalTrades   int
-		VisibleTrades int
-		Tags          []string
+		Title            string
+		Trades           []tradeSummary
+		Flash            string
+		Metrics          dashboardMetrics
+		Panels           []dashboardPanel
+		Filters          indexFilters
+		TotalTrades      int
+		VisibleTrades    int
+		Tags             []TagCount
+		Accounts         []string
+		AccountBreakdown []groupMetrics
+		TagCloud         []tagCloudEntry
+		Mistakes         []mistakeCount
+		SetupRanking     setupRanking
+		ExitReasons      []exitReasonStats
+		Sessions         []sessionStats
+		Extremes         tradeExtremes
+		RecentTrades     []*domain.Trade
+		ReviewNudges     []reviewNudge
+		StaleTrades      []staleTrade
+		TimeStops        []*domain.Trade
+		TopVelocity      []velocityTrade
+		OpenRisk         openRiskBreakdown
+		Sort             string
+		VelocitySortHref template.URL
+		SavedViews       []savedViewLink
+		FilterQuery      string
+		ExportQuery      template.URL
+		OpenHome         bool
 	}{
 		Title:         "交易日誌",
 		Trades:        summaries,
@@ -106,7 +293,40 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		TotalTrades:   len(trades),
 		VisibleTrades: len(filtered),
 		Tags:          tags,
+		Accounts:      accounts,
+		TagCloud:      tagCloud(statRows, s.tagOrder),
+		Mistakes:      mistakeBreakdown(statRows),
+		SetupRanking:  rankSetups(statRows, s.minSamples),
+		ExitReasons:   exitReasonBreakdown(statRows, s.minSamples),
+		Sessions:      sessionBreakdown(statRows, s.marketHours, s.minSamples),
+		Extremes:      findExtremes(statRows),
+		RecentTrades:  s.recentTrades(ctx, r),
+		ReviewNudges:  reviewNudges(trades, now),
+		StaleTrades:   s.staleTrades(ctx),
+		TimeStops:     timeStopsReached(trades, now),
+		TopVelocity:   topVelocity(statRows),
+		OpenRisk:      summarizeOpenRisk(statRows),
+		Sort:          parseSort(r.URL.Query()),
+		SavedViews:    s.savedViewLinks(ctx, filters.Query()),
+		FilterQuery:   filters.Query(),
+		OpenHome:      openHome,
+	}
+	data.Panels = s.dashboardPanels(dashboardView{Metrics: metrics, Conviction: conviction, Adherence: adherence, Sharpe: sharpe, HoldPlan: holdPlan, PlanCapture: planCapture, Rows: statRows, RPrecision: s.rPrecision, VisibleTrades: data.VisibleTrades, TotalTrades: data.TotalTrades})
+	if len(accounts) > 0 {
+		data.AccountBreakdown = groupTrades(statRows, accountKey)
+		for i := range data.AccountBreakdown {
+			data.AccountBreakdown[i].Metrics = data.AccountBreakdown[i].Metrics.withMinSamples(s.minSamples)
+		}
 	}
+	if query := filters.Query(); query != "" {
+		data.ExportQuery = template.URL("?" + query)
+	}
+	if data.Sort == sortVelocity {
+		data.VelocitySortHref = template.URL(sortHref(data.FilterQuery, ""))
+	} else {
+		data.VelocitySortHref = template.URL(sortHref(data.FilterQuery, sortVelocity))
+	}
+	sortTradeRows(data.Trades, data.Sort)
 
 	s.render(w, "index.gohtml", data)
 }
@@ -127,11 +347,12 @@ func (s *Server) handleNewTrade(w http.ResponseWriter, r *http.Request) {
 	}
 	tr := &domain.Trade{}
 	tr.Direction = domain.DirectionLong
+	tr.HedgeOf = strings.TrimSpace(r.URL.Query().Get("hedge_of"))
 	data := map[string]interface{}{
 		"Title":  "新增交易",
 		"Trade":  tr,
 		"Action": "/trades",
-		"Form":   newTradeFormData(tr, true),
+		"Form":   s.tradeFormData(tr, true),
 	}
 	s.render(w, "trade_form.gohtml", data)
 }
@@ -147,6 +368,8 @@ func (s *Server) handleTradeRoutes(w http.ResponseWriter, r *http.Request) {
 	switch {
 	case len(parts) == 1 && r.Method == http.MethodGet:
 		s.handleShowTrade(w, r, id)
+	case len(parts) == 2 && parts[1] == "card.png" && r.Method == http.MethodGet:
+		s.handleTradeCard(w, r, id)
 	case len(parts) == 2 && parts[1] == "edit" && r.Method == http.MethodGet:
 		s.handleEditTrade(w, r, id)
 	case len(parts) == 2 && parts[1] == "update" && r.Method == http.MethodPost:
@@ -155,6 +378,17 @@ func (s *Server) handleTradeRoutes(w http.ResponseWriter, r *http.Request) {
 		s.handleDeleteTrade(w, r, id)
 	case len(parts) == 2 && parts[1] == "followups" && r.Method == http.MethodPost:
 		s.handleAddFollowUp(w, r, id)
+	case len(parts) == 3 && parts[1] == "followups" && parts[2] == "sort" && r.Method == http.MethodPost:
+		s.handleSortFollowUps(w, r, id)
+	case len(parts) == 2 && parts[1] == "reopen" && r.Method == http.MethodPost:
+		s.handleReopenTrade(w, r, id)
+	case len(parts) == 2 && parts[1] == "split" && r.Method == http.MethodPost:
+		s.handleSplitTrade(w, r, id)
+	case len(parts) == 2 && parts[1] == "share" && r.Method == http.MethodPost:
+		s.handleCreateShare(w, r, id)
+	case len(parts) == 3 && parts[1] == "share" && r.Method == http.MethodDelete,
+		len(parts) == 4 && parts[1] == "share" && parts[3] == "delete" && r.Method == http.MethodPost:
+		s.handleRevokeShare(w, r, id, parts[2])
 	default:
 		http.NotFound(w, r)
 	}
@@ -170,11 +404,36 @@ func (s *Server) handleCreateTrade(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, strings.Join(errs, "; "), http.StatusBadRequest)
 		return
 	}
-	if err := s.svc.Create(r.Context(), tr); err != nil {
+	var duplicate *domain.Trade
+	if r.URL.Query().Get("force") != "1" {
+		matches, err := s.svc.FindPossibleDuplicates(r.Context(), tr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(matches) > 0 {
+			duplicate = matches[0]
+		}
+	}
+	exposureWarnings, err := s.svc.ExposureWarnings(r.Context(), tr)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	http.Redirect(w, r, fmt.Sprintf("/trades/%s?flash=%s", tr.ID, url.QueryEscape("交易已建立")), http.StatusSeeOther)
+	warnings := append(s.svc.TickWarnings(tr), exposureWarnings...)
+	if err := s.svc.Create(r.Context(), tr); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, tradesvc.ErrInvalidHedge) || errors.Is(err, tradesvc.ErrInvalidScreenshotURL) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	target := fmt.Sprintf("/trades/%s?flash=%s", tr.ID, url.QueryEscape(s.savedFlash("交易已建立", tr, warnings...)))
+	if duplicate != nil {
+		target += "&duplicate=" + url.QueryEscape(duplicate.ID)
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
 }
 
 func (s *Server) handleShowTrade(w http.ResponseWriter, r *http.Request, id string) {
@@ -187,8 +446,15 @@ func (s *Server) handleShowTrade(w http.ResponseWriter, r *http.Request, id stri
 		http.Error(w, err.Error(), status)
 		return
 	}
+	s.recordRecentlyViewed(w, r, tr.ID)
 
 	metrics := buildTradeMetrics(tr, r.URL.Query().Get("close_price"))
+	metrics.applyWhatIf(tr, r.URL.Query().Get("whatif_price"), r.URL.Query().Get("whatif_quantity"))
+	metrics.applyAnnualization(tr, s.annualization)
+	metrics.applyLeverage(tr, s.accountSize, s.maxLeverage)
+	metrics.applySession(tr, s.marketHours)
+	metrics.TimeStopReached = tr.TimeStopReached(s.svc.Now())
+	metrics.ScreenshotImage = isImageURL(tr.ScreenshotURL)
 
 	data := struct {
 		Title      string
@@ -196,12 +462,31 @@ func (s *Server) handleShowTrade(w http.ResponseWriter, r *http.Request, id stri
 		Metrics    tradeMetrics
 		QueryClose *float64
 		Flash      string
+		Duplicate  *domain.Trade
+		// FollowUpTemplate pre-fills the follow-up notes field.
+		FollowUpTemplate string
+		Shares           []shareLinkView
+		Hedge            *hedgeView
 	}{
 		Title:      fmt.Sprintf("交易 - %s", tr.Instrument),
 		Trade:      tr,
 		Metrics:    metrics,
 		QueryClose: metrics.QueryClose,
 		Flash:      r.URL.Query().Get("flash"),
+
+		FollowUpTemplate: s.followUpTemplate,
+		Shares:           s.shareLinks(tr),
+	}
+	legs, err := s.svc.HedgeGroup(r.Context(), tr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data.Hedge = newHedgeView(legs)
+	if dupID := r.URL.Query().Get("duplicate"); dupID != "" && dupID != tr.ID {
+		if dup, err := s.svc.Get(r.Context(), dupID); err == nil {
+			data.Duplicate = dup
+		}
 	}
 	s.render(w, "trade_detail.gohtml", data)
 }
@@ -220,7 +505,7 @@ func (s *Server) handleEditTrade(w http.ResponseWriter, r *http.Request, id stri
 		"Title":  "編輯交易",
 		"Trade":  tr,
 		"Action": fmt.Sprintf("/trades/%s/update", tr.ID),
-		"Form":   newTradeFormData(tr, false),
+		"Form":   s.tradeFormData(tr, false),
 	}
 	s.render(w, "trade_form.gohtml", data)
 }
@@ -240,22 +525,41 @@ func (s *Server) handleUpdateTrade(w http.ResponseWriter, r *http.Request, id st
 		return
 	}
 	tr, errs := buildTradeFromForm(r)
+	if isDryRun(r) {
+		mergeExisting(tr, existing)
+		s.writeValidation(w, tr, errs)
+		return
+	}
 	if len(errs) > 0 {
 		http.Error(w, strings.Join(errs, "; "), http.StatusBadRequest)
 		return
 	}
-	tr.ID = existing.ID
-	tr.CreatedAt = existing.CreatedAt
-	tr.FollowUps = existing.FollowUps
+	mergeExisting(tr, existing)
+	tickWarnings := s.svc.TickWarnings(tr)
 	if err := s.svc.Update(r.Context(), tr); err != nil {
 		status := http.StatusInternalServerError
-		if errors.Is(err, storage.ErrNotFound) {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
 			status = http.StatusNotFound
+		case errors.Is(err, tradesvc.ErrInvalidHedge), errors.Is(err, tradesvc.ErrInvalidScreenshotURL):
+			status = http.StatusBadRequest
 		}
 		http.Error(w, err.Error(), status)
 		return
 	}
-	http.Redirect(w, r, fmt.Sprintf("/trades/%s?flash=%s", tr.ID, url.QueryEscape("交易已更新")), http.StatusSeeOther)
+	http.Redirect(w, r, fmt.Sprintf("/trades/%s?flash=%s", tr.ID, url.QueryEscape(s.savedFlash("交易已更新", tr, tickWarnings...))), http.StatusSeeOther)
+}
+
+// savedFlash appends any non-blocking service warnings, plus the extra ones
+// given, to a save confirmation.
+func (s *Server) savedFlash(message string, tr *domain.Trade, extra ...string) string {
+	if warning := s.svc.SetupWarning(tr); warning != "" {
+		message += "（" + warning + "）"
+	}
+	for _, warning := range extra {
+		message += "（" + warning + "）"
+	}
+	return message
 }
 
 func (s *Server) handleDeleteTrade(w http.ResponseWriter, r *http.Request, id string) {
@@ -295,7 +599,20 @@ func (s *Server) handleAddFollowUp(w http.ResponseWriter, r *http.Request, id st
 		http.Error(w, "價格格式錯誤", http.StatusBadRequest)
 		return
 	}
-	follow := domain.FollowUp{DaysAfter: days, Price: price, Notes: strings.TrimSpace(r.FormValue("notes"))}
+	notes := strings.TrimSpace(strings.ReplaceAll(r.FormValue("notes"), "\r\n", "\n"))
+	if notes == strings.TrimSpace(s.followUpTemplate) {
+		// The pre-filled template was submitted untouched.
+		notes = ""
+	}
+	follow := domain.FollowUp{DaysAfter: days, Price: price, Notes: notes}
+	if loggedOn := strings.TrimSpace(r.FormValue("logged_on")); loggedOn != "" {
+		dt, err := time.Parse("2006-01-02", loggedOn)
+		if err != nil {
+			http.Error(w, "紀錄日期格式錯誤", http.StatusBadRequest)
+			return
+		}
+		follow.LoggedAt = dt
+	}
 	if err := s.svc.AddFollowUp(r.Context(), id, follow); err != nil {
 		status := http.StatusInternalServerError
 		if errors.Is(err, storage.ErrNotFound) {
@@ -307,6 +624,74 @@ func (s *Server) handleAddFollowUp(w http.ResponseWriter, r *http.Request, id st
 	http.Redirect(w, r, fmt.Sprintf("/trades/%s?flash=%s", id, url.QueryEscape("已新增後續追蹤")), http.StatusSeeOther)
 }
 
+// handleSortFollowUps persists the follow-ups in day order and drops duplicate
+// observations for the same day count.
+func (s *Server) handleSortFollowUps(w http.ResponseWriter, r *http.Request, id string) {
+	removed, err := s.svc.SortFollowUps(r.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	flash := "已依天數整理後續追蹤"
+	if removed > 0 {
+		flash = fmt.Sprintf("%s，移除 %d 筆同天數的舊紀錄", flash, removed)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/trades/%s?flash=%s", id, url.QueryEscape(flash)), http.StatusSeeOther)
+}
+
+func (s *Server) handleReopenTrade(w http.ResponseWriter, r *http.Request, id string) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "表單格式錯誤", http.StatusBadRequest)
+		return
+	}
+	removeFollowUps := r.FormValue("remove_follow_ups") == "1"
+	if _, err := s.svc.Reopen(r.Context(), id, removeFollowUps); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, tradesvc.ErrTradeOpen):
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/trades/%s?flash=%s", id, url.QueryEscape("交易已重新開啟")), http.StatusSeeOther)
+}
+
+// handleSplitTrade moves part of an open trade's quantity into a new linked
+// trade and shows the new portion.
+func (s *Server) handleSplitTrade(w http.ResponseWriter, r *http.Request, id string) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "表單格式錯誤", http.StatusBadRequest)
+		return
+	}
+	quantity, err := parseRequiredFloat(r.FormValue("quantity"))
+	if err != nil {
+		http.Error(w, "拆分數量格式錯誤", http.StatusBadRequest)
+		return
+	}
+	_, split, err := s.svc.Split(r.Context(), id, quantity)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, tradesvc.ErrTradeClosed):
+			status = http.StatusConflict
+		case errors.Is(err, tradesvc.ErrInvalidSplit):
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/trades/%s?flash=%s", split.ID, url.QueryEscape("已拆分為新交易")), http.StatusSeeOther)
+}
+
 func (s *Server) render(w http.ResponseWriter, name string, data interface{}) {
 	var buf bytes.Buffer
 	if err := s.templates.ExecuteTemplate(&buf, name, data); err != nil {
@@ -320,39 +705,176 @@ func (s *Server) render(w http.ResponseWriter, name string, data interface{}) {
 	}
 }
 
+// tradeSummary carries a trade together with its derived metrics so they are
+// computed once per request and shared by the dashboard and the table rows.
 type tradeSummary struct {
 	*domain.Trade
 	NetResult     float64
 	ResultPercent float64
 	RMultiple     float64
+	TotalRisk     float64
 	FollowUp7     *float64
 	FollowUp30    *float64
 	Status        string
+	Outcome       domain.Outcome
 	HoldDays      float64
 	HasHold       bool
 	IsOpen        bool
+	HighLeverage  bool
+	// TimeStopReached flags an open trade held past its time stop.
+	TimeStopReached bool
+	// ProfitPerDay is the net result per day held; see domain.Trade.ProfitPerDay.
+	ProfitPerDay    float64
+	HasProfitPerDay bool
 }
 
 type tradeMetrics struct {
-	Net           float64
-	NetPercent    float64
-	RMultiple     float64
-	TotalRisk     float64
-	TargetR       float64
-	FollowUp7     *float64
-	FollowUp30    *float64
-	Unrealized    float64
-	UnrealizedPct float64
-	QueryClose    *float64
+	Net            float64
+	NetPercent     float64
+	RMultiple      float64
+	TotalRisk      float64
+	TargetR        float64
+	RiskReward     string
+	FeeRatePercent float64
+	// CostBasis and Proceeds are the accounting view of the closed portion,
+	// fees included; see domain.Trade.CostBasis.
+	CostBasis float64
+	Proceeds  float64
+	// Session is the label of the trading session the trade was entered in;
+	// empty when unknown.
+	Session string
+	// TimeStopReached flags an open trade held past its time stop.
+	TimeStopReached bool
+	// ScreenshotImage is set when the screenshot link is an image that can be
+	// shown as a thumbnail.
+	ScreenshotImage bool
+	// AnnualizedSimple and AnnualizedCompounded are set according to the
+	// configured annualization mode when the trade has a valid holding period.
+	AnnualizedSimple     *float64
+	AnnualizedCompounded *float64
+	// Leverage is the effective leverage when an account size is known;
+	// HighLeverage flags it above the configured MaxLeverage.
+	Leverage      *float64
+	HighLeverage  bool
+	MaxLeverage   float64
+	Sanity        sanityCheck
+	ExpectedValue *float64
+	// EntrySlippage and EntrySlippageCost are set when a planned entry price was recorded.
+	EntrySlippage     *float64
+	EntrySlippageCost float64
+	FollowUp7         *float64
+	FollowUp30        *float64
+	Unrealized        float64
+	UnrealizedPct     float64
+	QueryClose        *float64
+	WhatIfPrice       *float64
+	WhatIfQty         *float64
+	WhatIfResult      float64
+	// RTargets lists the 1R, 2R and 3R price levels when risk per share is defined.
+	RTargets []rTarget
+	// CapturedMove is the share of the move up to the best follow-up price that
+	// the exit captured; nil until a closed trade has follow-ups.
+	CapturedMove *float64
+	// ActualHold and HoldDeviation compare a closed trade's holding period with
+	// its ExpectedHoldDays; nil without an expected hold.
+	ActualHold    *float64
+	HoldDeviation *float64
+}
+
+// rTarget is the price at which a trade reaches a given R multiple.
+type rTarget struct {
+	R     float64
+	Price float64
+}
+
+// rTargetMultiples are the reward milestones shown on the detail page.
+var rTargetMultiples = []float64{1, 2, 3}
+
+// applyWhatIf evaluates a hypothetical partial exit when both price and quantity are given.
+func (m *tradeMetrics) applyWhatIf(tr *domain.Trade, priceStr, qtyStr string) {
+	price, err := parseOptionalPtrFloat(priceStr)
+	if err != nil || price == nil {
+		return
+	}
+	qty, err := parseOptionalPtrFloat(qtyStr)
+	if err != nil || qty == nil || *qty <= 0 {
+		return
+	}
+	m.WhatIfPrice = price
+	m.WhatIfQty = qty
+	m.WhatIfResult = tr.PartialExitResult(*price, *qty)
+}
+
+// applyLeverage fills the effective leverage and flags it when above maxLeverage.
+func (m *tradeMetrics) applyLeverage(tr *domain.Trade, accountSize, maxLeverage float64) {
+	if v, ok := tr.EffectiveLeverage(accountSize); ok {
+		m.Leverage = &v
+		m.HighLeverage = maxLeverage > 0 && v > maxLeverage
+		m.MaxLeverage = maxLeverage
+	}
+}
+
+// flagLeverage marks rows whose effective leverage exceeds the configured maximum.
+func (s *Server) flagLeverage(rows []tradeSummary) {
+	if s.maxLeverage <= 0 {
+		return
+	}
+	for i := range rows {
+		if v, ok := rows[i].EffectiveLeverage(s.accountSize); ok && v > s.maxLeverage {
+			rows[i].HighLeverage = true
+		}
+	}
+}
+
+// applyAnnualization fills the annualized returns requested by mode.
+func (m *tradeMetrics) applyAnnualization(tr *domain.Trade, mode string) {
+	if mode != AnnualizeCompounded {
+		if v, ok := tr.AnnualizedReturn(); ok {
+			m.AnnualizedSimple = &v
+		}
+	}
+	if mode != AnnualizeSimple {
+		if v, ok := tr.AnnualizedReturnCompounded(); ok {
+			m.AnnualizedCompounded = &v
+		}
+	}
 }
 
 func buildTradeMetrics(tr *domain.Trade, closePrice string) tradeMetrics {
 	metrics := tradeMetrics{
-		Net:        tr.NetResult(),
-		NetPercent: tr.ResultPercent(),
-		RMultiple:  tr.RMultiple(),
-		TotalRisk:  tr.TotalRiskAmount(),
-		TargetR:    tr.EffectiveRewardTarget(),
+		Net:            tr.NetResult(),
+		NetPercent:     tr.ResultPercent(),
+		RMultiple:      tr.RMultiple(),
+		TotalRisk:      tr.TotalRiskAmount(),
+		TargetR:        tr.EffectiveRewardTarget(),
+		RiskReward:     "N/A",
+		FeeRatePercent: tr.FeeRate * 100,
+		CostBasis:      tr.CostBasis(),
+		Proceeds:       tr.Proceeds(),
+		Sanity:         buildSanityCheck(tr),
+	}
+	if planned, achieved, ok := tr.RiskRewardAchieved(); ok {
+		metrics.RiskReward = fmt.Sprintf("計畫 %.1f:1，實際 %.1f:1", planned, achieved)
+	}
+	if p := tr.RiskManagement.WinProbability; p != nil && metrics.TargetR != 0 {
+		ev := tr.ExpectedValue(*p)
+		metrics.ExpectedValue = &ev
+	}
+	if slip, ok := tr.EntrySlippage(); ok {
+		metrics.EntrySlippage = &slip
+		metrics.EntrySlippageCost, _ = tr.EntrySlippageCost()
+	}
+	for _, r := range rTargetMultiples {
+		if price, ok := tr.PriceAtR(r); ok {
+			metrics.RTargets = append(metrics.RTargets, rTarget{R: r, Price: price})
+		}
+	}
+	if v, ok := tr.CapturedMovePercent(); ok {
+		metrics.CapturedMove = &v
+	}
+	if actual, deviation, ok := tr.HoldDeviation(); ok {
+		metrics.ActualHold = &actual
+		metrics.HoldDeviation = &deviation
 	}
 	if v, ok := tr.FollowUpChangePercent(7); ok {
 		val := v
@@ -362,6 +884,9 @@ func buildTradeMetrics(tr *domain.Trade, closePrice string) tradeMetrics {
 		val := v
 		metrics.FollowUp30 = &val
 	}
+	if strings.TrimSpace(closePrice) == "" && tr.MarkPrice != nil && !tr.HasExited() {
+		closePrice = strconv.FormatFloat(*tr.MarkPrice, 'f', -1, 64)
+	}
 	if strings.TrimSpace(closePrice) != "" {
 		if v, err := strconv.ParseFloat(strings.TrimSpace(closePrice), 64); err == nil {
 			metrics.Unrealized = tr.UnrealizedResult(v)
@@ -377,48 +902,172 @@ type indexFilters struct {
 	Direction  string
 	Status     string
 	Tag        string
+	Account    string
+	From       string
+	To         string
+	// Archived is "" (hide archived trades), "include" or "only".
+	Archived string
+	// Reviewed is "" (any), "true" (has a written review) or "false".
+	Reviewed string
+	// Mode is "" (real trades only), "paper" or "all".
+	Mode     string
+	fromDate time.Time
+	toDate   time.Time
+	// symbol is the canonical instrument when Instrument is a configured alias.
+	symbol string
 }
 
 func (f indexFilters) Active() bool {
-	return f.Instrument != "" || f.Direction != "" || f.Status != "" || f.Tag != ""
+	return f.Instrument != "" || f.Direction != "" || f.Status != "" || f.Tag != "" || f.Account != "" || f.From != "" || f.To != "" || f.Archived != "" || f.Reviewed != "" || f.Mode != ""
+}
+
+// Query serialises the active filters so links (such as exports) can carry them over.
+func (f indexFilters) Query() string {
+	values := url.Values{}
+	if f.Instrument != "" {
+		values.Set("instrument", f.Instrument)
+	}
+	if f.Direction != "" {
+		values.Set("direction", f.Direction)
+	}
+	if f.Status != "" {
+		values.Set("status", f.Status)
+	}
+	if f.Tag != "" {
+		values.Set("tag", f.Tag)
+	}
+	if f.Account != "" {
+		values.Set("account", f.Account)
+	}
+	if f.From != "" {
+		values.Set("from", f.From)
+	}
+	if f.To != "" {
+		values.Set("to", f.To)
+	}
+	if f.Archived != "" {
+		values.Set("archived", f.Archived)
+	}
+	if f.Reviewed != "" {
+		values.Set("reviewed", f.Reviewed)
+	}
+	if f.Mode != "" {
+		values.Set("mode", f.Mode)
+	}
+	return values.Encode()
 }
 
 type dashboardMetrics struct {
-	Total        int
-	Closed       int
-	Open         int
-	WinRate      float64
-	AvgR         float64
-	AvgHoldDays  float64
-	AvgReturnPct float64
-	TotalNet     float64
-	OpenRisk     float64
+	Total        int     `json:"total"`
+	Closed       int     `json:"closed"`
+	Open         int     `json:"open"`
+	Wins         int     `json:"wins"`
+	Losses       int     `json:"losses"`
+	Breakeven    int     `json:"breakeven"`
+	WinRate      float64 `json:"win_rate"`
+	AvgR         float64 `json:"avg_r"`
+	AvgHoldDays  float64 `json:"avg_hold_days"`
+	AvgReturnPct float64 `json:"avg_return_pct"`
+	// TotalNet sums the net result of closed trades only. The entry fees and
+	// financing already paid on open trades are kept apart in OpenFees so open
+	// positions do not drag the realized total down.
+	TotalNet float64 `json:"total_net"`
+	OpenFees float64 `json:"open_fees"`
+	OpenRisk float64 `json:"open_risk"`
+	// OpenUnrealizedNet values the MarkedOpen open trades that have a MarkPrice
+	// at that price; open trades without one are left out.
+	OpenUnrealizedNet float64 `json:"open_unrealized_net"`
+	MarkedOpen        int     `json:"marked_open"`
+	// RiskSamples counts trades that recorded both a stop-based risk and a planned
+	// maximum risk; only those contribute to the risk usage comparison.
+	RiskSamples    int     `json:"risk_samples"`
+	AvgRiskTaken   float64 `json:"avg_risk_taken"`
+	AvgRiskPlanned float64 `json:"avg_risk_planned"`
+	RiskUsagePct   float64 `json:"risk_usage_pct"`
+	// SlippageSamples counts trades with a planned entry price; TotalSlippage is
+	// the summed entry slippage cost of those trades.
+	SlippageSamples int     `json:"slippage_samples"`
+	TotalSlippage   float64 `json:"total_slippage"`
+	// RSamples and HoldSamples count the closed trades behind AvgR and
+	// AvgHoldDays.
+	RSamples    int `json:"r_samples"`
+	HoldSamples int `json:"hold_samples"`
+	// AvgWin and AvgLoss are the mean net result of winning and losing closed
+	// trades, AvgLoss as a positive amount. BreakevenWinRate is the win rate, in
+	// percent, at which those sizes net to zero: 1 / (1 + AvgWin/AvgLoss). It is
+	// only set when there is at least one win and one loss.
+	AvgWin           float64 `json:"avg_win"`
+	AvgLoss          float64 `json:"avg_loss"`
+	BreakevenWinRate float64 `json:"breakeven_win_rate"`
+	// Insufficient names the statistics backed by fewer than MinSamples
+	// observations; see withMinSamples. Both are unset until it is applied.
+	Insufficient map[string]bool `json:"insufficient,omitempty"`
+	MinSamples   int             `json:"min_samples,omitempty"`
 }
 
 func parseIndexFilters(r *http.Request) indexFilters {
-	q := r.URL.Query()
+	return parseFilterValues(r.URL.Query())
+}
+
+// parseFilterValues reads the trade list filters from query values, dropping
+// unknown or malformed ones.
+func parseFilterValues(q url.Values) indexFilters {
 	filters := indexFilters{
 		Instrument: strings.TrimSpace(q.Get("instrument")),
 		Direction:  strings.ToUpper(strings.TrimSpace(q.Get("direction"))),
 		Status:     strings.ToLower(strings.TrimSpace(q.Get("status"))),
 		Tag:        strings.ToLower(strings.TrimSpace(q.Get("tag"))),
+		Account:    strings.TrimSpace(q.Get("account")),
+		Archived:   strings.ToLower(strings.TrimSpace(q.Get("archived"))),
+		Mode:       strings.ToLower(strings.TrimSpace(q.Get("mode"))),
+	}
+	if filters.Archived != archivedInclude && filters.Archived != archivedOnly {
+		filters.Archived = ""
+	}
+	if filters.Mode != modePaper && filters.Mode != modeAll {
+		filters.Mode = ""
 	}
 	if filters.Direction != string(domain.DirectionLong) && filters.Direction != string(domain.DirectionShort) {
 		filters.Direction = ""
 	}
 	switch filters.Status {
-	case "open", "closed", "wins", "losses":
+	case "open", "closed", "wins", "losses", "breakeven":
 	default:
 		filters.Status = ""
 	}
 	if filters.Tag != "" {
-		filters.Tag = normalizeTag(filters.Tag)
+		filters.Tag = domain.NormalizeTag(filters.Tag)
+	}
+	if reviewed, err := strconv.ParseBool(strings.TrimSpace(q.Get("reviewed"))); err == nil {
+		filters.Reviewed = strconv.FormatBool(reviewed)
+	}
+	if from := strings.TrimSpace(q.Get("from")); from != "" {
+		if dt, err := time.Parse("2006-01-02", from); err == nil {
+			filters.From = from
+			filters.fromDate = dt
+		}
+	}
+	if to := strings.TrimSpace(q.Get("to")); to != "" {
+		if dt, err := time.Parse("2006-01-02", to); err == nil {
+			filters.To = to
+			filters.toDate = dt
+		}
+	}
+	return filters
+}
+
+// indexFilters parses the list filters and resolves an instrument alias, so
+// filtering by "TSM" also finds trades stored under its canonical symbol.
+func (s *Server) indexFilters(r *http.Request) indexFilters {
+	filters := parseIndexFilters(r)
+	if symbol := s.svc.CanonicalInstrument(filters.Instrument); symbol != filters.Instrument {
+		filters.symbol = symbol
 	}
 	return filters
 }
 
-func applyIndexFilters(trades []*domain.Trade, filters indexFilters) []*domain.Trade {
-	if !filters.Active() {
+func applyIndexFilters(trades []*domain.Trade, filters indexFilters, epsilon float64) []*domain.Trade {
+	if !filters.Active() && !hasPaperTrades(trades) {
 		return trades
 	}
 
@@ -429,13 +1078,17 @@ func applyIndexFilters(trades []*domain.Trade, filters indexFilters) []*domain.T
 			instrument := strings.ToLower(tr.Instrument)
 			market := strings.ToLower(tr.Market)
 			setup := strings.ToLower(tr.Setup)
-			if !strings.Contains(instrument, needle) && !strings.Contains(market, needle) && !strings.Contains(setup, needle) {
+			aliased := filters.symbol != "" && strings.EqualFold(tr.Instrument, filters.symbol)
+			if !aliased && !strings.Contains(instrument, needle) && !strings.Contains(market, needle) && !strings.Contains(setup, needle) {
 				continue
 			}
 		}
 		if filters.Direction != "" && string(tr.Direction) != filters.Direction {
 			continue
 		}
+		if filters.Mode != modeAll && tr.IsPaper != (filters.Mode == modePaper) {
+			continue
+		}
 		switch filters.Status {
 		case "open":
 			if tr.HasExited() {
@@ -446,18 +1099,34 @@ func applyIndexFilters(trades []*domain.Trade, filters indexFilters) []*domain.T
 				continue
 			}
 		case "wins":
-			if !tr.HasExited() || tr.NetResult() <= 0 {
+			if tr.Outcome(epsilon) != domain.OutcomeWin {
 				continue
 			}
 		case "losses":
-			if !tr.HasExited() || tr.NetResult() >= 0 {
+			if tr.Outcome(epsilon) != domain.OutcomeLoss {
 				continue
 			}
+		case "breakeven":
+			if tr.Outcome(epsilon) != domain.OutcomeBreakeven {
+				continue
+			}
+		}
+		if filters.Account != "" && !strings.EqualFold(tr.Account, filters.Account) {
+			continue
+		}
+		if filters.Reviewed != "" && strconv.FormatBool(tr.HasReview()) != filters.Reviewed {
+			continue
+		}
+		if !filters.fromDate.IsZero() && tr.Entry.Date.Before(filters.fromDate) {
+			continue
+		}
+		if !filters.toDate.IsZero() && !tr.Entry.Date.Before(filters.toDate.AddDate(0, 0, 1)) {
+			continue
 		}
 		if filters.Tag != "" {
 			match := false
 			for _, tag := range tr.Review.Tags {
-				if normalizeTag(tag) == filters.Tag {
+				if domain.NormalizeTag(tag) == filters.Tag {
 					match = true
 					break
 				}
@@ -471,80 +1140,150 @@ func applyIndexFilters(trades []*domain.Trade, filters indexFilters) []*domain.T
 	return filtered
 }
 
-func summarizeTrades(trades []*domain.Trade, now time.Time) dashboardMetrics {
+func newTradeSummary(tr *domain.Trade, now time.Time, epsilon float64) tradeSummary {
+	summary := tradeSummary{
+		Trade:         tr,
+		NetResult:     tr.NetResult(),
+		ResultPercent: tr.ResultPercent(),
+		RMultiple:     tr.RMultiple(),
+		TotalRisk:     tr.TotalRiskAmount(),
+		Status:        tradeStatus(tr),
+		Outcome:       tr.Outcome(epsilon),
+		IsOpen:        !tr.HasExited(),
+
+		TimeStopReached: tr.TimeStopReached(now),
+	}
+	if v, ok := tr.FollowUpChangePercent(7); ok {
+		val := v
+		summary.FollowUp7 = &val
+	}
+	if v, ok := tr.FollowUpChangePercent(30); ok {
+		val := v
+		summary.FollowUp30 = &val
+	}
+	if hold, ok := holdDays(tr, now); ok {
+		summary.HoldDays = hold
+		summary.HasHold = true
+	}
+	if v, ok := tr.ProfitPerDay(); ok {
+		summary.ProfitPerDay = v
+		summary.HasProfitPerDay = true
+	}
+	return summary
+}
+
+func buildTradeSummaries(trades []*domain.Trade, now time.Time, epsilon float64) []tradeSummary {
+	summaries := make([]tradeSummary, 0, len(trades))
+	for _, tr := range trades {
+		summaries = append(summaries, newTradeSummary(tr, now, epsilon))
+	}
+	return summaries
+}
+
+func summarizeTrades(trades []*domain.Trade, now time.Time, epsilon float64) dashboardMetrics {
+	return summarizeRows(buildTradeSummaries(trades, now, epsilon))
+}
+
+// summarizeRows aggregates precomputed trade summaries into dashboard metrics.
+// Money totals are summed as domain.Money so they do not drift with the number
+// of trades.
+func summarizeRows(rows []tradeSummary) dashboardMetrics {
 	metrics := dashboardMetrics{}
-	metrics.Total = len(trades)
-	if len(trades) == 0 {
+	metrics.Total = len(rows)
+	if len(rows) == 0 {
 		return metrics
 	}
 
-	var winCount int
 	var rTotal float64
 	var rSamples int
 	var holdTotal float64
 	var holdSamples int
 	var returnTotal float64
 	var returnSamples int
-
-	for _, tr := range trades {
-		net := tr.NetResult()
-		metrics.TotalNet += net
-		if tr.HasExited() {
+	var netTotal, slippageTotal, openRiskTotal domain.Money
+	var riskTakenTotal, riskPlannedTotal domain.Money
+	var winTotal, lossTotal domain.Money
+	var openFeesTotal, unrealizedTotal domain.Money
+
+	for _, row := range rows {
+		if cost, ok := row.EntrySlippageCost(); ok {
+			slippageTotal += domain.ToMoney(cost)
+			metrics.SlippageSamples++
+		}
+		if row.TotalRisk > 0 && row.RiskManagement.MaxRiskAmount > 0 {
+			riskTakenTotal += domain.ToMoney(row.TotalRisk)
+			riskPlannedTotal += domain.ToMoney(row.RiskManagement.MaxRiskAmount)
+			metrics.RiskSamples++
+		}
+		if !row.IsOpen {
 			metrics.Closed++
-			if net > 0 {
-				winCount++
+			netTotal += domain.ToMoney(row.NetResult)
+			switch row.Outcome {
+			case domain.OutcomeWin:
+				metrics.Wins++
+				winTotal += domain.ToMoney(row.NetResult)
+			case domain.OutcomeLoss:
+				metrics.Losses++
+				lossTotal -= domain.ToMoney(row.NetResult)
+			case domain.OutcomeBreakeven:
+				metrics.Breakeven++
 			}
-			if tr.TotalRiskAmount() > 0 {
-				rTotal += tr.RMultiple()
+			if row.TotalRisk > 0 {
+				rTotal += row.RMultiple
 				rSamples++
 			}
-			if hold, ok := holdDays(tr, now); ok {
-				holdTotal += hold
+			if row.HasHold {
+				holdTotal += row.HoldDays
 				holdSamples++
 			}
-			returnTotal += tr.ResultPercent()
+			returnTotal += row.ResultPercent
 			returnSamples++
 		} else {
 			metrics.Open++
-			metrics.OpenRisk += tr.TotalRiskAmount()
+			openRiskTotal += domain.ToMoney(row.TotalRisk)
+			// An open trade's net result is the fees and financing paid so far.
+			openFeesTotal -= domain.ToMoney(row.NetResult)
+			if row.MarkPrice != nil {
+				unrealizedTotal += domain.ToMoney(row.UnrealizedResult(*row.MarkPrice))
+				metrics.MarkedOpen++
+			}
 		}
 	}
 
-	if metrics.Closed > 0 {
-		metrics.WinRate = (float64(winCount) / float64(metrics.Closed)) * 100
+	metrics.TotalNet = netTotal.Float64()
+	metrics.TotalSlippage = slippageTotal.Float64()
+	metrics.OpenRisk = openRiskTotal.Float64()
+	metrics.OpenFees = openFeesTotal.Float64()
+	metrics.OpenUnrealizedNet = unrealizedTotal.Float64()
+	if decided := metrics.Wins + metrics.Losses; decided > 0 {
+		metrics.WinRate = (float64(metrics.Wins) / float64(decided)) * 100
 	}
+	metrics.RSamples = rSamples
+	metrics.HoldSamples = holdSamples
 	if rSamples > 0 {
 		metrics.AvgR = rTotal / float64(rSamples)
 	}
+	if metrics.Wins > 0 {
+		metrics.AvgWin = winTotal.Float64() / float64(metrics.Wins)
+	}
+	if metrics.Losses > 0 {
+		metrics.AvgLoss = lossTotal.Float64() / float64(metrics.Losses)
+	}
+	if metrics.AvgWin > 0 && metrics.AvgLoss > 0 {
+		metrics.BreakevenWinRate = 100 / (1 + metrics.AvgWin/metrics.AvgLoss)
+	}
 	if holdSamples > 0 {
 		metrics.AvgHoldDays = holdTotal / float64(holdSamples)
 	}
 	if returnSamples > 0 {
 		metrics.AvgReturnPct = returnTotal / float64(returnSamples)
 	}
-	return metrics
-}
-
-func collectTags(trades []*domain.Trade) []string {
-	seen := make(map[string]struct{})
-	for _, tr := range trades {
-		for _, tag := range tr.Review.Tags {
-			normalised := normalizeTag(tag)
-			if normalised == "" {
-				continue
-			}
-			seen[normalised] = struct{}{}
-		}
-	}
-	if len(seen) == 0 {
-		return nil
-	}
-	values := make([]string, 0, len(seen))
-	for tag := range seen {
-		values = append(values, tag)
+	if metrics.RiskSamples > 0 {
+		metrics.AvgRiskTaken = riskTakenTotal.Float64() / float64(metrics.RiskSamples)
+		metrics.AvgRiskPlanned = riskPlannedTotal.Float64() / float64(metrics.RiskSamples)
+		metrics.RiskUsagePct = (metrics.AvgRiskTaken / metrics.AvgRiskPlanned) * 100
 	}
-	sort.Strings(values)
-	return values
+	return metrics
 }
 
 func tradeStatus(tr *domain.Trade) string {
@@ -572,17 +1311,6 @@ func holdDays(tr *domain.Trade, now time.Time) (float64, bool) {
 	return duration, true
 }
 
-func normalizeTag(tag string) string {
-	trimmed := strings.TrimSpace(strings.ToLower(tag))
-	if trimmed == "" {
-		return ""
-	}
-	if !utf8.ValidString(trimmed) {
-		return ""
-	}
-	return trimmed
-}
-
 func buildTradeFromForm(r *http.Request) (*domain.Trade, []string) {
 	var errs []string
 	get := func(name string) string { return strings.TrimSpace(r.FormValue(name)) }
@@ -590,7 +1318,15 @@ func buildTradeFromForm(r *http.Request) (*domain.Trade, []string) {
 	tr := &domain.Trade{}
 	tr.Instrument = get("instrument")
 	tr.Market = get("market")
+	tr.Account = get("account")
+	tr.IsPaper = get("is_paper") != ""
+	tr.HedgeOf = get("hedge_of")
+	tr.Currency = get("currency")
+	tr.FeeCurrency = get("fee_currency")
 	tr.Setup = get("setup")
+	if choice := get("setup_choice"); choice != "" && choice != setupChoiceOther {
+		tr.Setup = choice
+	}
 	tr.Direction = domain.Direction(strings.ToUpper(get("direction")))
 	if tr.Direction != domain.DirectionLong && tr.Direction != domain.DirectionShort {
 		tr.Direction = domain.DirectionLong
@@ -606,6 +1342,17 @@ func buildTradeFromForm(r *http.Request) (*domain.Trade, []string) {
 			errs = append(errs, "進場日期格式錯誤")
 		}
 	}
+	if entryTime := get("entry_time"); entryTime != "" {
+		if at, err := time.Parse("15:04", entryTime); err == nil {
+			tr.Entry.Date = tr.Entry.Date.Add(time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute)
+		} else {
+			errs = append(errs, "進場時間格式錯誤")
+		}
+	}
+	tr.Session = domain.Session(strings.ToUpper(get("session")))
+	if tr.Session != "" && !tr.Session.Valid() {
+		errs = append(errs, "交易時段無效")
+	}
 
 	var err error
 	if tr.Entry.Price, err = parseRequiredFloat(get("entry_price")); err != nil {
@@ -617,6 +1364,20 @@ func buildTradeFromForm(r *http.Request) (*domain.Trade, []string) {
 	if tr.Entry.Fees, err = parseOptionalFloat(get("entry_fees"), 0); err != nil {
 		errs = append(errs, "進場手續費格式錯誤")
 	}
+	if strings.EqualFold(get("fee_model"), string(domain.FeeModelPercent)) {
+		tr.FeeModel = domain.FeeModelPercent
+		rate, err := parseRequiredFloat(get("fee_rate"))
+		if err != nil || rate < 0 || rate >= 100 {
+			errs = append(errs, "手續費費率格式錯誤")
+		}
+		tr.FeeRate = rate / 100
+	}
+	if tr.FeeFXRate, err = parseOptionalPtrFloat(get("fee_fx_rate")); err != nil || (tr.FeeFXRate != nil && *tr.FeeFXRate <= 0) {
+		errs = append(errs, "手續費匯率格式錯誤")
+	}
+	if tr.Entry.PlannedEntryPrice, err = parseOptionalPtrFloat(get("planned_entry_price")); err != nil {
+		errs = append(errs, "計畫進場價格式錯誤")
+	}
 	if tr.Entry.StopLoss, err = parseOptionalPtrFloat(get("entry_stop_loss")); err != nil {
 		errs = append(errs, "停損價格格式錯誤")
 	}
@@ -626,6 +1387,19 @@ func buildTradeFromForm(r *http.Request) (*domain.Trade, []string) {
 	if tr.Entry.RiskPerShare, err = parseOptionalPtrFloat(get("entry_risk")); err != nil {
 		errs = append(errs, "自訂每股風險格式錯誤")
 	}
+	if tr.MarkPrice, err = parseOptionalPtrFloat(get("mark_price")); err != nil || (tr.MarkPrice != nil && *tr.MarkPrice <= 0) {
+		errs = append(errs, "最新參考價格式錯誤")
+	}
+	if tr.ExpectedHoldDays, err = parseOptionalPtrInt(get("expected_hold_days")); err != nil || (tr.ExpectedHoldDays != nil && *tr.ExpectedHoldDays < 0) {
+		errs = append(errs, "預期持有天數格式錯誤")
+	}
+	if raw := get("time_stop_date"); raw != "" {
+		if dt, err := time.Parse("2006-01-02", raw); err == nil {
+			tr.TimeStopDate = &dt
+		} else {
+			errs = append(errs, "時間停損日期格式錯誤")
+		}
+	}
 	tr.Entry.Notes = get("entry_notes")
 
 	tr.RiskManagement = domain.RiskManagement{
@@ -638,6 +1412,12 @@ func buildTradeFromForm(r *http.Request) (*domain.Trade, []string) {
 	if tr.RiskManagement.MaxRiskAmount, err = parseOptionalFloat(get("max_risk"), 0); err != nil {
 		errs = append(errs, "最大風險格式錯誤")
 	}
+	if pct, err := parseOptionalPtrFloat(get("win_probability")); err != nil || (pct != nil && (*pct < 0 || *pct > 100)) {
+		errs = append(errs, "勝率預估需介於 0 到 100")
+	} else if pct != nil {
+		p := *pct / 100
+		tr.RiskManagement.WinProbability = &p
+	}
 
 	exitProvided := false
 	if dateStr := get("exit_date"); dateStr != "" {
@@ -676,7 +1456,11 @@ func buildTradeFromForm(r *http.Request) (*domain.Trade, []string) {
 			errs = append(errs, "出場手續費格式錯誤")
 		}
 	}
-	if reason := get("exit_reason"); reason != "" {
+	reason := get("exit_reason")
+	if choice := get("exit_reason_choice"); choice != "" && choice != exitReasonChoiceOther {
+		reason = choice
+	}
+	if reason != "" {
 		ensureExit(tr)
 		tr.Exit.Reason = reason
 		exitProvided = true
@@ -697,13 +1481,22 @@ func buildTradeFromForm(r *http.Request) (*domain.Trade, []string) {
 		OutcomeSummary: get("outcome"),
 		Psychology:     get("psychology"),
 		Improvements:   get("improvements"),
+		Mistakes:       r.Form["mistakes"],
+	}
+	switch get("followed_plan") {
+	case "yes":
+		followed := true
+		tr.Review.FollowedPlan = &followed
+	case "no":
+		followed := false
+		tr.Review.FollowedPlan = &followed
 	}
 	if tags := get("tags"); tags != "" {
 		parts := strings.Split(tags, ",")
 		seen := make(map[string]struct{})
 		var cleaned []string
 		for _, tag := range parts {
-			normalized := normalizeTag(tag)
+			normalized := domain.NormalizeTag(tag)
 			if normalized == "" {
 				continue
 			}
@@ -718,7 +1511,17 @@ func buildTradeFromForm(r *http.Request) (*domain.Trade, []string) {
 
 	tr.MarketContext = get("market_context")
 	tr.AdditionalNotes = get("additional_notes")
+	tr.ScreenshotURL = get("screenshot_url")
+	if tradesvc.ValidateScreenshotURL(tr.ScreenshotURL) != nil {
+		errs = append(errs, "截圖網址需為 http 或 https 開頭的完整網址")
+	}
 
+	if tr.FinancingCost, err = parseOptionalFloat(get("financing_cost"), 0); err != nil {
+		errs = append(errs, "隔夜利息／融資成本格式錯誤")
+	}
+	if tr.AccountSizeAtEntry, err = parseOptionalPtrFloat(get("account_size")); err != nil || (tr.AccountSizeAtEntry != nil && *tr.AccountSizeAtEntry <= 0) {
+		errs = append(errs, "帳戶規模格式錯誤")
+	}
 	if tr.ExecutionScore, err = parseOptionalPtrFloat(get("execution_score")); err != nil {
 		errs = append(errs, "執行評分格式錯誤")
 	}
@@ -729,6 +1532,9 @@ func buildTradeFromForm(r *http.Request) (*domain.Trade, []string) {
 		errs = append(errs, "出場後信心格式錯誤")
 	}
 
+	if len(errs) == 0 {
+		errs = tradeErrors(tr)
+	}
 	return tr, errs
 }
 
@@ -760,6 +1566,18 @@ func parseOptionalPtrFloat(val string) (*float64, error) {
 	return &f, nil
 }
 
+func parseOptionalPtrInt(val string) (*int, error) {
+	normalized := normalizeIntegerInput(val)
+	if normalized == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(normalized)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
 func ensureExit(tr *domain.Trade) {
 	if tr.Exit == nil {
 		tr.Exit = &domain.ExitDetail{}
@@ -769,26 +1587,42 @@ func ensureExit(tr *domain.Trade) {
 type tradeFormData struct {
 	Instrument       string
 	Market           string
+	Account          string
+	HedgeOf          string
+	Currency         string
+	FeeCurrency      string
+	FeeFXRate        string
+	FeeModel         string
+	FeeRate          string
 	Direction        string
 	Setup            string
 	EntryDate        string
+	EntryTime        string
+	Session          string
 	EntryPrice       string
 	EntryQuantity    string
 	EntryFees        string
+	PlannedEntry     string
 	EntryStopLoss    string
 	EntryTarget      string
+	MarkPrice        string
+	ExpectedHold     string
+	TimeStopDate     string
 	EntryRisk        string
 	EntryNotes       string
 	Thesis           string
 	Plan             string
 	Checklist        string
 	MaxRisk          string
+	WinProbability   string
+	ExpectedValue    string
 	PositionSizing   string
 	ContingencyPlan  string
 	ExitDate         string
 	ExitPrice        string
 	ExitQuantity     string
 	ExitFees         string
+	FinancingCost    string
 	ExitReason       string
 	ExitNotes        string
 	Outcome          string
@@ -797,15 +1631,96 @@ type tradeFormData struct {
 	Tags             string
 	MarketContext    string
 	AdditionalNotes  string
+	ScreenshotURL    string
 	ExecutionScore   string
+	AccountSize      string
 	ConfidenceBefore string
 	ConfidenceAfter  string
+	// StopExitReason and TargetExitReason are the reasons filled in when the exit
+	// is auto-filled from the recorded stop or target.
+	StopExitReason   string
+	TargetExitReason string
+	Sanity           sanityCheck
+	// KnownSetups is the configured setup taxonomy; when set the form shows a
+	// dropdown and only falls back to free text for "other".
+	KnownSetups  []string
+	SetupChoice  string
+	SetupIsKnown bool
+	// Mistakes holds the checklist options, plus any stored mistakes no longer
+	// on the checklist, with their checked state.
+	Mistakes []mistakeOption
+	// FollowedPlan is "yes", "no" or "" when plan adherence was not assessed.
+	FollowedPlan string
+	IsPaper      bool
+	// ExitReasons is the configured list of standard exit reasons; when set the
+	// form shows a dropdown and only falls back to free text for "other".
+	ExitReasons       []string
+	ExitReasonChoice  string
+	ExitReasonIsKnown bool
+}
+
+// mistakeOption is one checkbox of the mistakes checklist.
+type mistakeOption struct {
+	Label   string
+	Checked bool
+}
+
+const (
+	stopExitReason   = "停損出場"
+	targetExitReason = "達標出場"
+	// setupChoiceOther is the dropdown value that defers to the free-text setup field.
+	setupChoiceOther = "__other__"
+	// exitReasonChoiceOther does the same for the free-text exit reason.
+	exitReasonChoiceOther = "__other__"
+)
+
+// tradeFormData builds the form view model including the configured setup taxonomy.
+func (s *Server) tradeFormData(tr *domain.Trade, isNew bool) tradeFormData {
+	data := newTradeFormData(tr, isNew)
+	if isNew && tr.Entry.Date.IsZero() {
+		data.EntryDate = s.svc.Now().Format("2006-01-02")
+	}
+	data.KnownSetups = s.svc.KnownSetups()
+	data.SetupIsKnown = data.Setup == ""
+	for _, setup := range data.KnownSetups {
+		if strings.EqualFold(setup, data.Setup) {
+			data.SetupIsKnown = true
+			data.SetupChoice = setup
+		}
+	}
+	data.ExitReasons = s.svc.ExitReasons()
+	data.ExitReasonIsKnown = data.ExitReason == ""
+	for _, reason := range data.ExitReasons {
+		if strings.EqualFold(reason, tradesvc.NormalizeExitReason(data.ExitReason)) {
+			data.ExitReasonIsKnown = true
+			data.ExitReasonChoice = reason
+		}
+	}
+	selected := make(map[string]bool, len(tr.Review.Mistakes))
+	for _, m := range tr.Review.Mistakes {
+		selected[m] = true
+	}
+	for _, m := range s.svc.MistakeChecklist() {
+		data.Mistakes = append(data.Mistakes, mistakeOption{Label: m, Checked: selected[m]})
+		delete(selected, m)
+	}
+	for _, m := range tr.Review.Mistakes {
+		if selected[m] {
+			data.Mistakes = append(data.Mistakes, mistakeOption{Label: m, Checked: true})
+		}
+	}
+	return data
 }
 
 func newTradeFormData(tr *domain.Trade, isNew bool) tradeFormData {
 	data := tradeFormData{
 		Instrument:      tr.Instrument,
 		Market:          tr.Market,
+		Account:         tr.Account,
+		IsPaper:         tr.IsPaper,
+		HedgeOf:         tr.HedgeOf,
+		Currency:        tr.Currency,
+		FeeCurrency:     tr.FeeCurrency,
 		Setup:           tr.Setup,
 		Direction:       string(tr.Direction),
 		EntryNotes:      tr.Entry.Notes,
@@ -821,6 +1736,11 @@ func newTradeFormData(tr *domain.Trade, isNew bool) tradeFormData {
 		Improvements:    tr.Review.Improvements,
 		MarketContext:   tr.MarketContext,
 		AdditionalNotes: tr.AdditionalNotes,
+		ScreenshotURL:   tr.ScreenshotURL,
+
+		StopExitReason:   stopExitReason,
+		TargetExitReason: targetExitReason,
+		Sanity:           buildSanityCheck(tr),
 	}
 
 	if data.Direction == "" {
@@ -829,17 +1749,44 @@ func newTradeFormData(tr *domain.Trade, isNew bool) tradeFormData {
 
 	if !tr.Entry.Date.IsZero() {
 		data.EntryDate = tr.Entry.Date.Format("2006-01-02")
-	} else if isNew {
-		data.EntryDate = time.Now().Format("2006-01-02")
 	}
+	if tr.HasEntryTime() {
+		data.EntryTime = tr.Entry.Date.Format("15:04")
+	}
+	data.Session = string(tr.Session)
 	data.EntryPrice = formatRequiredFloat(tr.Entry.Price, 4, isNew)
 	data.EntryQuantity = formatRequiredFloat(tr.Entry.Quantity, 4, isNew)
 	data.EntryFees = formatOptionalFloat(tr.Entry.Fees, 2)
+	data.FeeFXRate = formatOptionalPtrFloat(tr.FeeFXRate, 6)
+	data.FeeModel = string(tr.FeeModel)
+	if tr.FeeModel == domain.FeeModelPercent {
+		data.FeeRate = strconv.FormatFloat(tr.FeeRate*100, 'f', -1, 64)
+	}
+	data.PlannedEntry = formatOptionalPtrFloat(tr.Entry.PlannedEntryPrice, 4)
+	if tr.Review.FollowedPlan != nil {
+		data.FollowedPlan = "no"
+		if *tr.Review.FollowedPlan {
+			data.FollowedPlan = "yes"
+		}
+	}
 	data.EntryStopLoss = formatOptionalPtrFloat(tr.Entry.StopLoss, 4)
 	data.EntryTarget = formatOptionalPtrFloat(tr.Entry.Target, 4)
+	data.MarkPrice = formatOptionalPtrFloat(tr.MarkPrice, 4)
+	if tr.ExpectedHoldDays != nil {
+		data.ExpectedHold = strconv.Itoa(*tr.ExpectedHoldDays)
+	}
+	if tr.TimeStopDate != nil {
+		data.TimeStopDate = tr.TimeStopDate.Format("2006-01-02")
+	}
 	data.EntryRisk = formatOptionalPtrFloat(tr.Entry.RiskPerShare, 4)
 
 	data.MaxRisk = formatOptionalFloat(tr.RiskManagement.MaxRiskAmount, 2)
+	if p := tr.RiskManagement.WinProbability; p != nil {
+		data.WinProbability = strconv.FormatFloat(*p*100, 'f', 1, 64)
+		if tr.EffectiveRewardTarget() != 0 {
+			data.ExpectedValue = strconv.FormatFloat(tr.ExpectedValue(*p), 'f', 2, 64)
+		}
+	}
 
 	if tr.Exit != nil {
 		if !tr.Exit.Date.IsZero() {
@@ -861,6 +1808,8 @@ func newTradeFormData(tr *domain.Trade, isNew bool) tradeFormData {
 	}
 
 	data.ExecutionScore = formatOptionalPtrFloat(tr.ExecutionScore, 1)
+	data.AccountSize = formatOptionalPtrFloat(tr.AccountSizeAtEntry, 2)
+	data.FinancingCost = formatOptionalFloat(tr.FinancingCost, 2)
 	data.ConfidenceBefore = formatOptionalPtrFloat(tr.ConfidenceBefore, 1)
 	data.ConfidenceAfter = formatOptionalPtrFloat(tr.ConfidenceAfter, 1)
 
@@ -914,7 +1863,12 @@ func normalizeNumericInput(val string) string {
 			b.WriteRune('+')
 		case r == ',' || r == '，':
 			continue
+		case r == '\'' || r == '’' || r == '＇':
+			// Swiss-style grouping: 1'000'000.
+			continue
 		case unicode.IsSpace(r):
+			// Includes the no-break, narrow no-break and thin spaces used as
+			// grouping separators.
 			continue
 		default:
 			b.WriteRune(r)
@@ -2,6 +2,8 @@ package web
 
 import (
 	"context"
+	"encoding/json"
+	"image/png"
 	"math"
 	"net/http"
 	"net/http/httptest"
@@ -13,6 +15,7 @@ import (
 	domain "best_trade_logs/internal/domain/trade"
 	tradesvc "best_trade_logs/internal/service/trade"
 	"best_trade_logs/internal/storage"
+	"best_trade_logs/internal/web/templates"
 )
 
 func TestBuildTradeFromFormParsesExit(t *testing.T) {
@@ -27,6 +30,7 @@ func TestBuildTradeFromFormParsesExit(t *testing.T) {
 	form.Set("exit_price", "110")
 	form.Set("exit_quantity", "10")
 	form.Set("exit_fees", "1")
+	form.Set("financing_cost", "3.5")
 
 	req := httptest.NewRequest(http.MethodPost, "/trades", strings.NewReader(form.Encode()))
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -44,6 +48,9 @@ func TestBuildTradeFromFormParsesExit(t *testing.T) {
 	if tr.Exit.Price != 110 {
 		t.Fatalf("unexpected exit price: %v", tr.Exit.Price)
 	}
+	if tr.FinancingCost != 3.5 {
+		t.Fatalf("unexpected financing cost: %v", tr.FinancingCost)
+	}
 }
 
 func TestHandleCreateTradePersists(t *testing.T) {
@@ -165,6 +172,1870 @@ func TestBuildTradeFromFormNormalizesInputs(t *testing.T) {
 	}
 }
 
+func TestNormalizeNumericInputGroupingSeparators(t *testing.T) {
+	cases := map[string]string{
+		"1,234,567.89":        "1234567.89",
+		"1,00,000":            "100000", // Indian grouping
+		"12,34,567.5":         "1234567.5",
+		"1'000'000":           "1000000", // Swiss grouping
+		"1’000’000.25":        "1000000.25",
+		"1 000 000":           "1000000",
+		"1\u202f000\u202f000": "1000000",
+		"1\u00a0234.5":        "1234.5",
+		"－１，２３４．５":            "-1234.5",
+		"0.0001":              "0.0001",
+	}
+	for in, want := range cases {
+		if got := normalizeNumericInput(in); got != want {
+			t.Fatalf("normalizeNumericInput(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
 func testContext() context.Context {
 	return httptest.NewRequest(http.MethodGet, "/", nil).Context()
 }
+
+func TestHandleIndexFiltersByAccount(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := tradesvc.NewService(repo)
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	for _, tr := range []*domain.Trade{
+		{Instrument: "AAPL", Account: "Margin", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: time.Now(), Price: 100, Quantity: 1}, Exit: &domain.ExitDetail{Date: time.Now(), Price: 110, Quantity: 1}},
+		{Instrument: "MSFT", Account: "Cash", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: time.Now(), Price: 100, Quantity: 1}, Exit: &domain.ExitDetail{Date: time.Now(), Price: 90, Quantity: 1}},
+	} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/?account=margin", nil)
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "帳戶績效") {
+		t.Fatalf("expected account breakdown to render")
+	}
+	if !strings.Contains(body, ">AAPL<") || strings.Contains(body, ">MSFT<") {
+		t.Fatalf("expected only the margin account trade to be listed")
+	}
+}
+
+func benchmarkTrades(n int) []*domain.Trade {
+	trades := make([]*domain.Trade, 0, n)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		stop := 95.0
+		tr := &domain.Trade{
+			Instrument: "BENCH",
+			Direction:  domain.DirectionLong,
+			Entry:      domain.EntryDetail{Date: start.AddDate(0, 0, i%365), Price: 100, Quantity: 10, Fees: 1, StopLoss: &stop},
+			FollowUps:  []domain.FollowUp{{DaysAfter: 7, Price: 104}, {DaysAfter: 30, Price: 98}},
+		}
+		if i%4 != 0 {
+			tr.Exit = &domain.ExitDetail{Date: tr.Entry.Date.AddDate(0, 0, 5), Price: 100 + float64(i%11) - 5, Quantity: 10, Fees: 1}
+		}
+		trades = append(trades, tr)
+	}
+	return trades
+}
+
+func BenchmarkIndexSummaries10k(b *testing.B) {
+	trades := benchmarkTrades(10000)
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rows := buildTradeSummaries(trades, now, domain.DefaultBreakevenEpsilon)
+		_ = summarizeRows(rows)
+		_ = groupTrades(rows, accountKey)
+	}
+}
+
+func TestSummarizeTradesRiskUsage(t *testing.T) {
+	stop := 95.0
+	trades := []*domain.Trade{
+		{
+			Direction:      domain.DirectionLong,
+			Entry:          domain.EntryDetail{Price: 100, Quantity: 10, StopLoss: &stop},
+			RiskManagement: domain.RiskManagement{MaxRiskAmount: 100},
+		},
+		{
+			Direction:      domain.DirectionLong,
+			Entry:          domain.EntryDetail{Price: 100, Quantity: 30, StopLoss: &stop},
+			RiskManagement: domain.RiskManagement{MaxRiskAmount: 100},
+		},
+		{
+			// Missing the planned risk, so it must be ignored.
+			Direction: domain.DirectionLong,
+			Entry:     domain.EntryDetail{Price: 100, Quantity: 100, StopLoss: &stop},
+		},
+	}
+
+	metrics := summarizeTrades(trades, time.Now(), domain.DefaultBreakevenEpsilon)
+	if metrics.RiskSamples != 2 {
+		t.Fatalf("expected 2 risk samples, got %d", metrics.RiskSamples)
+	}
+	if math.Abs(metrics.AvgRiskTaken-100) > 1e-9 || math.Abs(metrics.AvgRiskPlanned-100) > 1e-9 {
+		t.Fatalf("unexpected averages: taken %v planned %v", metrics.AvgRiskTaken, metrics.AvgRiskPlanned)
+	}
+	if math.Abs(metrics.RiskUsagePct-100) > 1e-9 {
+		t.Fatalf("expected 100%% usage, got %v", metrics.RiskUsagePct)
+	}
+}
+
+func TestSummarizeTradesSumsMoneyWithoutDrift(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	trades := make([]*domain.Trade, 1000)
+	var naive float64
+	for i := range trades {
+		// Each trade nets 0.10: 0.11 gross minus a 0.01 fee.
+		trades[i] = &domain.Trade{
+			Direction: domain.DirectionLong,
+			Entry:     domain.EntryDetail{Date: day, Price: 1, Quantity: 1},
+			Exit:      &domain.ExitDetail{Date: day, Price: 1.11, Quantity: 1, Fees: 0.01},
+		}
+		naive += trades[i].NetResult()
+	}
+	if naive == 100 {
+		t.Fatalf("expected the plain float sum to drift from 100")
+	}
+
+	rows := buildTradeSummaries(trades, day, domain.DefaultBreakevenEpsilon)
+	if metrics := summarizeRows(rows); metrics.TotalNet != 100 {
+		t.Fatalf("expected total net of exactly 100, got %v", metrics.TotalNet)
+	}
+	if curve := equityCurve(rows); curve[len(curve)-1].Equity != 100 {
+		t.Fatalf("expected final equity of exactly 100, got %v", curve[len(curve)-1].Equity)
+	}
+	if series := monthlySeries(rows); len(series) != 1 || series[0].Net != 100 || series[0].Cumulative != 100 {
+		t.Fatalf("expected a monthly net of exactly 100, got %+v", series)
+	}
+}
+
+func TestSummarizeTradesTotalNetExcludesOpenFees(t *testing.T) {
+	trades := []*domain.Trade{
+		{Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 1, Fees: 2}, Exit: &domain.ExitDetail{Price: 120, Quantity: 1, Fees: 2}},
+		{Direction: domain.DirectionShort, Entry: domain.EntryDetail{Price: 50, Quantity: 2, Fees: 1}, Exit: &domain.ExitDetail{Price: 55, Quantity: 2, Fees: 1}},
+		{Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 80, Quantity: 1, Fees: 4}},
+		{Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 30, Quantity: 3, Fees: 1.5}, FinancingCost: 0.5},
+	}
+
+	metrics := summarizeTrades(trades, time.Now(), domain.DefaultBreakevenEpsilon)
+	if metrics.TotalNet != 4 {
+		t.Fatalf("expected total net of the closed trades only, got %v", metrics.TotalNet)
+	}
+	if metrics.OpenFees != 6 {
+		t.Fatalf("expected open fees and financing tracked separately, got %v", metrics.OpenFees)
+	}
+	if panel := totalNetPanel(dashboardView{Metrics: metrics}); panel.Value != "4.00" {
+		t.Fatalf("unexpected total net panel: %+v", panel)
+	}
+}
+
+func TestHandleEditTradeExposesExitAutoFill(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := tradesvc.NewService(repo)
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	stop, target := 95.0, 120.0
+	tr := &domain.Trade{Instrument: "AAPL", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: time.Now(), Price: 100, Quantity: 10, StopLoss: &stop, Target: &target}}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+tr.ID+"/edit", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `data-exit-price="95.0000"`) || !strings.Contains(body, `data-exit-price="120.0000"`) {
+		t.Fatalf("expected stop and target to be exposed for exit auto-fill")
+	}
+}
+
+func TestSummarizeTradesExcludesBreakevenFromWinRate(t *testing.T) {
+	closed := func(exit float64) *domain.Trade {
+		return &domain.Trade{
+			Direction: domain.DirectionLong,
+			Entry:     domain.EntryDetail{Price: 100, Quantity: 1},
+			Exit:      &domain.ExitDetail{Price: exit, Quantity: 1},
+		}
+	}
+	trades := []*domain.Trade{closed(110), closed(90), closed(100.004), closed(99.996)}
+
+	metrics := summarizeTrades(trades, time.Now(), domain.DefaultBreakevenEpsilon)
+	if metrics.Wins != 1 || metrics.Losses != 1 || metrics.Breakeven != 2 {
+		t.Fatalf("unexpected tallies: %+v", metrics)
+	}
+	if math.Abs(metrics.WinRate-50) > 1e-9 {
+		t.Fatalf("expected 50%% win rate, got %v", metrics.WinRate)
+	}
+
+	strict := summarizeTrades(trades, time.Now(), 0)
+	if strict.Breakeven != 0 || strict.Wins != 2 || strict.Losses != 2 {
+		t.Fatalf("expected zero epsilon to classify every trade, got %+v", strict)
+	}
+}
+
+func TestSummarizeTradesBreakevenWinRate(t *testing.T) {
+	closed := func(exit float64) *domain.Trade {
+		return &domain.Trade{
+			Direction: domain.DirectionLong,
+			Entry:     domain.EntryDetail{Price: 100, Quantity: 1},
+			Exit:      &domain.ExitDetail{Price: exit, Quantity: 1},
+		}
+	}
+	// Wins average 30, losses average 10: a 25% win rate breaks even.
+	trades := []*domain.Trade{closed(120), closed(140), closed(90), closed(90), closed(100)}
+
+	metrics := summarizeTrades(trades, time.Now(), domain.DefaultBreakevenEpsilon)
+	if math.Abs(metrics.AvgWin-30) > 1e-9 || math.Abs(metrics.AvgLoss-10) > 1e-9 {
+		t.Fatalf("unexpected average win/loss: %v / %v", metrics.AvgWin, metrics.AvgLoss)
+	}
+	if math.Abs(metrics.BreakevenWinRate-25) > 1e-9 {
+		t.Fatalf("expected 25%% breakeven win rate, got %v", metrics.BreakevenWinRate)
+	}
+
+	onlyWins := summarizeTrades(trades[:2], time.Now(), domain.DefaultBreakevenEpsilon)
+	if onlyWins.BreakevenWinRate != 0 {
+		t.Fatalf("expected no breakeven win rate without losses, got %v", onlyWins.BreakevenWinRate)
+	}
+}
+
+func TestSummarizeTradesSeparatesRealizedAndOpenResults(t *testing.T) {
+	mark := 120.0
+	trades := []*domain.Trade{
+		{Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 1, Fees: 1}, Exit: &domain.ExitDetail{Price: 110, Quantity: 1, Fees: 1}},
+		{Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 2, Fees: 5}, MarkPrice: &mark},
+		{Direction: domain.DirectionShort, Entry: domain.EntryDetail{Price: 50, Quantity: 1, Fees: 3}},
+	}
+
+	metrics := summarizeTrades(trades, time.Now(), domain.DefaultBreakevenEpsilon)
+	if metrics.TotalNet != 8 {
+		t.Fatalf("expected open trades to stay out of the realized total, got %v", metrics.TotalNet)
+	}
+	if metrics.OpenUnrealizedNet != 35 || metrics.MarkedOpen != 1 {
+		t.Fatalf("expected only the marked open trade to be valued, got %v over %d trades", metrics.OpenUnrealizedNet, metrics.MarkedOpen)
+	}
+	if panel := openPnLPanel(dashboardView{Metrics: metrics}); panel.Value != "35.00" || !strings.Contains(panel.Meta, "1 / 2") {
+		t.Fatalf("unexpected open P/L panel: %+v", panel)
+	}
+}
+
+func TestSummarizeSharpeSubtractsRiskFreeRate(t *testing.T) {
+	entry := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	closed := func(exit float64) *domain.Trade {
+		return &domain.Trade{
+			Direction: domain.DirectionLong,
+			Entry:     domain.EntryDetail{Date: entry, Price: 100, Quantity: 1},
+			Exit:      &domain.ExitDetail{Date: entry.AddDate(1, 0, 0), Price: exit, Quantity: 1},
+		}
+	}
+	rows := buildTradeSummaries([]*domain.Trade{closed(110), closed(130)}, time.Now(), domain.DefaultBreakevenEpsilon)
+
+	raw := summarizeSharpe(rows, 0)
+	if !raw.HasRatio || math.Abs(raw.Ratio-20/math.Sqrt(200)) > 1e-9 {
+		t.Fatalf("unexpected Sharpe ratio without a risk-free rate: %+v", raw)
+	}
+	// A year at 5% takes five points off each trade's return.
+	excess := summarizeSharpe(rows, 5)
+	if math.Abs(excess.AvgExcess-15) > 1e-9 || math.Abs(excess.Ratio-15/math.Sqrt(200)) > 1e-9 {
+		t.Fatalf("unexpected Sharpe ratio with a 5%% risk-free rate: %+v", excess)
+	}
+	if single := summarizeSharpe(rows[:1], 0); single.HasRatio {
+		t.Fatalf("expected no ratio from a single trade")
+	}
+}
+
+func TestSummarizeHoldPlanSplitsDeviationByOutcome(t *testing.T) {
+	entry := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	planned := func(expected, held int, exit float64) *domain.Trade {
+		return &domain.Trade{
+			Direction:        domain.DirectionLong,
+			Entry:            domain.EntryDetail{Date: entry, Price: 100, Quantity: 1},
+			Exit:             &domain.ExitDetail{Date: entry.AddDate(0, 0, held), Price: exit, Quantity: 1},
+			ExpectedHoldDays: &expected,
+		}
+	}
+	trades := []*domain.Trade{
+		planned(10, 4, 110), // winner cut six days early
+		planned(5, 13, 90),  // loser held eight days too long
+		planned(5, 9, 95),   // loser held four days too long
+		{Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: entry, Price: 100, Quantity: 1}},
+	}
+	rows := buildTradeSummaries(trades, time.Now(), domain.DefaultBreakevenEpsilon)
+
+	plan := summarizeHoldPlan(rows)
+	if plan.Samples != 3 || plan.AvgDeviation != 2 {
+		t.Fatalf("unexpected overall deviation: %+v", plan)
+	}
+	if plan.Winners != 1 || plan.WinnerAvgDeviation != -6 || plan.Losers != 2 || plan.LoserAvgDeviation != 6 {
+		t.Fatalf("unexpected deviation by outcome: %+v", plan)
+	}
+	if panel := holdPlanPanel(dashboardView{HoldPlan: plan}); panel.Value != "+2.0 天" || !strings.Contains(panel.Meta, "獲利 -6.0 天 · 虧損 +6.0 天") {
+		t.Fatalf("unexpected hold plan panel: %+v", panel)
+	}
+}
+
+func TestSummarizeTradesByConviction(t *testing.T) {
+	trade := func(exit *float64, confidence *float64) *domain.Trade {
+		tr := &domain.Trade{
+			Direction:        domain.DirectionLong,
+			Entry:            domain.EntryDetail{Price: 100, Quantity: 1},
+			ConfidenceBefore: confidence,
+		}
+		if exit != nil {
+			tr.Exit = &domain.ExitDetail{Price: *exit, Quantity: 1}
+		}
+		return tr
+	}
+	f := func(v float64) *float64 { return &v }
+	trades := []*domain.Trade{
+		trade(f(110), f(9)),
+		trade(f(90), f(3)),
+		trade(f(110), nil),
+		trade(nil, f(8)),
+	}
+
+	metrics := summarizeTradesByConviction(trades, time.Now(), domain.DefaultBreakevenEpsilon)
+	if metrics.Samples != 2 {
+		t.Fatalf("expected 2 samples, got %d", metrics.Samples)
+	}
+	if math.Abs(metrics.TotalNet) > 1e-9 || math.Abs(metrics.WeightedNet-10) > 1e-9 {
+		t.Fatalf("expected flat 0 and weighted 10, got %v and %v", metrics.TotalNet, metrics.WeightedNet)
+	}
+	if math.Abs(metrics.WinRate-50) > 1e-9 || math.Abs(metrics.WeightedWinRate-75) > 1e-9 {
+		t.Fatalf("expected win rates 50/75, got %v/%v", metrics.WinRate, metrics.WeightedWinRate)
+	}
+}
+
+func TestPlanAdherenceFromFormAndDashboard(t *testing.T) {
+	form := url.Values{}
+	form.Set("instrument", "AAPL")
+	form.Set("entry_date", "2024-01-02")
+	form.Set("entry_price", "100")
+	form.Set("entry_quantity", "1")
+	form.Set("followed_plan", "no")
+	req := httptest.NewRequest(http.MethodPost, "/trades", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := req.ParseForm(); err != nil {
+		t.Fatalf("parse form: %v", err)
+	}
+	parsed, errs := buildTradeFromForm(req)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if parsed.Review.FollowedPlan == nil || *parsed.Review.FollowedPlan {
+		t.Fatalf("expected followed_plan=no to be recorded as false")
+	}
+	if got := newTradeFormData(parsed, false).FollowedPlan; got != "no" {
+		t.Fatalf("expected the form to round-trip \"no\", got %q", got)
+	}
+
+	stop := 95.0
+	trade := func(exit float64, followed *bool) *domain.Trade {
+		return &domain.Trade{
+			Direction: domain.DirectionLong,
+			Entry:     domain.EntryDetail{Price: 100, Quantity: 1, StopLoss: &stop},
+			Exit:      &domain.ExitDetail{Price: exit, Quantity: 1},
+			Review:    domain.TradeReview{FollowedPlan: followed},
+		}
+	}
+	yes, no := true, false
+	trades := []*domain.Trade{
+		trade(110, &yes), // +2R
+		trade(95, &yes),  // -1R
+		trade(95, &no),   // -1R
+		trade(120, nil),  // not assessed
+	}
+	a := summarizeTradesByAdherence(trades, time.Now(), domain.DefaultBreakevenEpsilon)
+	if a.Followed.Closed != 2 || a.Deviated.Closed != 1 || a.Samples() != 3 {
+		t.Fatalf("unexpected split: %+v", a)
+	}
+	if math.Abs(a.Followed.WinRate-50) > 1e-9 || math.Abs(a.Followed.AvgR-0.5) > 1e-9 {
+		t.Fatalf("unexpected followed stats: win %v avg R %v", a.Followed.WinRate, a.Followed.AvgR)
+	}
+	if a.Deviated.WinRate != 0 || math.Abs(a.Deviated.AvgR+1) > 1e-9 {
+		t.Fatalf("unexpected deviated stats: win %v avg R %v", a.Deviated.WinRate, a.Deviated.AvgR)
+	}
+	if panel := planAdherencePanel(dashboardView{Adherence: a, RPrecision: templates.DefaultRPrecision}); panel.Value != "0.50R / -1.00R" || panel.ValueClass != "text-positive" {
+		t.Fatalf("unexpected panel: %+v", panel)
+	}
+}
+
+func TestReviewNudgesOrdersRecentUnreviewedTrades(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	closedDaysAgo := func(instrument string, days int) *domain.Trade {
+		return &domain.Trade{Instrument: instrument, Exit: &domain.ExitDetail{Date: now.AddDate(0, 0, -days)}}
+	}
+	reviewed := closedDaysAgo("REVIEWED", 1)
+	reviewed.Review.OutcomeSummary = "done"
+	trades := []*domain.Trade{
+		closedDaysAgo("OLDER", 5),
+		closedDaysAgo("STALE", 10),
+		reviewed,
+		closedDaysAgo("FRESH", 0),
+		{Instrument: "OPEN"},
+	}
+
+	nudges := reviewNudges(trades, now)
+	if len(nudges) != 2 {
+		t.Fatalf("expected 2 nudges, got %d", len(nudges))
+	}
+	if nudges[0].Trade.Instrument != "FRESH" || nudges[1].Trade.Instrument != "OLDER" {
+		t.Fatalf("expected most recent exit first, got %s then %s", nudges[0].Trade.Instrument, nudges[1].Trade.Instrument)
+	}
+}
+
+func TestBuildTradeFromFormParsesWinProbability(t *testing.T) {
+	form := url.Values{}
+	form.Set("instrument", "AAPL")
+	form.Set("entry_date", "2024-01-02")
+	form.Set("entry_price", "100")
+	form.Set("entry_quantity", "10")
+	form.Set("entry_stop_loss", "95")
+	form.Set("entry_target", "110")
+	form.Set("win_probability", "40")
+
+	req := httptest.NewRequest(http.MethodPost, "/trades", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := req.ParseForm(); err != nil {
+		t.Fatalf("parse form: %v", err)
+	}
+	tr, errs := buildTradeFromForm(req)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if tr.RiskManagement.WinProbability == nil || math.Abs(*tr.RiskManagement.WinProbability-0.4) > 1e-9 {
+		t.Fatalf("expected win probability 0.4, got %v", tr.RiskManagement.WinProbability)
+	}
+	if got := newTradeFormData(tr, false).ExpectedValue; got != "0.20" {
+		t.Fatalf("expected form to show 0.20R expected value, got %q", got)
+	}
+}
+
+func TestHandleShowTradeRendersWhatIfResult(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := tradesvc.NewService(repo)
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	tr := &domain.Trade{Instrument: "AAPL", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: time.Now(), Price: 100, Quantity: 10, Fees: 10}}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+tr.ID+"?close_price=105&whatif_price=120&whatif_quantity=5", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, ">95.00</strong>") {
+		t.Fatalf("expected what-if result 95.00 in detail page")
+	}
+	if !strings.Contains(body, `name="close_price" value="105.0000"`) {
+		t.Fatalf("expected reference price to be preserved")
+	}
+}
+
+func TestHandleCreateTradeWarnsAboutDuplicates(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := tradesvc.NewService(repo)
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	form := url.Values{}
+	form.Set("instrument", "EURUSD")
+	form.Set("direction", "SHORT")
+	form.Set("entry_date", "2023-01-02")
+	form.Set("entry_price", "1.1")
+	form.Set("entry_quantity", "1000")
+
+	post := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.handleCreateTrade(rec, req)
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected redirect, got %d", rec.Code)
+		}
+		return rec
+	}
+
+	if loc := post("/trades").Header().Get("Location"); strings.Contains(loc, "duplicate=") {
+		t.Fatalf("did not expect duplicate warning for first trade: %s", loc)
+	}
+	loc := post("/trades").Header().Get("Location")
+	if !strings.Contains(loc, "duplicate=") {
+		t.Fatalf("expected duplicate warning, got %s", loc)
+	}
+	if loc := post("/trades?force=1").Header().Get("Location"); strings.Contains(loc, "duplicate=") {
+		t.Fatalf("expected force to skip duplicate check: %s", loc)
+	}
+
+	trades, _ := repo.List(testContext())
+	if len(trades) != 3 {
+		t.Fatalf("expected duplicates to still be saved, got %d trades", len(trades))
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, loc, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "可能與既有紀錄重複") {
+		t.Fatalf("expected duplicate warning on detail page, got %d", rec.Code)
+	}
+}
+
+func TestBuildTradeMetricsRiskReward(t *testing.T) {
+	stop, target := 98.0, 105.0
+	tr := &domain.Trade{
+		Direction: domain.DirectionLong,
+		Entry:     domain.EntryDetail{Price: 100, Quantity: 10, StopLoss: &stop, Target: &target},
+		Exit:      &domain.ExitDetail{Price: 102.2, Quantity: 10},
+	}
+	if got := buildTradeMetrics(tr, "").RiskReward; got != "計畫 2.5:1，實際 1.1:1" {
+		t.Fatalf("unexpected risk reward label: %q", got)
+	}
+	tr.Entry.StopLoss = nil
+	if got := buildTradeMetrics(tr, "").RiskReward; got != "N/A" {
+		t.Fatalf("expected N/A without stop, got %q", got)
+	}
+}
+
+func TestBuildSanityCheckFlagsTightStopAndFarTarget(t *testing.T) {
+	stop, target := 99.8, 130.0
+	tr := &domain.Trade{Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, StopLoss: &stop, Target: &target}}
+	check := buildSanityCheck(tr)
+	if check.StopPercent == nil || check.TargetPercent == nil {
+		t.Fatalf("expected both distances to be computed")
+	}
+	if len(check.Warnings) != 2 {
+		t.Fatalf("expected tight stop and far target warnings, got %v", check.Warnings)
+	}
+
+	stop, target = 97, 106
+	if check := buildSanityCheck(tr); len(check.Warnings) != 0 {
+		t.Fatalf("expected no warnings for reasonable levels, got %v", check.Warnings)
+	}
+}
+
+func TestHandleReopenTrade(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := tradesvc.NewService(repo)
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	tr := &domain.Trade{Instrument: "AAPL", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: time.Now(), Price: 100, Quantity: 1}, Exit: &domain.ExitDetail{Date: time.Now(), Price: 110, Quantity: 1}}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	reopen := func() int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/trades/"+tr.ID+"/reopen", strings.NewReader(""))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		server.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := reopen(); code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d", code)
+	}
+	if code := reopen(); code != http.StatusConflict {
+		t.Fatalf("expected conflict for already open trade, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+tr.ID, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "異動紀錄") {
+		t.Fatalf("expected audit trail on detail page, got %d", rec.Code)
+	}
+}
+
+func TestBuildTradeFromFormParsesPercentFees(t *testing.T) {
+	form := url.Values{}
+	form.Set("instrument", "BTCUSDT")
+	form.Set("entry_date", "2024-01-02")
+	form.Set("entry_price", "40000")
+	form.Set("entry_quantity", "0.5")
+	form.Set("fee_model", "PERCENT")
+	form.Set("fee_rate", "0.1")
+
+	req := httptest.NewRequest(http.MethodPost, "/trades", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	tr, errs := buildTradeFromForm(req)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if tr.FeeModel != domain.FeeModelPercent || math.Abs(tr.FeeRate-0.001) > 1e-12 {
+		t.Fatalf("expected percent fee model at 0.001, got %q %v", tr.FeeModel, tr.FeeRate)
+	}
+	if data := newTradeFormData(tr, false); data.FeeRate != "0.1" {
+		t.Fatalf("expected form to show the rate as a percent, got %q", data.FeeRate)
+	}
+
+	form.Set("fee_rate", "")
+	req = httptest.NewRequest(http.MethodPost, "/trades", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, errs := buildTradeFromForm(req); len(errs) == 0 {
+		t.Fatalf("expected missing rate to be rejected")
+	}
+}
+
+func TestCreateTradeWithKnownSetupDropdown(t *testing.T) {
+	repo := storage.NewInMemoryTradeRepository()
+	svc := tradesvc.NewService(repo, tradesvc.WithKnownSetups([]string{"Breakout", "Pullback"}))
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/new", nil))
+	if !strings.Contains(rec.Body.String(), `<option value="Pullback"`) {
+		t.Fatalf("expected known setups dropdown")
+	}
+
+	post := func(choice, other string) string {
+		form := url.Values{}
+		form.Set("instrument", "AAPL")
+		form.Set("entry_date", "2024-01-02")
+		form.Set("entry_price", "100")
+		form.Set("entry_quantity", "1")
+		form.Set("setup_choice", choice)
+		form.Set("setup", other)
+		req := httptest.NewRequest(http.MethodPost, "/trades?force=1", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.handleCreateTrade(rec, req)
+		loc, _ := url.Parse(rec.Header().Get("Location"))
+		return loc.Query().Get("flash")
+	}
+	if flash := post("Pullback", "ignored"); flash != "交易已建立" {
+		t.Fatalf("expected plain flash for known setup, got %q", flash)
+	}
+	if flash := post(setupChoiceOther, "Gap fill"); !strings.Contains(flash, "不在已知策略清單中") {
+		t.Fatalf("expected unknown setup warning, got %q", flash)
+	}
+
+	trades, _ := svc.List(testContext())
+	setups := map[string]bool{}
+	for _, tr := range trades {
+		setups[tr.Setup] = true
+	}
+	if !setups["Pullback"] || !setups["Gap fill"] {
+		t.Fatalf("unexpected stored setups: %v", setups)
+	}
+}
+
+func TestMistakesChecklistAndBreakdown(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository(), tradesvc.WithMistakeChecklist([]string{"追價進場", "移動停損"}))
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/new", nil))
+	if !strings.Contains(rec.Body.String(), `name="mistakes" value="移動停損"`) {
+		t.Fatalf("expected mistakes checkboxes on the form")
+	}
+
+	for _, selected := range [][]string{{"移動停損"}, {"移動停損", "追價進場"}} {
+		form := url.Values{}
+		form.Set("instrument", "AAPL")
+		form.Set("entry_date", "2024-01-02")
+		form.Set("entry_price", "100")
+		form.Set("entry_quantity", "1")
+		form["mistakes"] = selected
+		req := httptest.NewRequest(http.MethodPost, "/trades?force=1", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		server.handleCreateTrade(httptest.NewRecorder(), req)
+	}
+
+	rows := make([]tradeSummary, 0, 2)
+	trades, _ := svc.List(testContext())
+	for _, tr := range trades {
+		rows = append(rows, tradeSummary{Trade: tr})
+	}
+	breakdown := mistakeBreakdown(rows)
+	if len(breakdown) != 2 || breakdown[0].Mistake != "移動停損" || breakdown[0].Count != 2 || breakdown[1].Count != 1 {
+		t.Fatalf("unexpected breakdown: %+v", breakdown)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), "常見錯誤") {
+		t.Fatalf("expected mistakes breakdown on the dashboard")
+	}
+}
+
+func TestUpdateTradeValidateOnly(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	tr := &domain.Trade{Instrument: "AAPL", Entry: domain.EntryDetail{Price: 100, Quantity: 1}}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	form := url.Values{}
+	form.Set("instrument", "MSFT")
+	form.Set("entry_date", "2024-01-02")
+	form.Set("entry_price", "abc")
+	form.Set("entry_quantity", "1")
+	req := httptest.NewRequest(http.MethodPost, "/trades/"+tr.ID+"/update?validate=1", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "進場價格格式錯誤") {
+		t.Fatalf("expected validation errors, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	stored, _ := svc.Get(testContext(), tr.ID)
+	if stored.Instrument != "AAPL" {
+		t.Fatalf("expected dry run not to persist, got %q", stored.Instrument)
+	}
+}
+
+func TestNewTradeFormDefaultsToClockDate(t *testing.T) {
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository(), tradesvc.WithClock(tradesvc.ClockFunc(func() time.Time { return fixed })))
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/new", nil))
+	if !strings.Contains(rec.Body.String(), `value="2024-03-01"`) {
+		t.Fatalf("expected entry date to default to the injected clock")
+	}
+}
+
+func TestSearchHighlightsMatchingField(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	tr := &domain.Trade{
+		Instrument: "AAPL",
+		Review:     domain.TradeReview{Psychology: strings.Repeat("平靜", 20) + "看到財報後 FOMO 追高" + strings.Repeat("。", 40)},
+	}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	hits := searchTrades([]*domain.Trade{tr}, "fomo")
+	if len(hits) != 1 || hits[0].Field != "心理狀態" || hits[0].Match != "FOMO" {
+		t.Fatalf("unexpected hits: %+v", hits)
+	}
+	if !strings.HasPrefix(hits[0].Before, "…") || !strings.HasSuffix(hits[0].After, "…") {
+		t.Fatalf("expected snippet to be trimmed on both sides: %+v", hits[0])
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?q=fomo", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<mark>FOMO</mark>") {
+		t.Fatalf("expected highlighted match, got %d", rec.Code)
+	}
+}
+
+func TestIndexReviewedFilter(t *testing.T) {
+	reviewed := &domain.Trade{Instrument: "DONE", Review: domain.TradeReview{OutcomeSummary: "按計畫出場"}}
+	pending := &domain.Trade{Instrument: "TODO", Review: domain.TradeReview{Tags: []string{"breakout"}}}
+	trades := []*domain.Trade{reviewed, pending}
+
+	for query, want := range map[string][]*domain.Trade{
+		"/?reviewed=true":  {reviewed},
+		"/?reviewed=0":     {pending},
+		"/?reviewed=maybe": {reviewed, pending},
+	} {
+		filters := parseIndexFilters(httptest.NewRequest(http.MethodGet, query, nil))
+		got := applyIndexFilters(trades, filters, domain.DefaultBreakevenEpsilon)
+		if len(got) != len(want) {
+			t.Fatalf("%s: expected %d trades, got %d", query, len(want), len(got))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: unexpected trade %s", query, got[i].Instrument)
+			}
+		}
+	}
+	if q := parseIndexFilters(httptest.NewRequest(http.MethodGet, "/?reviewed=0", nil)).Query(); q != "reviewed=false" {
+		t.Fatalf("expected normalised reviewed query, got %q", q)
+	}
+}
+
+func TestIndexArchivedFilter(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	archivedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	trades := []*domain.Trade{
+		{Instrument: "ACTIVE"},
+		{Instrument: "SHELVED", ArchivedAt: &archivedAt},
+	}
+	for _, tr := range trades {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	get := func(server *Server, target string) string {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if body := get(server, "/"); strings.Contains(body, "SHELVED") || !strings.Contains(body, "ACTIVE") {
+		t.Fatalf("expected archived trade hidden by default")
+	}
+	if body := get(server, "/?archived=only"); !strings.Contains(body, "SHELVED") || strings.Contains(body, "ACTIVE") {
+		t.Fatalf("expected only archived trades")
+	}
+
+	withStats, err := NewServer(svc, WithArchivedInStats(true))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if body := get(withStats, "/"); strings.Contains(body, "SHELVED") || !strings.Contains(body, "2 筆未平倉") {
+		t.Fatalf("expected archived trade counted in stats but hidden from the list")
+	}
+}
+
+func TestIndexArchivedInStatsFeedsEveryPanel(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	archivedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	exit := &domain.ExitDetail{Date: archivedAt, Price: 12, Quantity: 1}
+	trades := []*domain.Trade{
+		{Instrument: "ACTIVE", Entry: domain.EntryDetail{Price: 10, Quantity: 1}},
+		{Instrument: "SHELVED", Entry: domain.EntryDetail{Price: 10, Quantity: 1}, Exit: exit, ArchivedAt: &archivedAt, Review: domain.TradeReview{Tags: []string{"shelved-tag"}}},
+	}
+	for _, tr := range trades {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	get := func(server *Server) string {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Body.String()
+	}
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if strings.Contains(get(server), "shelved-tag") {
+		t.Fatalf("expected the archived trade's tag left out of the tag cloud by default")
+	}
+	withStats, err := NewServer(svc, WithArchivedInStats(true))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if !strings.Contains(get(withStats), "shelved-tag") {
+		t.Fatalf("expected the archived trade's tag in the tag cloud when archived trades count in stats")
+	}
+}
+
+func TestLeverageFlaggedAboveThreshold(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	server, err := NewServer(svc, WithLeverage(10000, 3))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	tr := &domain.Trade{Instrument: "ES", Entry: domain.EntryDetail{Price: 5000, Quantity: 8}}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+tr.ID, nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "4.00x") || !strings.Contains(body, "超過上限 3.00x") {
+		t.Fatalf("expected flagged leverage on the detail page")
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), "高槓桿") {
+		t.Fatalf("expected leverage flag in the list")
+	}
+}
+
+func TestTradeCardPNG(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	stop := 95.0
+	tr := &domain.Trade{
+		Instrument: "AAPL",
+		Direction:  domain.DirectionLong,
+		Entry:      domain.EntryDetail{Price: 100, Quantity: 10, StopLoss: &stop},
+		Exit:       &domain.ExitDetail{Price: 110, Quantity: 10},
+	}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+tr.ID+"/card.png", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("expected png, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	img, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != cardWidth || b.Dy() != cardHeight {
+		t.Fatalf("unexpected card size %v", b)
+	}
+	r, g, b, _ := img.At(0, 0).RGBA()
+	if wr, wg, wb, _ := cardPositive.RGBA(); r != wr || g != wg || b != wb {
+		t.Fatalf("expected winning accent, got %v", img.At(0, 0))
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/missing/card.png", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestBitmapFontGlyphsAreWellFormed(t *testing.T) {
+	for r, glyph := range bitmapFont {
+		for _, row := range glyph {
+			if len(row) != glyphWidth {
+				t.Fatalf("glyph %q has a row of width %d", r, len(row))
+			}
+		}
+	}
+}
+
+func TestDashboardMetricsVisibility(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	if _, err := NewServer(svc, WithDashboardMetrics([]string{"win_rate", "sortino"})); err == nil {
+		t.Fatalf("expected unknown dashboard metric to be rejected")
+	}
+
+	server, err := NewServer(svc, WithDashboardMetrics([]string{"total_net", " win_rate "}))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if err := svc.Create(testContext(), &domain.Trade{Instrument: "AAPL", Entry: domain.EntryDetail{Price: 100, Quantity: 1}}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	netIdx := strings.Index(body, `data-metric="total_net"`)
+	winIdx := strings.Index(body, `data-metric="win_rate"`)
+	if netIdx < 0 || winIdx < 0 || netIdx > winIdx {
+		t.Fatalf("expected total_net then win_rate panels")
+	}
+	if strings.Contains(body, `data-metric="avg_r"`) {
+		t.Fatalf("expected hidden panels not to render")
+	}
+}
+
+func TestAnnualizationModes(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	if _, err := NewServer(svc, WithAnnualization("weekly")); err == nil {
+		t.Fatalf("expected unknown annualization mode to be rejected")
+	}
+
+	entry := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := &domain.Trade{Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: entry, Price: 100, Quantity: 1}, Exit: &domain.ExitDetail{Date: entry.AddDate(0, 0, 73), Price: 102, Quantity: 1}}
+	for mode, want := range map[string][2]bool{
+		AnnualizeSimple:     {true, false},
+		AnnualizeCompounded: {false, true},
+		AnnualizeBoth:       {true, true},
+	} {
+		var m tradeMetrics
+		m.applyAnnualization(tr, mode)
+		if (m.AnnualizedSimple != nil) != want[0] || (m.AnnualizedCompounded != nil) != want[1] {
+			t.Fatalf("mode %s: unexpected annualized values %+v", mode, m)
+		}
+	}
+}
+
+func TestFollowUpTemplatePrefillsNotes(t *testing.T) {
+	server, svc := newAPITestServer(t, WithFollowUpTemplate(`價格走勢：\n成交量：`))
+	tr := &domain.Trade{Instrument: "AAPL", Entry: domain.EntryDetail{Price: 100, Quantity: 1}}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+tr.ID, nil))
+	if !strings.Contains(rec.Body.String(), "<textarea id=\"follow_notes\" name=\"notes\" rows=\"3\">價格走勢：\n成交量：</textarea>") {
+		t.Fatalf("expected the template in the follow-up notes field")
+	}
+
+	for _, notes := range []string{"價格走勢：\r\n成交量：", "價格走勢：站上月線\n成交量："} {
+		form := url.Values{"days_after": {"7"}, "price": {"101"}, "notes": {notes}}
+		req := httptest.NewRequest(http.MethodPost, "/trades/"+tr.ID+"/followups", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec = httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected redirect, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	stored, _ := svc.Get(testContext(), tr.ID)
+	if len(stored.FollowUps) != 2 || stored.FollowUps[0].Notes != "" || !strings.Contains(stored.FollowUps[1].Notes, "站上月線") {
+		t.Fatalf("expected the untouched template to be dropped, got %+v", stored.FollowUps)
+	}
+}
+
+func TestShowTradeListsRTargets(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	stop := 104.0
+	tr := &domain.Trade{Instrument: "ES", Direction: domain.DirectionShort, Entry: domain.EntryDetail{Price: 100, Quantity: 1, StopLoss: &stop}}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+tr.ID, nil))
+	if !strings.Contains(rec.Body.String(), "R 目標：1R 96.00 &middot; 2R 92.00 &middot; 3R 88.00") {
+		t.Fatalf("expected R target levels on the detail page")
+	}
+}
+
+func TestShowTradeListsCostBasisAndProceeds(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	tr := &domain.Trade{
+		Instrument: "ES",
+		Direction:  domain.DirectionShort,
+		Entry:      domain.EntryDetail{Price: 100, Quantity: 2, Fees: 1},
+		Exit:       &domain.ExitDetail{Price: 90, Quantity: 2, Fees: 1},
+	}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+tr.ID, nil))
+	if !strings.Contains(rec.Body.String(), "181.00 / 199.00") {
+		t.Fatalf("expected the short's cover cost and sale proceeds on the detail page")
+	}
+}
+
+func TestRMultiplePrecisionAndUndefinedRisk(t *testing.T) {
+	server, svc := newAPITestServer(t, WithRPrecision(1))
+	stop := 96.0
+	withStop := &domain.Trade{Instrument: "STOPPED", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 1, StopLoss: &stop}, Exit: &domain.ExitDetail{Price: 94.5, Quantity: 1}}
+	noStop := &domain.Trade{Instrument: "NOSTOP", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 1}, Exit: &domain.ExitDetail{Price: 105, Quantity: 1}}
+	for _, tr := range []*domain.Trade{withStop, noStop} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+
+	if body := get("/trades/" + withStop.ID); !strings.Contains(body, `<span class="stat-value">-1.4R</span>`) {
+		t.Fatalf("expected R with one decimal on the detail page")
+	}
+	if body := get("/trades/" + noStop.ID); !strings.Contains(body, `<span class="stat-value">—</span>`) || strings.Contains(body, "0.0R") {
+		t.Fatalf("expected undefined R to render as a dash")
+	}
+	if body := get("/"); !strings.Contains(body, `<div class="cell-heading">—</div>`) || !strings.Contains(body, `<div class="cell-heading">-1.4R</div>`) {
+		t.Fatalf("expected the trade list to show R only for trades with a defined risk")
+	}
+
+	if _, err := NewServer(svc, WithRPrecision(7)); err == nil {
+		t.Fatalf("expected an out-of-range R precision to be rejected")
+	}
+}
+
+// Follow-up changes are derived from the direction on every render, so
+// correcting a trade's direction must flip them without any backfill.
+func TestDirectionFixRecomputesFollowUpChange(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	tr := &domain.Trade{
+		Instrument: "FLIP",
+		Direction:  domain.DirectionLong,
+		Entry:      domain.EntryDetail{Price: 95, Quantity: 1},
+		Exit:       &domain.ExitDetail{Price: 100, Quantity: 1},
+		FollowUps:  []domain.FollowUp{{DaysAfter: 7, Price: 110}},
+	}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	detail := func() string {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+tr.ID, nil))
+		return rec.Body.String()
+	}
+	if body := detail(); !strings.Contains(body, "第 7 天 10.00%") || !strings.Contains(body, "<td>10.00%</td>") {
+		t.Fatalf("expected a +10%% follow-up change for the long trade")
+	}
+
+	fixed, err := svc.Get(testContext(), tr.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	fixed.Direction = domain.DirectionShort
+	if err := svc.Update(testContext(), fixed); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if body := detail(); !strings.Contains(body, "第 7 天 -10.00%") || !strings.Contains(body, "<td>-10.00%</td>") {
+		t.Fatalf("expected the follow-up change to flip after correcting the direction")
+	}
+}
+
+func TestHomeViewOpenPositions(t *testing.T) {
+	server, svc := newAPITestServer(t, WithHomeView("open"))
+	for _, tr := range []*domain.Trade{
+		{Instrument: "HOLDING"},
+		{Instrument: "FINISHED", Exit: &domain.ExitDetail{Price: 1, Quantity: 1}},
+	} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+	if body := get("/"); !strings.Contains(body, "HOLDING") || strings.Contains(body, "FINISHED") {
+		t.Fatalf("expected only open positions on the home page")
+	}
+	if body := get("/?status="); !strings.Contains(body, "HOLDING") || !strings.Contains(body, "FINISHED") {
+		t.Fatalf("expected an explicit empty status to show the full history")
+	}
+
+	if _, err := NewServer(svc, WithHomeView("calendar")); err == nil {
+		t.Fatalf("expected an unknown home view to be rejected")
+	}
+}
+
+func TestTimeStopFlaggedOnIndexAndDetail(t *testing.T) {
+	now := time.Date(2024, 4, 10, 12, 0, 0, 0, time.UTC)
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository(), tradesvc.WithClock(tradesvc.ClockFunc(func() time.Time { return now })))
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	past := now.AddDate(0, 0, -2)
+	future := now.AddDate(0, 0, 5)
+	due := &domain.Trade{Instrument: "DUE", Entry: domain.EntryDetail{Date: now.AddDate(0, 0, -30), Price: 10, Quantity: 1}, TimeStopDate: &past}
+	pending := &domain.Trade{Instrument: "PENDING", Entry: domain.EntryDetail{Date: now.AddDate(0, 0, -30), Price: 10, Quantity: 1}, TimeStopDate: &future}
+	for _, tr := range []*domain.Trade{due, pending} {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+
+	body := get("/")
+	if !strings.Contains(body, "DUE &middot; 時間停損 2024-04-08") || strings.Contains(body, "PENDING &middot; 時間停損") {
+		t.Fatalf("expected only the trade past its time stop in the dashboard card")
+	}
+	if strings.Count(body, "已達時間停損</span>") != 1 {
+		t.Fatalf("expected one flagged row in the trade list")
+	}
+	if !strings.Contains(get("/trades/"+due.ID), "已到期") || strings.Contains(get("/trades/"+pending.ID), "已到期") {
+		t.Fatalf("expected the detail page to flag only the reached time stop")
+	}
+
+	rec := httptest.NewRecorder()
+	form := url.Values{"instrument": {"FORM"}, "direction": {"LONG"}, "entry_date": {"2024-04-01"}, "entry_price": {"10"}, "entry_quantity": {"1"}, "time_stop_date": {"2024-04-05"}}
+	req := httptest.NewRequest(http.MethodPost, "/trades", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	server.Handler().ServeHTTP(rec, req)
+	trades, _ := svc.List(testContext())
+	var saved *domain.Trade
+	for _, tr := range trades {
+		if tr.Instrument == "FORM" {
+			saved = tr
+		}
+	}
+	if saved == nil || saved.TimeStopDate == nil || !saved.TimeStopDate.Equal(time.Date(2024, 4, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the form to store the time stop, got %+v (status %d)", saved, rec.Code)
+	}
+}
+
+func TestSavedViews(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	post := func(target string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+
+	if body := get("/?status=losses"); !strings.Contains(body, `name="query" value="status=losses"`) {
+		t.Fatalf("expected the save form to carry the current filters")
+	}
+	rec := post("/views", url.Values{"name": {" 2024 losses "}, "query": {"status=losses&to=2024-12-31&from=2024-01-01&bogus=1&direction=UP"}})
+	if rec.Code != http.StatusSeeOther || !strings.HasPrefix(rec.Header().Get("Location"), "/?from=2024-01-01&status=losses&to=2024-12-31&flash=") {
+		t.Fatalf("expected a redirect to the saved filters, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	views, _ := svc.SavedViews(testContext())
+	if len(views) != 1 || views[0].Name != "2024 losses" || views[0].Query != "from=2024-01-01&status=losses&to=2024-12-31" {
+		t.Fatalf("expected the normalized filters to be stored, got %+v", views)
+	}
+
+	if body := get("/"); !strings.Contains(body, `<a class="tag" href="/?from=2024-01-01&amp;status=losses&amp;to=2024-12-31">2024 losses</a>`) {
+		t.Fatalf("expected the saved view as a quick link")
+	}
+	if body := get("/?from=2024-01-01&status=losses&to=2024-12-31"); !strings.Contains(body, `aria-current="page" style="font-weight:600;">2024 losses</a>`) {
+		t.Fatalf("expected the applied view to be marked")
+	}
+
+	if rec := post("/views", url.Values{"name": {" "}, "query": {"status=open"}}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a view without a name, got %d", rec.Code)
+	}
+	if rec := post("/views/delete", url.Values{"name": {"2024 losses"}}); rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected the view to be deleted, got %d", rec.Code)
+	}
+	if rec := post("/views/delete", url.Values{"name": {"2024 losses"}}); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown view, got %d", rec.Code)
+	}
+	if views, _ := svc.SavedViews(testContext()); len(views) != 0 {
+		t.Fatalf("expected no saved views left, got %+v", views)
+	}
+}
+
+func TestCollectTagsOrdersByUsage(t *testing.T) {
+	trades := []*domain.Trade{
+		{Review: domain.TradeReview{Tags: []string{"Breakout", "breakout", "gap"}}},
+		{Review: domain.TradeReview{Tags: []string{"reversal", "breakout"}}},
+		{Review: domain.TradeReview{Tags: []string{"gap", "breakout"}}},
+	}
+
+	trades = append(trades, &domain.Trade{Review: domain.TradeReview{Tags: []string{"alpha"}}})
+	check := func(order string, want []TagCount) {
+		t.Helper()
+		got := collectTags(trades, order)
+		if len(got) != len(want) {
+			t.Fatalf("%s: unexpected tags %+v", order, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: expected %+v at %d, got %+v", order, want[i], i, got[i])
+			}
+		}
+	}
+	check(TagOrderAlpha, []TagCount{{"alpha", 1}, {"breakout", 3}, {"gap", 2}, {"reversal", 1}})
+	check(TagOrderUsage, []TagCount{{"breakout", 3}, {"gap", 2}, {"alpha", 1}, {"reversal", 1}})
+
+	if _, err := NewServer(tradesvc.NewService(storage.NewInMemoryTradeRepository()), WithTagOrder("random")); err == nil {
+		t.Fatalf("expected an unknown tag order to be rejected")
+	}
+}
+
+func TestShowTradeRendersReviewMarkdownSafely(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	tr := &domain.Trade{
+		Instrument: "AAPL",
+		Entry:      domain.EntryDetail{Price: 100, Quantity: 1},
+		Review: domain.TradeReview{
+			OutcomeSummary: "**Stopped out** early\n\n- sized too big\n- ignored [plan](https://example.com/plan)",
+			Psychology:     "<script>alert(1)</script> [click](javascript:alert(1))",
+		},
+	}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+tr.ID, nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"<p><strong>Stopped out</strong> early</p>",
+		"<ul><li>sized too big</li><li>ignored <a href=\"https://example.com/plan\" rel=\"nofollow noopener\">plan</a></li></ul>",
+		"&lt;script&gt;alert(1)&lt;/script&gt; [click](javascript:alert(1))",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in the detail page", want)
+		}
+	}
+	if strings.Contains(body, "<script>alert") || strings.Contains(body, `href="javascript:`) {
+		t.Fatalf("expected unsafe markup to be escaped")
+	}
+}
+
+func TestShareLinkRendersReadOnlyTrade(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	tr := &domain.Trade{
+		Instrument: "NVDA",
+		Direction:  domain.DirectionLong,
+		Entry:      domain.EntryDetail{Price: 100, Quantity: 37, Fees: 1},
+		Exit:       &domain.ExitDetail{Price: 110, Quantity: 37, Fees: 1},
+	}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/trades/"+tr.ID+"/share", nil))
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+	stored, _ := svc.Get(testContext(), tr.ID)
+	if len(stored.Shares) != 1 {
+		t.Fatalf("expected one share link, got %d", len(stored.Shares))
+	}
+	path := server.shareLinks(stored)[0].Path
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "NVDA") {
+		t.Fatalf("expected the shared trade, got %d", rec.Code)
+	}
+	if strings.Contains(body, "368.00") || strings.Contains(body, "數量 37") {
+		t.Fatalf("expected amounts to be hidden by default")
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path+"x", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected a tampered token to be rejected, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/trades/"+tr.ID+"/share/"+stored.Shares[0].ID, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 on revoke, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected a revoked link to be gone, got %d", rec.Code)
+	}
+}
+
+func TestRankSetupsByExpectancy(t *testing.T) {
+	stop := 90.0
+	closed := func(setup string, exit float64) *domain.Trade {
+		return &domain.Trade{
+			Instrument: "AAPL",
+			Direction:  domain.DirectionLong,
+			Setup:      setup,
+			Entry:      domain.EntryDetail{Price: 100, Quantity: 10, StopLoss: &stop},
+			Exit:       &domain.ExitDetail{Price: exit, Quantity: 10},
+		}
+	}
+	trades := []*domain.Trade{
+		closed("突破", 120), closed("突破", 90),
+		closed("回檔", 110), closed("回檔", 110),
+		closed("反轉", 130),
+		{Instrument: "OPEN", Setup: "反轉", Entry: domain.EntryDetail{Price: 100, Quantity: 10, StopLoss: &stop}},
+		closed("", 150),
+	}
+
+	ranking := rankSetups(buildTradeSummaries(trades, time.Now(), domain.DefaultBreakevenEpsilon), 2)
+	if len(ranking.Ranked) != 2 {
+		t.Fatalf("expected two ranked setups, got %+v", ranking.Ranked)
+	}
+	if ranking.Ranked[0].Setup != "回檔" || ranking.Ranked[0].Rank != 1 || math.Abs(ranking.Ranked[0].Expectancy-1) > 1e-9 {
+		t.Fatalf("expected 回檔 first at 1R, got %+v", ranking.Ranked[0])
+	}
+	if ranking.Ranked[1].Setup != "突破" || math.Abs(ranking.Ranked[1].Expectancy-0.5) > 1e-9 {
+		t.Fatalf("expected 突破 second at 0.5R, got %+v", ranking.Ranked[1])
+	}
+	if len(ranking.Insufficient) != 1 || ranking.Insufficient[0].Setup != "反轉" || ranking.Insufficient[0].Samples != 1 {
+		t.Fatalf("expected 反轉 to lack data, got %+v", ranking.Insufficient)
+	}
+}
+
+func TestExitReasonBreakdownGroupsNormalizedReasons(t *testing.T) {
+	closed := func(reason string, exit float64) *domain.Trade {
+		return &domain.Trade{
+			Instrument: "AAPL",
+			Direction:  domain.DirectionLong,
+			Entry:      domain.EntryDetail{Price: 100, Quantity: 10},
+			Exit:       &domain.ExitDetail{Price: exit, Quantity: 10, Reason: reason},
+		}
+	}
+	trades := []*domain.Trade{
+		closed("停損出場", 90),
+		closed(" 停損出場\n", 95),
+		closed("達標出場", 120),
+		closed("", 100),
+		{Instrument: "OPEN", Entry: domain.EntryDetail{Price: 100, Quantity: 10}},
+	}
+
+	stats := exitReasonBreakdown(buildTradeSummaries(trades, time.Now(), domain.DefaultBreakevenEpsilon), 2)
+	if len(stats) != 3 {
+		t.Fatalf("expected three reasons, got %+v", stats)
+	}
+	if stats[0].Reason != "停損出場" || stats[0].Metrics.Closed != 2 || stats[0].Metrics.TotalNet != -150 {
+		t.Fatalf("expected 停損出場 first with both trades, got %+v", stats[0])
+	}
+	if !stats[0].Metrics.Enough(statWinRate) || stats[0].Metrics.WinRate != 0 {
+		t.Fatalf("expected a 0%% win rate for 停損出場, got %+v", stats[0].Metrics)
+	}
+	reasons := map[string]dashboardMetrics{}
+	for _, s := range stats {
+		reasons[s.Reason] = s.Metrics
+	}
+	if m, ok := reasons["達標出場"]; !ok || m.Enough(statWinRate) {
+		t.Fatalf("expected 達標出場 with too few samples, got %+v", m)
+	}
+	if _, ok := reasons[exitReasonUnset]; !ok {
+		t.Fatalf("expected trades without a reason to be grouped, got %+v", stats)
+	}
+}
+
+func TestSessionBreakdown(t *testing.T) {
+	hours, _ := domain.ParseMarketHours("US=09:30-16:00")
+	closed := func(hour int, session domain.Session, exit float64) *domain.Trade {
+		return &domain.Trade{
+			Market:    "US",
+			Direction: domain.DirectionLong,
+			Session:   session,
+			Entry:     domain.EntryDetail{Date: time.Date(2024, 6, 3, hour, 0, 0, 0, time.UTC), Price: 100, Quantity: 10},
+			Exit:      &domain.ExitDetail{Price: exit, Quantity: 10},
+		}
+	}
+	trades := []*domain.Trade{
+		closed(17, "", 90),
+		closed(0, domain.SessionAfterHours, 95),
+		closed(10, "", 120),
+		closed(0, "", 110),
+		{Market: "US", Entry: domain.EntryDetail{Date: time.Date(2024, 6, 3, 7, 0, 0, 0, time.UTC), Price: 100, Quantity: 10}},
+	}
+
+	stats := sessionBreakdown(buildTradeSummaries(trades, time.Now(), domain.DefaultBreakevenEpsilon), hours, 1)
+	if len(stats) != 2 {
+		t.Fatalf("expected regular and after-hours sessions only, got %+v", stats)
+	}
+	if stats[0].Session != "盤中" || stats[0].Metrics.Closed != 1 || stats[0].Metrics.TotalNet != 200 {
+		t.Fatalf("expected the regular session first, got %+v", stats[0])
+	}
+	if stats[1].Session != "盤後" || stats[1].Metrics.Closed != 2 || stats[1].Metrics.TotalNet != -150 {
+		t.Fatalf("expected both after-hours trades grouped, got %+v", stats[1])
+	}
+	if stats := sessionBreakdown(buildTradeSummaries(trades[3:], time.Now(), domain.DefaultBreakevenEpsilon), hours, 1); stats != nil {
+		t.Fatalf("expected no breakdown without classified closed trades, got %+v", stats)
+	}
+}
+
+func TestIndexModeFilterSeparatesPaperTrades(t *testing.T) {
+	real := &domain.Trade{Instrument: "REAL"}
+	paper := &domain.Trade{Instrument: "SIM", IsPaper: true}
+	trades := []*domain.Trade{real, paper}
+
+	for query, want := range map[string][]*domain.Trade{
+		"/":              {real},
+		"/?mode=real":    {real},
+		"/?mode=paper":   {paper},
+		"/?mode=all":     {real, paper},
+		"/?mode=unknown": {real},
+	} {
+		filters := parseIndexFilters(httptest.NewRequest(http.MethodGet, query, nil))
+		got := applyIndexFilters(trades, filters, domain.DefaultBreakevenEpsilon)
+		if len(got) != len(want) {
+			t.Fatalf("%s: expected %d trades, got %d", query, len(want), len(got))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: unexpected trade %s", query, got[i].Instrument)
+			}
+		}
+	}
+}
+
+func TestSplitTradeFromDetailPage(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	tr := &domain.Trade{Instrument: "AAPL", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 10, Fees: 2}}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	split := func(quantity string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/trades/"+tr.ID+"/split", strings.NewReader("quantity="+quantity))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := split("10"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 when nothing would remain, got %d", rec.Code)
+	}
+	rec := split("4")
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+	location := rec.Header().Get("Location")
+	if strings.HasPrefix(location, "/trades/"+tr.ID+"?") {
+		t.Fatalf("expected a redirect to the new trade, got %s", location)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "拆分自") {
+		t.Fatalf("expected the new trade to link back to the original, got %d", rec.Code)
+	}
+}
+
+func TestDashboardHidesStatsBelowMinSamples(t *testing.T) {
+	closed := func(exit float64) *domain.Trade {
+		return &domain.Trade{
+			Direction: domain.DirectionLong,
+			Entry:     domain.EntryDetail{Price: 100, Quantity: 1},
+			Exit:      &domain.ExitDetail{Price: exit, Quantity: 1},
+		}
+	}
+	trades := []*domain.Trade{closed(110), closed(90), closed(120)}
+	metrics := summarizeTrades(trades, time.Now(), domain.DefaultBreakevenEpsilon).withMinSamples(3)
+	if !metrics.Enough(statWinRate) || !metrics.Enough(statAvgReturn) {
+		t.Fatalf("expected three decided trades to be enough, got %v", metrics.Insufficient)
+	}
+	if metrics.Enough(statAvgR) {
+		t.Fatalf("expected avg R without any defined risk to be insufficient")
+	}
+	if metrics = metrics.withMinSamples(4); metrics.Enough(statWinRate) {
+		t.Fatalf("expected three trades to fall short of four samples")
+	}
+
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	server, err := NewServer(svc, WithDashboardMetrics([]string{"win_rate"}), WithMinSamples(4))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	for _, tr := range trades {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if strings.Contains(body, "66.7%") || !strings.Contains(body, "樣本不足（至少需 4 筆）") {
+		t.Fatalf("expected the win rate to be withheld")
+	}
+}
+
+func TestDetailPageShowsNetOfHedge(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	primary := &domain.Trade{Instrument: "KO", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 60, Quantity: 10}, Exit: &domain.ExitDetail{Price: 65, Quantity: 10}}
+	if err := svc.Create(testContext(), primary); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	form := url.Values{}
+	form.Set("instrument", "PEP")
+	form.Set("direction", "SHORT")
+	form.Set("entry_date", "2024-01-02")
+	form.Set("entry_price", "170")
+	form.Set("entry_quantity", "4")
+	form.Set("exit_price", "175")
+	form.Set("exit_quantity", "4")
+	form.Set("hedge_of", primary.ID)
+	req := httptest.NewRequest(http.MethodPost, "/trades?force=1", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+primary.ID, nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "PEP") || !strings.Contains(body, "避險後淨損益：<strong class=\"text-positive\">30.00</strong>") {
+		t.Fatalf("expected the combined result of both legs on the primary trade")
+	}
+
+	form.Set("hedge_of", "missing")
+	req = httptest.NewRequest(http.MethodPost, "/trades?force=1", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown hedge target, got %d", rec.Code)
+	}
+}
+
+func TestDetailPageKeepsHedgeCurrenciesApart(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	primary := &domain.Trade{Instrument: "2330", Currency: "TWD", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 600, Quantity: 10}, Exit: &domain.ExitDetail{Price: 610, Quantity: 10}}
+	if err := svc.Create(testContext(), primary); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	hedge := &domain.Trade{Instrument: "TSM", Currency: "USD", Direction: domain.DirectionShort, Entry: domain.EntryDetail{Price: 100, Quantity: 2}, Exit: &domain.ExitDetail{Price: 101, Quantity: 2}, HedgeOf: primary.ID}
+	if err := svc.Create(testContext(), hedge); err != nil {
+		t.Fatalf("create hedge: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+primary.ID, nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "<strong class=\"text-positive\">100.00</strong> TWD、<strong class=\"text-negative\">-2.00</strong> USD") {
+		t.Fatalf("expected the hedge result per currency, got %s", body)
+	}
+	if strings.Contains(body, "98.00") {
+		t.Fatalf("expected TWD and USD results not to be added together")
+	}
+}
+
+func TestDetailPageListsFollowUpsByDay(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	tr := &domain.Trade{
+		Instrument: "AAPL",
+		Exit:       &domain.ExitDetail{Price: 100, Quantity: 1},
+		FollowUps: []domain.FollowUp{
+			{DaysAfter: 30, Price: 130, Notes: "第三十天"},
+			{DaysAfter: 7, Price: 107, Notes: "第七天"},
+		},
+	}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trades/"+tr.ID, nil))
+	body := rec.Body.String()
+	if strings.Index(body, "第七天") > strings.Index(body, "第三十天") {
+		t.Fatalf("expected follow-ups to be listed by day count")
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/trades/"+tr.ID+"/followups/sort", nil))
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d", rec.Code)
+	}
+	stored, _ := svc.Get(testContext(), tr.ID)
+	if stored.FollowUps[0].DaysAfter != 7 {
+		t.Fatalf("expected the sorted order to be stored, got %+v", stored.FollowUps)
+	}
+}
+
+func TestIndexSortsByProfitPerDay(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	entry := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	trades := []*domain.Trade{
+		{Instrument: "SLOW", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: entry, Price: 100, Quantity: 10}, Exit: &domain.ExitDetail{Date: entry.AddDate(0, 2, 0), Price: 120, Quantity: 10}},
+		{Instrument: "QUICK", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: entry, Price: 100, Quantity: 10}, Exit: &domain.ExitDetail{Date: entry.AddDate(0, 0, 1), Price: 120, Quantity: 10}},
+		{Instrument: "HELD", Direction: domain.DirectionLong, Entry: domain.EntryDetail{Date: entry, Price: 100, Quantity: 10}},
+	}
+	for _, tr := range trades {
+		if err := svc.Create(testContext(), tr); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?sort=velocity", nil))
+	body := rec.Body.String()
+	table := body[strings.Index(body, "<th>交易</th>\n            <th>狀態</th>"):]
+	quick, slow, held := strings.Index(table, "QUICK"), strings.Index(table, "SLOW"), strings.Index(table, "HELD")
+	if quick < 0 || slow < quick || held < slow {
+		t.Fatalf("expected trades ordered by profit per day with open trades last")
+	}
+	if !strings.Contains(body, "獲利效率最佳交易") || !strings.Contains(body, "200.00") {
+		t.Fatalf("expected the top velocity list to show QUICK's 200 per day")
+	}
+	if !strings.Contains(body, `每日損益 ↓`) {
+		t.Fatalf("expected the velocity column to be marked as the sort order")
+	}
+}
+
+func TestVersionEndpoint(t *testing.T) {
+	server, err := NewServer(tradesvc.NewService(storage.NewInMemoryTradeRepository()))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	defer func(version, commit string) { Version, Commit = version, commit }(Version, Commit)
+	Version, Commit = "v1.2.0", "abc1234"
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var info versionInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if info.Version != "v1.2.0" || info.Commit != "abc1234" || info.BuildTime != BuildTime {
+		t.Fatalf("unexpected version info: %+v", info)
+	}
+}
+
+func TestSummarizePlanCapture(t *testing.T) {
+	stop, target := 95.0, 110.0
+	planned := func(exit float64) *domain.Trade {
+		return &domain.Trade{
+			Direction: domain.DirectionLong,
+			Entry:     domain.EntryDetail{Price: 100, Quantity: 1, StopLoss: &stop, Target: &target},
+			Exit:      &domain.ExitDetail{Price: exit, Quantity: 1},
+		}
+	}
+	trades := []*domain.Trade{
+		planned(105), // 1R of a planned 2R
+		planned(95),  // -1R of a planned 2R
+		planned(110), // the full 2R
+		{Direction: domain.DirectionLong, Entry: domain.EntryDetail{Price: 100, Quantity: 1, StopLoss: &stop}, Exit: &domain.ExitDetail{Price: 120, Quantity: 1}},
+	}
+	rows := buildTradeSummaries(trades, time.Now(), domain.DefaultBreakevenEpsilon)
+
+	capture := summarizePlanCapture(rows)
+	if capture.Samples != 3 || capture.PlannedR != 6 || capture.RealizedR != 2 || !capture.HasRatio {
+		t.Fatalf("unexpected plan capture: %+v", capture)
+	}
+	if panel := planCapturePanel(dashboardView{PlanCapture: capture, RPrecision: 1}); panel.Value != "33%" || !strings.Contains(panel.Meta, "（3 筆）") {
+		t.Fatalf("unexpected plan capture panel: %+v", panel)
+	}
+	if empty := planCapturePanel(dashboardView{PlanCapture: summarizePlanCapture(rows[3:])}); empty.Value != "—" {
+		t.Fatalf("expected no ratio without targeted trades, got %+v", empty)
+	}
+}
+
+func TestScreenshotURLOnFormAndDetail(t *testing.T) {
+	svc := tradesvc.NewService(storage.NewInMemoryTradeRepository())
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	post := func(screenshot string) *httptest.ResponseRecorder {
+		form := url.Values{"instrument": {"AAPL"}, "direction": {"LONG"}, "entry_date": {"2024-04-01"}, "entry_price": {"10"}, "entry_quantity": {"1"}, "screenshot_url": {screenshot}}
+		req := httptest.NewRequest(http.MethodPost, "/trades", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("javascript:alert(1)"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "截圖網址") {
+		t.Fatalf("expected a javascript: link to be rejected, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec := post("https://example.com/charts/aapl.png")
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d", rec.Code)
+	}
+	detail := httptest.NewRecorder()
+	server.Handler().ServeHTTP(detail, httptest.NewRequest(http.MethodGet, strings.SplitN(rec.Header().Get("Location"), "?", 2)[0], nil))
+	if !strings.Contains(detail.Body.String(), `<img src="https://example.com/charts/aapl.png"`) {
+		t.Fatalf("expected an image link to render as a thumbnail")
+	}
+}
+
+func TestFormEditKeepsFills(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tr := &domain.Trade{
+		Instrument: "SCALE",
+		Direction:  domain.DirectionLong,
+		Entry: domain.EntryDetail{Fills: []domain.Fill{
+			{Date: day, Price: 10, Quantity: 60},
+			{Date: day.AddDate(0, 0, 1), Price: 11, Quantity: 40},
+		}},
+		Exit: &domain.ExitDetail{Fills: []domain.Fill{
+			{Date: day.AddDate(0, 0, 5), Price: 12, Quantity: 100},
+		}},
+	}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	form := url.Values{"instrument": {"SCALE"}, "direction": {"LONG"}, "entry_date": {"2024-03-01"}, "entry_price": {"10.4"}, "entry_quantity": {"100"},
+		"exit_date": {"2024-03-06"}, "exit_price": {"12"}, "exit_quantity": {"100"}, "outcome": {"held the plan"}}
+	req := httptest.NewRequest(http.MethodPost, "/trades/"+tr.ID+"/update", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	stored, err := svc.Get(testContext(), tr.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(stored.Entry.Fills) != 2 || stored.Exit == nil || len(stored.Exit.Fills) != 1 {
+		t.Fatalf("expected the form edit to keep the fills, got %+v / %+v", stored.Entry, stored.Exit)
+	}
+	if stored.Entry.Quantity != 100 || stored.Review.OutcomeSummary != "held the plan" {
+		t.Fatalf("expected the edit to apply on top of the fills, got %+v", stored)
+	}
+}
+
+func TestFormEditReplacesFillsWhenPriceChanges(t *testing.T) {
+	server, svc := newAPITestServer(t)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tr := &domain.Trade{
+		Instrument: "SCALE",
+		Direction:  domain.DirectionLong,
+		Entry: domain.EntryDetail{Fills: []domain.Fill{
+			{Date: day, Price: 10, Quantity: 60},
+			{Date: day.AddDate(0, 0, 1), Price: 11, Quantity: 40},
+		}},
+		Exit: &domain.ExitDetail{Fills: []domain.Fill{
+			{Date: day.AddDate(0, 0, 5), Price: 12, Quantity: 100},
+		}},
+	}
+	if err := svc.Create(testContext(), tr); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	form := url.Values{"instrument": {"SCALE"}, "direction": {"LONG"}, "entry_date": {"2024-03-01"}, "entry_price": {"10.5"}, "entry_quantity": {"100"},
+		"exit_date": {"2024-03-06"}, "exit_price": {"12"}, "exit_quantity": {"100"}}
+	req := httptest.NewRequest(http.MethodPost, "/trades/"+tr.ID+"/update", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	stored, err := svc.Get(testContext(), tr.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if stored.Entry.Price != 10.5 || len(stored.Entry.Fills) != 0 {
+		t.Fatalf("expected the edited entry price to replace the fills, got %+v", stored.Entry)
+	}
+	if stored.Exit == nil || len(stored.Exit.Fills) != 1 {
+		t.Fatalf("expected the unchanged exit to keep its fills, got %+v", stored.Exit)
+	}
+}
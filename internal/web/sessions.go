@@ -0,0 +1,58 @@
+package web
+
+import (
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// sessionLabels are the display names of the trading sessions.
+var sessionLabels = map[domain.Session]string{
+	domain.SessionPreMarket:  "盤前",
+	domain.SessionRegular:    "盤中",
+	domain.SessionAfterHours: "盤後",
+}
+
+// WithMarketHours sets the regular session hours per market used to classify
+// trades with an entry time into pre-market, regular and after-hours.
+func WithMarketHours(hours domain.MarketHours) Option {
+	return func(s *Server) {
+		s.marketHours = hours
+	}
+}
+
+// applySession fills the label of the session the trade was entered in.
+func (m *tradeMetrics) applySession(tr *domain.Trade, hours domain.MarketHours) {
+	if session, ok := tr.TradingSession(hours); ok {
+		m.Session = sessionLabels[session]
+	}
+}
+
+// sessionStats is the performance of the closed trades entered in one session.
+type sessionStats struct {
+	Session string
+	Metrics dashboardMetrics
+}
+
+// sessionBreakdown groups closed trades by the session they were entered in,
+// in the order of the trading day. Trades whose session is unknown are left
+// out; nil is returned when no closed trade has one.
+func sessionBreakdown(rows []tradeSummary, hours domain.MarketHours, minSamples int) []sessionStats {
+	buckets := make(map[domain.Session][]tradeSummary)
+	for _, row := range rows {
+		if row.IsOpen {
+			continue
+		}
+		if session, ok := row.Trade.TradingSession(hours); ok {
+			buckets[session] = append(buckets[session], row)
+		}
+	}
+	if len(buckets) == 0 {
+		return nil
+	}
+	stats := make([]sessionStats, 0, len(buckets))
+	for _, session := range domain.Sessions {
+		if members, ok := buckets[session]; ok {
+			stats = append(stats, sessionStats{Session: sessionLabels[session], Metrics: summarizeRows(members).withMinSamples(minSamples)})
+		}
+	}
+	return stats
+}
@@ -0,0 +1,78 @@
+package web
+
+import (
+	"sort"
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// setupExpectancy is one setup's expectancy, the mean R-multiple of its closed
+// trades with a defined risk.
+type setupExpectancy struct {
+	Rank       int
+	Setup      string
+	Closed     int
+	Samples    int
+	Expectancy float64
+	WinRate    float64
+	HasWinRate bool
+	TotalNet   float64
+}
+
+// setupRanking orders setups by expectancy. Setups with too few samples to
+// judge are kept apart in Insufficient, most samples first.
+type setupRanking struct {
+	Ranked       []setupExpectancy
+	Insufficient []setupExpectancy
+	MinSamples   int
+}
+
+func setupKey(tr *domain.Trade) []string {
+	setup := strings.TrimSpace(tr.Setup)
+	if setup == "" {
+		return nil
+	}
+	return []string{setup}
+}
+
+// rankSetups groups closed trades by setup and ranks the setups by expectancy,
+// best first. Trades without a setup are left out.
+func rankSetups(rows []tradeSummary, minSamples int) setupRanking {
+	if minSamples < 1 {
+		minSamples = DefaultMinSamples
+	}
+	closed := make([]tradeSummary, 0, len(rows))
+	for _, row := range rows {
+		if !row.IsOpen {
+			closed = append(closed, row)
+		}
+	}
+	ranking := setupRanking{MinSamples: minSamples}
+	for _, g := range groupTrades(closed, setupKey) {
+		entry := setupExpectancy{
+			Setup:      g.Key,
+			Closed:     g.Metrics.Closed,
+			Samples:    g.Metrics.RSamples,
+			Expectancy: g.Metrics.AvgR,
+			WinRate:    g.Metrics.WinRate,
+			HasWinRate: g.Metrics.Wins+g.Metrics.Losses > 0,
+			TotalNet:   g.Metrics.TotalNet,
+		}
+		if entry.Samples < minSamples {
+			ranking.Insufficient = append(ranking.Insufficient, entry)
+			continue
+		}
+		ranking.Ranked = append(ranking.Ranked, entry)
+	}
+	sort.SliceStable(ranking.Ranked, func(i, j int) bool {
+		return ranking.Ranked[i].Expectancy > ranking.Ranked[j].Expectancy
+	})
+	sort.SliceStable(ranking.Insufficient, func(i, j int) bool {
+		return ranking.Insufficient[i].Samples > ranking.Insufficient[j].Samples
+	})
+	for i := range ranking.Ranked {
+		ranking.Ranked[i].Rank = i + 1
+	}
+	return ranking
+}
@@ -0,0 +1,138 @@
+package web
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+	"best_trade_logs/internal/storage"
+)
+
+// WithShareTTL sets how long new read-only share links stay valid. Share
+// tokens are signed with the session secret, so links only survive a restart
+// when one is configured.
+func WithShareTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		if ttl > 0 {
+			s.shareTTL = ttl
+		}
+	}
+}
+
+// shareLinkView is an active share link with its signed URL path.
+type shareLinkView struct {
+	domain.ShareLink
+	Path string
+}
+
+// shareToken signs the trade and share IDs so the token cannot be forged or
+// pointed at another trade.
+func (s *Server) shareToken(tradeID, shareID string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(tradeID + ":" + shareID))
+	return payload + "." + s.sign(signPurposeShare, payload)
+}
+
+func (s *Server) parseShareToken(token string) (tradeID, shareID string, ok bool) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.sign(signPurposeShare, payload))) {
+		return "", "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", false
+	}
+	tradeID, shareID, ok = strings.Cut(string(raw), ":")
+	return tradeID, shareID, ok && tradeID != "" && shareID != ""
+}
+
+// shareLinks lists the trade's unexpired share links for the detail page.
+func (s *Server) shareLinks(tr *domain.Trade) []shareLinkView {
+	now := s.svc.Now()
+	var links []shareLinkView
+	for _, link := range tr.Shares {
+		if !link.Expired(now) {
+			links = append(links, shareLinkView{ShareLink: link, Path: "/s/" + s.shareToken(tr.ID, link.ID)})
+		}
+	}
+	return links
+}
+
+// handleCreateShare creates a share link; amounts=1 includes quantities, fees
+// and results in the shared view.
+func (s *Server) handleCreateShare(w http.ResponseWriter, r *http.Request, id string) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "表單格式錯誤", http.StatusBadRequest)
+		return
+	}
+	link, err := s.svc.Share(r.Context(), id, s.shareTTL, r.FormValue("amounts") == "1")
+	if err != nil {
+		writeShareError(w, err)
+		return
+	}
+	flash := fmt.Sprintf("已建立分享連結 /s/%s，有效至 %s", s.shareToken(id, link.ID), link.ExpiresAt.Format("2006-01-02 15:04"))
+	http.Redirect(w, r, fmt.Sprintf("/trades/%s?flash=%s", id, url.QueryEscape(flash)), http.StatusSeeOther)
+}
+
+// handleRevokeShare revokes a share link. DELETE answers 204; the POST form
+// variant redirects back to the trade.
+func (s *Server) handleRevokeShare(w http.ResponseWriter, r *http.Request, id, shareID string) {
+	if err := s.svc.RevokeShare(r.Context(), id, shareID); err != nil {
+		writeShareError(w, err)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/trades/%s?flash=%s", id, url.QueryEscape("分享連結已撤銷")), http.StatusSeeOther)
+}
+
+// handleSharedTrade renders the read-only view behind /s/{token}. It needs no
+// credentials; invalid, revoked and expired tokens all answer 404.
+func (s *Server) handleSharedTrade(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	tradeID, shareID, ok := s.parseShareToken(strings.TrimPrefix(r.URL.Path, "/s/"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	tr, link, err := s.svc.SharedTrade(r.Context(), tradeID, shareID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	s.render(w, "shared_trade.gohtml", struct {
+		Title   string
+		Trade   *domain.Trade
+		Metrics tradeMetrics
+		Link    domain.ShareLink
+	}{
+		Title:   fmt.Sprintf("分享交易 - %s", tr.Instrument),
+		Trade:   tr,
+		Metrics: buildTradeMetrics(tr, ""),
+		Link:    link,
+	})
+}
+
+func writeShareError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, storage.ErrNotFound) {
+		status = http.StatusNotFound
+	}
+	http.Error(w, err.Error(), status)
+}
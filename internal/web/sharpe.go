@@ -0,0 +1,76 @@
+package web
+
+import (
+	"fmt"
+	"math"
+)
+
+// WithRiskFreeRate sets the annual risk-free rate, in percent, that the Sharpe
+// ratio subtracts from each closed trade's return, pro-rated by how long the
+// trade was held. Zero, the default, compares raw returns.
+func WithRiskFreeRate(rate float64) Option {
+	return func(s *Server) {
+		s.riskFreeRate = rate
+	}
+}
+
+// sharpeMetrics is a per-trade Sharpe ratio: the mean excess return of the
+// closed trades divided by the standard deviation of those excess returns.
+// A trade's excess return is its ResultPercent minus the risk-free rate earned
+// over its holding period; trades without a holding period are not charged.
+type sharpeMetrics struct {
+	Samples      int
+	AvgExcess    float64
+	Ratio        float64
+	HasRatio     bool
+	RiskFreeRate float64
+}
+
+func summarizeSharpe(rows []tradeSummary, riskFreeRate float64) sharpeMetrics {
+	metrics := sharpeMetrics{RiskFreeRate: riskFreeRate}
+	var excess []float64
+	for _, row := range rows {
+		if row.IsOpen {
+			continue
+		}
+		r := row.ResultPercent
+		if row.HasHold {
+			r -= riskFreeRate * row.HoldDays / 365
+		}
+		excess = append(excess, r)
+	}
+	metrics.Samples = len(excess)
+	if metrics.Samples < 2 {
+		return metrics
+	}
+	var sum float64
+	for _, r := range excess {
+		sum += r
+	}
+	metrics.AvgExcess = sum / float64(metrics.Samples)
+	var squares float64
+	for _, r := range excess {
+		squares += (r - metrics.AvgExcess) * (r - metrics.AvgExcess)
+	}
+	stddev := math.Sqrt(squares / float64(metrics.Samples-1))
+	if stddev > 0 {
+		metrics.Ratio = metrics.AvgExcess / stddev
+		metrics.HasRatio = true
+	}
+	return metrics
+}
+
+func sharpePanel(d dashboardView) dashboardPanel {
+	s := d.Sharpe
+	panel := dashboardPanel{Label: "夏普比率", Value: "—", Meta: "需至少兩筆報酬不同的已平倉交易"}
+	if s.Samples < d.Metrics.MinSamples {
+		panel.Meta = insufficientMeta(d.Metrics)
+		return panel
+	}
+	if s.HasRatio {
+		panel.Value = fmt.Sprintf("%.2f", s.Ratio)
+		panel.ValueClass = signClass(s.Ratio)
+		panel.Meta = fmt.Sprintf("每筆平均超額報酬 %.2f%% · 無風險利率 %.2f%%（%d 筆）", s.AvgExcess, s.RiskFreeRate, s.Samples)
+	}
+	return panel
+}
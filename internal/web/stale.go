@@ -0,0 +1,99 @@
+package web
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// WithStaleAfter lists open trades entered longer ago than d on the dashboard
+// so forgotten positions get closed, and sets the default age for
+// /admin/close-stale. Zero, the default, disables both.
+func WithStaleAfter(d time.Duration) Option {
+	return func(s *Server) {
+		if d > 0 {
+			s.staleAfter = d
+		}
+	}
+}
+
+// staleTrade is an open trade that has been held past the configured age.
+type staleTrade struct {
+	Trade    *domain.Trade
+	DaysOpen int
+}
+
+// staleTrades lists the stale open trades, oldest first, when a stale age is configured.
+func (s *Server) staleTrades(ctx context.Context) []staleTrade {
+	if s.staleAfter <= 0 {
+		return nil
+	}
+	trades, err := s.svc.StaleTrades(ctx, s.staleAfter)
+	if err != nil {
+		log.Printf("stale trades lookup: %v", err)
+		return nil
+	}
+	now := s.svc.Now()
+	stale := make([]staleTrade, 0, len(trades))
+	for _, tr := range trades {
+		stale = append(stale, staleTrade{Trade: tr, DaysOpen: int(now.Sub(tr.Entry.Date).Hours() / 24)})
+	}
+	return stale
+}
+
+// timeStopsReached lists the open trades held past their own time stop,
+// earliest time stop first. Unlike stale trades these follow each trade's plan
+// rather than a global age.
+func timeStopsReached(trades []*domain.Trade, now time.Time) []*domain.Trade {
+	var reached []*domain.Trade
+	for _, tr := range trades {
+		if tr.TimeStopReached(now) {
+			reached = append(reached, tr)
+		}
+	}
+	sort.SliceStable(reached, func(i, j int) bool {
+		return reached[i].TimeStopDate.Before(*reached[j].TimeStopDate)
+	})
+	return reached
+}
+
+// handleAdminCloseStale closes stale open trades at their mark price. The age
+// comes from older_than_days, falling back to the configured stale age.
+func (s *Server) handleAdminCloseStale(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	olderThan := s.staleAfter
+	if raw := strings.TrimSpace(r.URL.Query().Get("older_than_days")); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "older_than_days 格式錯誤")
+			return
+		}
+		olderThan = time.Duration(v) * 24 * time.Hour
+	}
+	if olderThan <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "需指定 older_than_days 或設定 STALE_AFTER")
+		return
+	}
+	closed, err := s.svc.AutoCloseStale(r.Context(), olderThan)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ids := make([]string, 0, len(closed))
+	for _, tr := range closed {
+		ids = append(ids, tr.ID)
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Closed int      `json:"closed"`
+		IDs    []string `json:"ids"`
+	}{Closed: len(closed), IDs: ids})
+}
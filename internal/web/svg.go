@@ -0,0 +1,87 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Bounds for the rendered chart size, in pixels.
+const (
+	defaultChartWidth  = 600
+	defaultChartHeight = 200
+	minChartSize       = 50
+	maxChartSize       = 4000
+	chartPadding       = 10
+)
+
+// parseChartSize reads a width or height query value, falling back to def and
+// clamping to the supported range.
+func parseChartSize(raw string, def int) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, err
+	}
+	if v < minChartSize {
+		v = minChartSize
+	}
+	if v > maxChartSize {
+		v = maxChartSize
+	}
+	return v, nil
+}
+
+// renderEquitySVG draws the equity curve as a polyline starting from zero, with a
+// dashed zero baseline. Points are spaced evenly by trade rather than by date.
+func renderEquitySVG(curve []equityPoint, width, height int) string {
+	values := make([]float64, 0, len(curve)+1)
+	values = append(values, 0)
+	for _, p := range curve {
+		values = append(values, p.Equity)
+	}
+	lo, hi := 0.0, 0.0
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+
+	plotW := float64(width - 2*chartPadding)
+	plotH := float64(height - 2*chartPadding)
+	x := func(i int) float64 {
+		if len(values) == 1 {
+			return chartPadding
+		}
+		return chartPadding + plotW*float64(i)/float64(len(values)-1)
+	}
+	y := func(v float64) float64 {
+		return chartPadding + plotH*(hi-v)/(hi-lo)
+	}
+
+	var points strings.Builder
+	for i, v := range values {
+		if i > 0 {
+			points.WriteByte(' ')
+		}
+		fmt.Fprintf(&points, "%.1f,%.1f", x(i), y(v))
+	}
+	stroke := "#16a34a"
+	if values[len(values)-1] < 0 {
+		stroke = "#dc2626"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+	b.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>`)
+	fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#94a3b8" stroke-dasharray="4 4"/>`, chartPadding, y(0), width-chartPadding, y(0))
+	fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, stroke, points.String())
+	b.WriteString(`</svg>`)
+	return b.String()
+}
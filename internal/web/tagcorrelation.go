@@ -0,0 +1,81 @@
+package web
+
+import (
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// tagComboStats is the performance of the closed trades in one side of a tag
+// comparison. Expectancy is the mean R-multiple of those with a defined risk.
+type tagComboStats struct {
+	Trades     int     `json:"trades"`
+	Wins       int     `json:"wins"`
+	Losses     int     `json:"losses"`
+	WinRate    float64 `json:"win_rate"`
+	Expectancy float64 `json:"expectancy"`
+	RSamples   int     `json:"r_samples"`
+	TotalNet   float64 `json:"total_net"`
+}
+
+// tagCorrelation compares closed trades carrying both tags A and B with those
+// carrying only one of them.
+type tagCorrelation struct {
+	A     string        `json:"a"`
+	B     string        `json:"b"`
+	Both  tagComboStats `json:"both"`
+	AOnly tagComboStats `json:"a_only"`
+	BOnly tagComboStats `json:"b_only"`
+}
+
+const (
+	tagComboBoth  = "both"
+	tagComboAOnly = "a_only"
+	tagComboBOnly = "b_only"
+)
+
+// correlateTags splits the closed trades tagged a or b into the intersection
+// and the two differences. Sides without trades are left zero.
+func correlateTags(rows []tradeSummary, a, b string) tagCorrelation {
+	closed := make([]tradeSummary, 0, len(rows))
+	for _, row := range rows {
+		if !row.IsOpen {
+			closed = append(closed, row)
+		}
+	}
+	keyFn := func(tr *domain.Trade) []string {
+		var hasA, hasB bool
+		for _, tag := range tagKeys(tr) {
+			hasA = hasA || tag == a
+			hasB = hasB || tag == b
+		}
+		switch {
+		case hasA && hasB:
+			return []string{tagComboBoth}
+		case hasA:
+			return []string{tagComboAOnly}
+		case hasB:
+			return []string{tagComboBOnly}
+		}
+		return nil
+	}
+	result := tagCorrelation{A: a, B: b}
+	for _, g := range groupTrades(closed, keyFn) {
+		stats := tagComboStats{
+			Trades:     g.Metrics.Closed,
+			Wins:       g.Metrics.Wins,
+			Losses:     g.Metrics.Losses,
+			WinRate:    g.Metrics.WinRate,
+			Expectancy: g.Metrics.AvgR,
+			RSamples:   g.Metrics.RSamples,
+			TotalNet:   g.Metrics.TotalNet,
+		}
+		switch g.Key {
+		case tagComboBoth:
+			result.Both = stats
+		case tagComboAOnly:
+			result.AOnly = stats
+		case tagComboBOnly:
+			result.BOnly = stats
+		}
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package web
+
+import (
+	"sort"
+	"strings"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// Tag orders accepted by WithTagOrder.
+const (
+	TagOrderAlpha = "alpha"
+	TagOrderUsage = "usage"
+)
+
+// WithTagOrder selects how the tag filter and the tag cloud list tags:
+// alphabetically, the default, or most used first.
+func WithTagOrder(order string) Option {
+	return func(s *Server) {
+		s.tagOrder = strings.ToLower(strings.TrimSpace(order))
+	}
+}
+
+// TagCount is a normalized tag and the number of trades carrying it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// collectTags lists the normalized tags used by trades with their usage
+// counts, in the given order. Ties in usage order fall back to alphabetical.
+func collectTags(trades []*domain.Trade, order string) []TagCount {
+	counts := make(map[string]int)
+	for _, tr := range trades {
+		seen := make(map[string]struct{}, len(tr.Review.Tags))
+		for _, tag := range tr.Review.Tags {
+			normalised := domain.NormalizeTag(tag)
+			if normalised == "" {
+				continue
+			}
+			if _, dup := seen[normalised]; dup {
+				continue
+			}
+			seen[normalised] = struct{}{}
+			counts[normalised]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	values := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		values = append(values, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if order == TagOrderUsage && values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Tag < values[j].Tag
+	})
+	return values
+}
@@ -0,0 +1,97 @@
+package templates
+
+import (
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdBulletPattern  = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	mdOrderedPattern = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	mdLinkPattern    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBoldPattern    = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+)
+
+// renderMarkdown turns a small, safe Markdown subset into HTML: paragraphs with
+// line breaks, bullet and numbered lists, **bold** and [links](url). The input
+// is HTML-escaped before any markup is added, so raw HTML is shown as text, and
+// links are only kept for http, https and mailto URLs.
+func renderMarkdown(src string) template.HTML {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+
+	var (
+		b         strings.Builder
+		paragraph []string
+		list      string
+	)
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>" + strings.Join(paragraph, "<br>") + "</p>")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			b.WriteString("</" + list + ">")
+			list = ""
+		}
+	}
+	openList := func(tag string) {
+		flushParagraph()
+		if list != tag {
+			closeList()
+			b.WriteString("<" + tag + ">")
+			list = tag
+		}
+	}
+
+	for _, line := range lines {
+		if m := mdBulletPattern.FindStringSubmatch(line); m != nil {
+			openList("ul")
+			b.WriteString("<li>" + renderMarkdownInline(m[1]) + "</li>")
+			continue
+		}
+		if m := mdOrderedPattern.FindStringSubmatch(line); m != nil {
+			openList("ol")
+			b.WriteString("<li>" + renderMarkdownInline(m[1]) + "</li>")
+			continue
+		}
+		closeList()
+		if strings.TrimSpace(line) == "" {
+			flushParagraph()
+			continue
+		}
+		paragraph = append(paragraph, renderMarkdownInline(strings.TrimSpace(line)))
+	}
+	flushParagraph()
+	closeList()
+	return template.HTML(b.String())
+}
+
+func renderMarkdownInline(text string) string {
+	text = template.HTMLEscapeString(text)
+	text = mdLinkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := mdLinkPattern.FindStringSubmatch(match)
+		href := html.UnescapeString(m[2])
+		if !isSafeMarkdownURL(href) {
+			return match
+		}
+		return `<a href="` + template.HTMLEscapeString(href) + `" rel="nofollow noopener">` + m[1] + `</a>`
+	})
+	return mdBoldPattern.ReplaceAllString(text, "<strong>$1$2</strong>")
+}
+
+func isSafeMarkdownURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 	"html/template"
 	"io"
 	"io/fs"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -15,13 +16,39 @@ import (
 //go:embed *.gohtml
 var templateFS embed.FS
 
+// DefaultRPrecision is the number of decimals R multiples are shown with.
+const DefaultRPrecision = 2
+
 // Engine encapsulates parsed templates keyed by page name.
 type Engine struct {
-	templates map[string]*template.Template
+	templates  map[string]*template.Template
+	rPrecision int
+}
+
+// Option customises an Engine.
+type Option func(*Engine)
+
+// WithRPrecision sets the number of decimals the formatR and tradeR helpers
+// show R multiples with.
+func WithRPrecision(decimals int) Option {
+	return func(e *Engine) {
+		if decimals >= 0 {
+			e.rPrecision = decimals
+		}
+	}
+}
+
+// FormatR renders an R multiple such as "1.50R" with the given decimals.
+func FormatR(r float64, decimals int) string {
+	return strconv.FormatFloat(r, 'f', decimals, 64) + "R"
 }
 
 // New parses the embedded templates with helper functions configured.
-func New() (*Engine, error) {
+func New(opts ...Option) (*Engine, error) {
+	e := &Engine{rPrecision: DefaultRPrecision}
+	for _, opt := range opts {
+		opt(e)
+	}
 	funcMap := template.FuncMap{
 		"ptrValue": func(v *float64) float64 {
 			if v == nil {
@@ -29,6 +56,21 @@ func New() (*Engine, error) {
 			}
 			return *v
 		},
+		"ptrInt": func(v *int) int {
+			if v == nil {
+				return 0
+			}
+			return *v
+		},
+		"ptrBool": func(v *bool) bool {
+			return v != nil && *v
+		},
+		"ptrPercent": func(v *float64) float64 {
+			if v == nil {
+				return 0
+			}
+			return *v * 100
+		},
 		"join": func(values []string, sep string) string {
 			return strings.Join(values, sep)
 		},
@@ -41,7 +83,18 @@ func New() (*Engine, error) {
 			}
 			return 0
 		},
+		"formatR": func(r float64) string {
+			return FormatR(r, e.rPrecision)
+		},
+		// tradeR renders a trade's R multiple, or "—" when it has no defined risk.
+		"tradeR": func(tr *domain.Trade) string {
+			if tr == nil || !tr.HasDefinedRisk() {
+				return "—"
+			}
+			return FormatR(tr.RMultiple(), e.rPrecision)
+		},
 		"formatTag": formatTag,
+		"markdown":  renderMarkdown,
 	}
 
 	base, err := template.New("layout.gohtml").Funcs(funcMap).ParseFS(templateFS, "layout.gohtml")
@@ -74,7 +127,8 @@ func New() (*Engine, error) {
 		tmpls[name] = clone
 	}
 
-	return &Engine{templates: tmpls}, nil
+	e.templates = tmpls
+	return e, nil
 }
 
 // FormatTag exposes the human-readable representation of a tag.
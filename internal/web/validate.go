@@ -0,0 +1,112 @@
+package web
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	domain "best_trade_logs/internal/domain/trade"
+)
+
+// validationResult is the response of a dry-run save requested with ?validate=1.
+type validationResult struct {
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// isDryRun reports whether the request only asks for validation.
+func isDryRun(r *http.Request) bool {
+	return r.URL.Query().Get("validate") == "1"
+}
+
+// mergeExisting carries over the fields an edit never replaces. Fills are kept
+// when the edit sends none, since the HTML form has no fill inputs, but only
+// while the submitted price, quantity and date still agree with them: a
+// changed value replaces the fills instead of being recomputed away.
+func mergeExisting(tr, existing *domain.Trade) {
+	tr.ID = existing.ID
+	tr.CreatedAt = existing.CreatedAt
+	if tr.FollowUps == nil {
+		tr.FollowUps = existing.FollowUps
+	}
+	tr.Events = existing.Events
+	tr.ArchivedAt = existing.ArchivedAt
+	tr.Shares = existing.Shares
+	tr.SplitFrom = existing.SplitFrom
+	if tr.InstrumentAlias == "" {
+		tr.InstrumentAlias = existing.InstrumentAlias
+	}
+	if len(tr.Entry.Fills) == 0 && fillsMatch(existing.Entry.Fills, tr.Entry.Price, tr.Entry.Quantity, tr.Entry.Date, false) {
+		tr.Entry.Fills = existing.Entry.Fills
+	}
+	if tr.Exit != nil && existing.Exit != nil && len(tr.Exit.Fills) == 0 && fillsMatch(existing.Exit.Fills, tr.Exit.Price, tr.Exit.Quantity, tr.Exit.Date, true) {
+		tr.Exit.Fills = existing.Exit.Fills
+	}
+}
+
+// fillsMatch reports whether fills add up to price and quantity at the four
+// decimals the form shows, and start (or with last, end) on date's day.
+func fillsMatch(fills []domain.Fill, price, quantity float64, date time.Time, last bool) bool {
+	var notional, filled float64
+	for _, f := range fills {
+		if f.Quantity > 0 {
+			notional += f.Price * f.Quantity
+			filled += f.Quantity
+		}
+	}
+	if filled == 0 {
+		return false
+	}
+	sameAtFormPrecision := func(a, b float64) bool {
+		return math.Round(a*1e4) == math.Round(b*1e4)
+	}
+	if !sameAtFormPrecision(notional/filled, price) || !sameAtFormPrecision(filled, quantity) {
+		return false
+	}
+	edge := fills[0].Date
+	if last {
+		edge = fills[len(fills)-1].Date
+	}
+	return edge.IsZero() || edge.Format("2006-01-02") == date.Format("2006-01-02")
+}
+
+// tradeErrors checks the values every trade saved through the form or the JSON
+// API must have, so neither path stores a trade the other would reject. Prices
+// and quantities of scaled entries come from their fills.
+func tradeErrors(tr *domain.Trade) []string {
+	var errs []string
+	if len(tr.Entry.Fills) == 0 {
+		if tr.Entry.Price <= 0 {
+			errs = append(errs, "進場價格必須大於 0")
+		}
+		if tr.Entry.Quantity <= 0 {
+			errs = append(errs, "數量必須大於 0")
+		}
+	}
+	// Dates are compared by day: the form has no exit time, so a same-day exit
+	// must not count as before a timed entry.
+	if tr.Exit != nil && !tr.Exit.Date.IsZero() && !tr.Entry.Date.IsZero() &&
+		tr.Exit.Date.Format("2006-01-02") < tr.Entry.Date.Format("2006-01-02") {
+		errs = append(errs, "出場日期不得早於進場日期")
+	}
+	return errs
+}
+
+// writeValidation reports the would-be outcome of saving tr without persisting
+// it: 200 when valid, 400 with the errors otherwise.
+func (s *Server) writeValidation(w http.ResponseWriter, tr *domain.Trade, errs []string) {
+	result := validationResult{Valid: len(errs) == 0, Errors: errs}
+	if result.Errors == nil {
+		result.Errors = []string{}
+	}
+	status := http.StatusBadRequest
+	if result.Valid {
+		status = http.StatusOK
+		if warning := s.svc.SetupWarning(tr); warning != "" {
+			result.Warnings = append(result.Warnings, warning)
+		}
+		result.Warnings = append(result.Warnings, s.svc.TickWarnings(tr)...)
+	}
+	writeJSON(w, status, result)
+}
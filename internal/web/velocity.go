@@ -0,0 +1,82 @@
+package web
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// sortVelocity orders the trade list by profit per day, fastest first.
+const sortVelocity = "velocity"
+
+// topVelocityLimit is how many trades the dashboard's velocity list shows.
+const topVelocityLimit = 5
+
+// velocityTrade is a closed trade ranked by how quickly it made its profit.
+type velocityTrade struct {
+	ID           string
+	Instrument   string
+	NetResult    float64
+	HoldDays     float64
+	ProfitPerDay float64
+}
+
+// parseSort returns the requested list order, or "" for the default order.
+func parseSort(q url.Values) string {
+	if sortKey := strings.ToLower(strings.TrimSpace(q.Get("sort"))); sortKey == sortVelocity {
+		return sortKey
+	}
+	return ""
+}
+
+// sortHref links to the list with the given filters in the given order.
+func sortHref(filterQuery, sortKey string) string {
+	values, _ := url.ParseQuery(filterQuery)
+	if sortKey != "" {
+		values.Set("sort", sortKey)
+	}
+	if encoded := values.Encode(); encoded != "" {
+		return "/?" + encoded
+	}
+	return "/"
+}
+
+// sortTradeRows reorders rows in place. By velocity, trades without a profit
+// per day (open trades or trades without dates) go last in their original
+// order.
+func sortTradeRows(rows []tradeSummary, sortKey string) {
+	if sortKey != sortVelocity {
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].HasProfitPerDay != rows[j].HasProfitPerDay {
+			return rows[i].HasProfitPerDay
+		}
+		return rows[i].ProfitPerDay > rows[j].ProfitPerDay
+	})
+}
+
+// topVelocity lists the profitable closed trades that earned the most per day
+// held, best first.
+func topVelocity(rows []tradeSummary) []velocityTrade {
+	var top []velocityTrade
+	for _, row := range rows {
+		if row.IsOpen || !row.HasProfitPerDay || row.ProfitPerDay <= 0 {
+			continue
+		}
+		top = append(top, velocityTrade{
+			ID:           row.ID,
+			Instrument:   row.Instrument,
+			NetResult:    row.NetResult,
+			HoldDays:     row.HoldDays,
+			ProfitPerDay: row.ProfitPerDay,
+		})
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].ProfitPerDay > top[j].ProfitPerDay
+	})
+	if len(top) > topVelocityLimit {
+		top = top[:topVelocityLimit]
+	}
+	return top
+}
@@ -0,0 +1,30 @@
+package web
+
+import "net/http"
+
+// Build information, injected at build time with
+//
+//	go build -ldflags "-X best_trade_logs/internal/web.Version=v1.2.0 -X best_trade_logs/internal/web.Commit=$(git rev-parse --short HEAD) -X best_trade_logs/internal/web.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// as done by `make build`. Plain `go run` builds report the defaults.
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// versionInfo is the body of GET /version.
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+}
+
+// handleVersion reports which build is running, to tell deployed instances apart.
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, versionInfo{Version: Version, Commit: Commit, BuildTime: BuildTime})
+}
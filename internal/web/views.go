@@ -0,0 +1,89 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	tradesvc "best_trade_logs/internal/service/trade"
+	"best_trade_logs/internal/storage"
+)
+
+// savedViewLink is a saved view rendered as a quick link on the trade list.
+// Active marks the view whose filters are the ones currently applied.
+type savedViewLink struct {
+	Name   string
+	Href   template.URL
+	Active bool
+}
+
+// savedViewLinks lists the saved views as links, flagging the one matching the
+// current filter query.
+func (s *Server) savedViewLinks(ctx context.Context, current string) []savedViewLink {
+	views, err := s.svc.SavedViews(ctx)
+	if err != nil {
+		log.Printf("saved views lookup: %v", err)
+		return nil
+	}
+	links := make([]savedViewLink, 0, len(views))
+	for _, v := range views {
+		links = append(links, savedViewLink{Name: v.Name, Href: template.URL("/?" + v.Query), Active: v.Query == current})
+	}
+	return links
+}
+
+// handleSaveView stores the posted filter query under a name. The query is
+// parsed and serialised again, so only known filters are kept.
+func (s *Server) handleSaveView(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	values, err := url.ParseQuery(r.PostFormValue("query"))
+	if err != nil {
+		http.Error(w, "篩選條件格式錯誤", http.StatusBadRequest)
+		return
+	}
+	query := parseFilterValues(values).Query()
+	name := strings.TrimSpace(r.PostFormValue("name"))
+	if err := s.svc.SaveView(r.Context(), storage.SavedView{Name: name, Query: query}); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, tradesvc.ErrInvalidView) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	flash := url.QueryEscape(fmt.Sprintf("已儲存檢視「%s」", name))
+	if query != "" {
+		query += "&"
+	}
+	http.Redirect(w, r, "/?"+query+"flash="+flash, http.StatusSeeOther)
+}
+
+// handleDeleteView removes the saved view named in the form.
+func (s *Server) handleDeleteView(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	name := r.FormValue("name")
+	if err := s.svc.DeleteView(r.Context(), name); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrViewNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/?flash=%s", url.QueryEscape(fmt.Sprintf("已刪除檢視「%s」", strings.TrimSpace(name)))), http.StatusSeeOther)
+}